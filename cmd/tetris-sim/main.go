@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"goeluosifangkuai/internal/ai"
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/sim"
+)
+
+func main() {
+	config := sim.DefaultConfig()
+	config.Workers = runtime.NumCPU()
+
+	modeIDs := make([]string, 0, len(game.Modes()))
+	for _, info := range game.Modes() {
+		modeIDs = append(modeIDs, info.ID)
+	}
+
+	// 命令行参数
+	flag.IntVar(&config.Games, "games", config.Games, "游戏局数")
+	flag.Int64Var(&config.Seed, "seed", config.Seed, "第一局的种子，之后每局加 1")
+	flag.StringVar(&config.Bot, "bot", config.Bot, "电脑玩家: "+strings.Join(sim.Bots(), ", "))
+	flag.StringVar(&config.Mode, "mode", config.Mode, "游戏模式: "+strings.Join(modeIDs, ", "))
+	flag.IntVar(&config.MaxPieces, "pieces", config.MaxPieces, "每局最多放置的方块数，0 表示不限（禅、操作练习和全消练习模式必须限制）")
+	flag.IntVar(&config.PieceTime, "piece-time", config.PieceTime, "每放置一个方块经过的游戏时间（毫秒）")
+	flag.IntVar(&config.Workers, "workers", config.Workers, "并行进行游戏的 goroutine 数")
+	weightsFile := flag.String("weights", "", "特征评估的权重文件，默认使用内置权重")
+	pieceSetFile := flag.String("piece-set", "", "方块集文件，默认使用标准的七种四连方块")
+	big := flag.Bool("big", false, "使用大方块变体")
+	format := flag.String("format", "json", "输出格式: json 或 csv")
+	output := flag.String("out", "", "输出文件，默认输出到标准输出")
+	summaryOutput := flag.String("summary-out", "", "将统计摘要以 CSV 格式另外输出到该文件（表头和一行数据）")
+	flag.Parse()
+
+	if err := configure(&config, *weightsFile, *pieceSetFile, *big); err != nil {
+		fail(err)
+	}
+	if *format != "json" && *format != "csv" {
+		fail(fmt.Errorf("未知的输出格式: %s", *format))
+	}
+
+	start := time.Now()
+	results, err := sim.Run(config)
+	if err != nil {
+		fail(err)
+	}
+	report := sim.NewReport(config, results)
+
+	// 统计摘要输出到标准错误，不影响重定向的结果
+	summary := report.Summary
+	fmt.Fprintf(os.Stderr, "%d 局 %s / %s，用时 %.1f 秒：平均 %.1f 行（中位 %.1f，%d-%d），平均 %.0f 分，%.2f PPS（游戏时间），实际 %.1f PPS，堆到顶部 %d 局\n",
+		summary.Games, config.Mode, config.Bot, time.Since(start).Seconds(),
+		summary.MeanLines, summary.MedianLines, summary.MinLines, summary.MaxLines,
+		summary.MeanScore, summary.MeanPPS, summary.MeanWallPPS, summary.GameOvers)
+
+	if *summaryOutput != "" {
+		if err := writeSummary(*summaryOutput, summary); err != nil {
+			fail(err)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *format == "csv" {
+		err = sim.WriteCSV(w, results)
+	} else {
+		err = sim.WriteJSON(w, report)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// configure 按命令行参数加载权重、方块集和规则变体
+func configure(config *sim.Config, weightsFile, pieceSetFile string, big bool) error {
+	if config.Games < 1 {
+		return fmt.Errorf("games 至少为 1")
+	}
+	if weightsFile != "" {
+		weights, err := ai.LoadWeights(weightsFile)
+		if err != nil {
+			return err
+		}
+		config.Weights = weights
+	}
+	if pieceSetFile != "" {
+		set, err := game.LoadPieceSet(pieceSetFile)
+		if err != nil {
+			return err
+		}
+		config.Game.PieceSet = set
+	}
+	if big {
+		config.Game = game.BigGameConfig(config.Game)
+	}
+	return nil
+}
+
+// writeSummary 将统计摘要以 CSV 格式写入文件
+func writeSummary(path string, summary sim.Summary) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := sim.WriteSummaryCSV(file, summary); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// fail 输出错误并退出
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "错误:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"goeluosifangkuai/internal/history"
+	"goeluosifangkuai/internal/profile"
+)
+
+func main() {
+	profileName := flag.String("profile", "", "玩家档案名，默认导出不使用档案时的游戏历史")
+	historyFile := flag.String("history", "", "游戏历史文件，指定时忽略 -profile")
+	mode := flag.String("mode", "", "只导出指定模式的游戏")
+	list := flag.Bool("list", false, "列出所有玩家档案")
+	format := flag.String("format", "csv", "输出格式: csv 或 json")
+	output := flag.String("out", "", "输出文件，默认输出到标准输出")
+	flag.Parse()
+
+	if *list {
+		if err := listProfiles(); err != nil {
+			fail(err)
+		}
+		return
+	}
+	if *format != "json" && *format != "csv" {
+		fail(fmt.Errorf("未知的输出格式: %s", *format))
+	}
+
+	path, err := historyPath(*historyFile, *profileName)
+	if err != nil {
+		fail(err)
+	}
+	records, skipped, err := history.Load(path)
+	if err != nil {
+		fail(err)
+	}
+	if *mode != "" {
+		var filtered []history.Record
+		for _, r := range records {
+			if r.Mode == *mode {
+				filtered = append(filtered, r)
+			}
+		}
+		records = filtered
+	}
+
+	// 摘要输出到标准错误，不影响重定向的结果
+	fmt.Fprintf(os.Stderr, "%s：导出 %d 局", path, len(records))
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "，跳过 %d 行损坏的记录", skipped)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *format == "csv" {
+		err = history.WriteCSV(w, records)
+	} else {
+		err = history.WriteJSON(w, *profileName, records)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// historyPath 返回要导出的游戏历史文件
+func historyPath(historyFile, profileName string) (string, error) {
+	if historyFile != "" {
+		return historyFile, nil
+	}
+	if profileName == "" {
+		return history.DefaultPath()
+	}
+
+	root, err := profile.Root()
+	if err != nil {
+		return "", err
+	}
+	profiles, err := profile.List(root)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range profiles {
+		if p.Name == profileName {
+			return p.HistoryPath(), nil
+		}
+	}
+	return "", fmt.Errorf("找不到档案: %s", profileName)
+}
+
+// listProfiles 输出所有玩家档案的名字和累计统计
+func listProfiles() error {
+	root, err := profile.Root()
+	if err != nil {
+		return err
+	}
+	profiles, err := profile.List(root)
+	if err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		fmt.Printf("%s\t%d 局\t%d 行\t最近使用 %s\n", p.Name, p.Stats.GamesPlayed, p.Stats.TotalLines, p.LastUsed.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// fail 输出错误并退出
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "错误:", err)
+	os.Exit(1)
+}
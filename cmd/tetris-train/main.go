@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"goeluosifangkuai/internal/ai"
+	"goeluosifangkuai/pkg/types"
+)
+
+func main() {
+	config := ai.DefaultTrainConfig()
+	config.Workers = runtime.NumCPU()
+
+	// 命令行参数
+	flag.IntVar(&config.Population, "population", config.Population, "每一代的个体数")
+	flag.IntVar(&config.Generations, "generations", config.Generations, "训练的代数")
+	flag.IntVar(&config.Games, "games", config.Games, "每个个体每一代进行的游戏局数")
+	flag.IntVar(&config.MaxPieces, "pieces", config.MaxPieces, "每局最多放置的方块数")
+	flag.IntVar(&config.Elite, "elite", config.Elite, "直接保留到下一代的最优个体数")
+	flag.Float64Var(&config.MutationRate, "mutation", config.MutationRate, "后代发生变异的概率")
+	flag.IntVar(&config.Workers, "workers", config.Workers, "并行进行游戏的 goroutine 数")
+	flag.Int64Var(&config.Seed, "seed", config.Seed, "随机种子，相同的种子得到相同的结果")
+	output := flag.String("out", types.AIWeightsFile, "最优权重的输出文件")
+	flag.Parse()
+
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("种群 %d，共 %d 代，每个个体 %d 局，每局最多 %d 个方块，%d 个并行\n",
+		config.Population, config.Generations, config.Games, config.MaxPieces, config.Workers)
+
+	// 每一代报告所有游戏消除行数的分布，并保存当前最优权重，中途停止也不会丢失结果
+	best, err := ai.Train(config, func(report ai.GenerationReport) {
+		fmt.Printf("第 %3d 代  最优 %7.1f 行/局  |  最少 %d  25%% %d  中位 %d  75%% %d  最多 %d  平均 %.1f\n",
+			report.Generation, report.BestFitness,
+			report.Percentile(0), report.Percentile(25), report.Percentile(50),
+			report.Percentile(75), report.Percentile(100), report.Mean())
+		if err := ai.SaveWeights(*output, report.Best); err != nil {
+			fmt.Fprintln(os.Stderr, "保存权重失败:", err)
+			os.Exit(1)
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("最优权重已写入 %s\n%+v\n", *output, best)
+}
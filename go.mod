@@ -2,8 +2,9 @@ module goeluosifangkuai
 
 go 1.19
 
+require fyne.io/fyne/v2 v2.6.3
+
 require (
-	fyne.io/fyne/v2 v2.6.3 // indirect
 	fyne.io/systray v1.11.0 // indirect
 	github.com/BurntSushi/toml v1.4.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
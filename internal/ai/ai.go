@@ -0,0 +1,113 @@
+// Package ai 实现电脑玩家的放置搜索
+package ai
+
+import (
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// topOutPenalty 放置后堆到危险区域时扣除的分数，保证只在别无选择时才会这样放置
+const topOutPenalty = 1e6
+
+// Config 电脑玩家配置
+type Config struct {
+	Weights   Weights // 特征权重
+	Lookahead bool    // 是否同时考虑下一个方块的最佳放置
+}
+
+// DefaultConfig 返回默认电脑玩家配置
+func DefaultConfig() Config {
+	return Config{
+		Weights:   DefaultWeights(),
+		Lookahead: true,
+	}
+}
+
+// Placement 一个可以到达的最终放置
+type Placement struct {
+	Piece  game.Tetromino // 落地时的方块（位置和旋转状态）
+	Inputs []types.Input  // 从出现位置到达该放置的操作序列，以快速下降结束
+	TSpin  game.TSpinType // 按操作序列放置时的 T-spin 类型
+	Lines  int            // 放置后消除的行数
+	Score  float64        // 评估得分，越高越好
+
+	result game.Board // 放置并消行后的棋盘
+}
+
+// Player 电脑玩家
+type Player interface {
+	// Placements 用路径搜索枚举方块在棋盘上所有可以到达的最终放置（包括滑入和旋入）并评估得分
+	Placements(board game.Board, piece game.Tetromino) []Placement
+
+	// FindBest 返回当前方块的最佳放置，next 不为 nil 且开启前瞻时同时考虑下一个方块
+	FindBest(board game.Board, current, next game.Tetromino) (Placement, bool)
+
+	// Play 计算并执行当前方块的最佳放置，返回 false 表示没有可用的放置
+	Play(g game.Game) bool
+}
+
+// player 是 Player 接口的具体实现
+type player struct {
+	config Config
+}
+
+// NewPlayer 创建电脑玩家
+func NewPlayer(config Config) Player {
+	return &player{config: config}
+}
+
+// Placements 枚举方块在棋盘上所有可以到达的最终放置并评估得分
+func (p *player) Placements(board game.Board, piece game.Tetromino) []Placement {
+	var placements []Placement
+	for _, path := range game.FindPaths(board, piece) {
+		candidate := Placement{Piece: path.Piece, Inputs: path.Inputs, TSpin: path.TSpin}
+		result, features := PlacePiece(board, candidate.Piece)
+		candidate.result = result
+		candidate.Lines = features.LinesCleared
+		candidate.Score = p.config.Weights.Score(features)
+		if result.IsGameOver() {
+			candidate.Score -= topOutPenalty
+		}
+		placements = append(placements, candidate)
+	}
+	return placements
+}
+
+// FindBest 返回当前方块的最佳放置，next 不为 nil 且开启前瞻时同时考虑下一个方块
+func (p *player) FindBest(board game.Board, current, next game.Tetromino) (Placement, bool) {
+	if current == nil {
+		return Placement{}, false
+	}
+
+	var best Placement
+	found := false
+	for _, placement := range p.Placements(board, current) {
+		score := placement.Score
+		if p.config.Lookahead && next != nil {
+			if nextBest, ok := p.FindBest(placement.result, next, nil); ok {
+				score += nextBest.Score
+			} else {
+				score -= topOutPenalty
+			}
+		}
+
+		if !found || score > best.Score {
+			best = placement
+			best.Score = score
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Play 计算并执行当前方块的最佳放置，返回 false 表示没有可用的放置
+func (p *player) Play(g game.Game) bool {
+	best, ok := p.FindBest(g.GetBoard(), g.GetCurrentTetromino(), g.GetNextTetromino())
+	if !ok {
+		return false
+	}
+	for _, input := range best.Inputs {
+		g.ApplyInput(input)
+	}
+	return true
+}
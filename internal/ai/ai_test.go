@@ -0,0 +1,116 @@
+// Package ai 提供电脑玩家的单元测试
+package ai
+
+import (
+	"testing"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+func TestBoardFeatures(t *testing.T) {
+	board := game.NewBoard(4, 4)
+	// 第 0 列高 2 且下方有一个空洞，第 2 列高 1
+	board.SetCell(0, 2, types.ColorGarbage)
+	board.SetCell(2, 3, types.ColorGarbage)
+
+	var features Features
+	computeBoardFeatures(board, &features)
+
+	if features.Holes != 1 {
+		t.Errorf("空洞数应为 1，实际为 %d", features.Holes)
+	}
+	if features.AggregateHeight != 3 {
+		t.Errorf("高度之和应为 3，实际为 %d", features.AggregateHeight)
+	}
+	if features.Bumpiness != 4 {
+		t.Errorf("高度差之和应为 4，实际为 %d", features.Bumpiness)
+	}
+}
+
+func TestPlacePieceCountsErodedCells(t *testing.T) {
+	board := game.NewBoard(types.BoardWidth, types.BoardHeight)
+	for x := 0; x < types.BoardWidth-1; x++ {
+		board.SetCell(x, types.BoardHeight-1, types.ColorGarbage)
+	}
+
+	piece := game.NewTetromino(types.TetrominoI).Rotate(types.DirectionRight)
+	piece.SetPosition(types.Position{X: types.BoardWidth - 1, Y: types.BoardHeight - 3})
+
+	result, features := PlacePiece(board, piece)
+	if features.LinesCleared != 1 || features.ErodedCells != 1 {
+		t.Errorf("应消除 1 行且消去 1 个小块，实际为 %d 行 %d 个", features.LinesCleared, features.ErodedCells)
+	}
+	if board.GetCell(0, types.BoardHeight-1) == types.ColorEmpty {
+		t.Errorf("放置不应修改原棋盘")
+	}
+	if result.GetCell(0, types.BoardHeight-1) != types.ColorEmpty {
+		t.Errorf("放置后的棋盘应已消行")
+	}
+}
+
+func TestPlayerPlaysHeadless(t *testing.T) {
+	config := game.DefaultGameConfig()
+	config.Seed = 1
+	g := game.NewGame(config)
+	g.SetState(types.GameStatePlaying)
+
+	player := NewPlayer(DefaultConfig())
+	for i := 0; i < 100 && g.GetState() == types.GameStatePlaying; i++ {
+		if !player.Play(g) {
+			t.Fatalf("第 %d 个方块没有可用的放置", i+1)
+		}
+	}
+
+	if g.GetState() != types.GameStatePlaying {
+		t.Errorf("电脑玩家在 100 个方块内不应堆到顶部")
+	}
+	if g.GetLinesCleared() < 30 {
+		t.Errorf("电脑玩家放置 100 个方块应至少消除 30 行，实际为 %d", g.GetLinesCleared())
+	}
+}
+
+func TestTrainIsDeterministic(t *testing.T) {
+	config := DefaultTrainConfig()
+	config.Population = 4
+	config.Generations = 2
+	config.Games = 2
+	config.MaxPieces = 20
+	config.Elite = 1
+
+	var reports []GenerationReport
+	config.Workers = 1
+	serial, err := Train(config, func(report GenerationReport) {
+		reports = append(reports, report)
+	})
+	if err != nil {
+		t.Fatalf("训练失败: %v", err)
+	}
+	config.Workers = 3
+	parallel, err := Train(config, nil)
+	if err != nil {
+		t.Fatalf("训练失败: %v", err)
+	}
+
+	if serial != parallel {
+		t.Errorf("相同种子的训练结果不应受并行数影响: %+v != %+v", serial, parallel)
+	}
+	if len(reports) != 2 || len(reports[0].Lines) != config.Population*config.Games {
+		t.Fatalf("每一代应报告所有游戏的消除行数")
+	}
+}
+
+func TestTrainRejectsInvalidConfig(t *testing.T) {
+	for _, change := range []func(*TrainConfig){
+		func(c *TrainConfig) { c.Population = 0 },
+		func(c *TrainConfig) { c.Generations = 0 },
+		func(c *TrainConfig) { c.Games = 0 },
+		func(c *TrainConfig) { c.MaxPieces = 0 },
+	} {
+		config := DefaultTrainConfig()
+		change(&config)
+		if _, err := Train(config, nil); err == nil {
+			t.Errorf("无效的训练配置应返回错误: %+v", config)
+		}
+	}
+}
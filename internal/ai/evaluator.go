@@ -0,0 +1,196 @@
+// Package ai 实现基于特征评估的电脑玩家
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// Features 放置方块后棋盘的特征（Dellacherie / El-Tetris）
+type Features struct {
+	LandingHeight     float64 // 方块落地高度（方块中心到底部的行数）
+	ErodedCells       int     // 消除的行数 × 方块在这些行中的小块数
+	LinesCleared      int     // 消除的行数
+	AggregateHeight   int     // 各列高度之和
+	Bumpiness         int     // 相邻列高度差之和
+	Holes             int     // 空洞数：列顶以下的空格
+	RowTransitions    int     // 行内空与非空的交替次数（左右墙壁视为非空）
+	ColumnTransitions int     // 列内空与非空的交替次数（地板视为非空）
+	Wells             int     // 累积井深：两侧都被占据的空格按深度 1+2+…+n 计算
+}
+
+// Weights 各个特征的权重
+type Weights struct {
+	LandingHeight     float64 `json:"landing_height"`
+	ErodedCells       float64 `json:"eroded_cells"`
+	LinesCleared      float64 `json:"lines_cleared"`
+	AggregateHeight   float64 `json:"aggregate_height"`
+	Bumpiness         float64 `json:"bumpiness"`
+	Holes             float64 `json:"holes"`
+	RowTransitions    float64 `json:"row_transitions"`
+	ColumnTransitions float64 `json:"column_transitions"`
+	Wells             float64 `json:"wells"`
+}
+
+// DefaultWeights 返回 El-Tetris 的权重
+func DefaultWeights() Weights {
+	return Weights{
+		LandingHeight:     -4.500158825082766,
+		ErodedCells:       3.4181268101392694,
+		RowTransitions:    -3.2178882868487753,
+		ColumnTransitions: -9.348695305445199,
+		Holes:             -7.899265427351652,
+		Wells:             -3.3855972247263626,
+	}
+}
+
+// Score 按权重计算特征的得分，得分越高越好
+func (w Weights) Score(f Features) float64 {
+	return w.LandingHeight*f.LandingHeight +
+		w.ErodedCells*float64(f.ErodedCells) +
+		w.LinesCleared*float64(f.LinesCleared) +
+		w.AggregateHeight*float64(f.AggregateHeight) +
+		w.Bumpiness*float64(f.Bumpiness) +
+		w.Holes*float64(f.Holes) +
+		w.RowTransitions*float64(f.RowTransitions) +
+		w.ColumnTransitions*float64(f.ColumnTransitions) +
+		w.Wells*float64(f.Wells)
+}
+
+// LoadWeights 从 JSON 文件加载权重
+func LoadWeights(path string) (Weights, error) {
+	var weights Weights
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return weights, fmt.Errorf("读取权重文件失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return weights, fmt.Errorf("解析权重文件失败: %w", err)
+	}
+	return weights, nil
+}
+
+// SaveWeights 将权重写入 JSON 文件
+func SaveWeights(path string, weights Weights) error {
+	data, err := json.MarshalIndent(weights, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// PlacePiece 在棋盘的副本上放置方块并消行，返回放置后的棋盘和特征，原棋盘不变
+func PlacePiece(board game.Board, piece game.Tetromino) (game.Board, Features) {
+	result := game.CloneBoard(board)
+	result.PlaceTetromino(piece)
+
+	var features Features
+	position := piece.GetPosition()
+	blocks := piece.GetBlocks()
+
+	// 落地高度按方块最高和最低小块的中点计算
+	minY, maxY := position.Y+blocks[0].Y, position.Y+blocks[0].Y
+	for _, block := range blocks {
+		y := position.Y + block.Y
+		if y < minY {
+			minY = y
+		}
+		if y > maxY {
+			maxY = y
+		}
+	}
+	features.LandingHeight = float64(result.GetHeight()) - float64(minY+maxY)/2
+
+	// 消行前统计方块在满行中的小块数
+	pieceCells := 0
+	for _, block := range blocks {
+		if y := position.Y + block.Y; y >= 0 && isRowFull(result, y) {
+			pieceCells++
+		}
+	}
+	features.LinesCleared = result.ClearLines()
+	features.ErodedCells = features.LinesCleared * pieceCells
+
+	computeBoardFeatures(result, &features)
+	return result, features
+}
+
+// isRowFull 检查一行是否已满
+func isRowFull(board game.Board, y int) bool {
+	for x := 0; x < board.GetWidth(); x++ {
+		if board.GetCell(x, y) == types.ColorEmpty {
+			return false
+		}
+	}
+	return true
+}
+
+// computeBoardFeatures 统计与棋盘形状有关的特征
+func computeBoardFeatures(board game.Board, features *Features) {
+	width, height := board.GetWidth(), board.GetHeight()
+	filled := func(x, y int) bool {
+		if x < 0 || x >= width || y >= height {
+			return true
+		}
+		return y >= 0 && board.GetCell(x, y) != types.ColorEmpty
+	}
+
+	previousHeight := 0
+	for x := 0; x < width; x++ {
+		// 列高和空洞
+		columnHeight := 0
+		for y := 0; y < height; y++ {
+			if filled(x, y) {
+				if columnHeight == 0 {
+					columnHeight = height - y
+				}
+			} else if columnHeight > 0 {
+				features.Holes++
+			}
+		}
+		features.AggregateHeight += columnHeight
+		if x > 0 {
+			features.Bumpiness += abs(columnHeight - previousHeight)
+		}
+		previousHeight = columnHeight
+
+		// 列内交替，棋盘上方视为空、地板视为非空
+		for y := 0; y <= height; y++ {
+			if filled(x, y) != (y > 0 && filled(x, y-1)) {
+				features.ColumnTransitions++
+			}
+		}
+
+		// 累积井深
+		depth := 0
+		for y := 0; y < height; y++ {
+			if !filled(x, y) && filled(x-1, y) && filled(x+1, y) {
+				depth++
+				features.Wells += depth
+			} else {
+				depth = 0
+			}
+		}
+	}
+
+	// 行内交替，左右墙壁视为非空
+	for y := 0; y < height; y++ {
+		for x := 0; x <= width; x++ {
+			if filled(x, y) != filled(x-1, y) {
+				features.RowTransitions++
+			}
+		}
+	}
+}
+
+// abs 返回整数的绝对值
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
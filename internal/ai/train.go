@@ -0,0 +1,301 @@
+// Package ai 实现评估权重的遗传算法训练
+package ai
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// TrainConfig 遗传算法训练配置
+type TrainConfig struct {
+	Population    int     // 每一代的个体数
+	Generations   int     // 训练的代数
+	Games         int     // 每个个体每一代进行的游戏局数
+	MaxPieces     int     // 每局最多放置的方块数
+	Elite         int     // 直接保留到下一代的最优个体数
+	Tournament    int     // 锦标赛选择中每次参与比较的个体数
+	MutationRate  float64 // 后代发生变异的概率
+	MutationScale float64 // 变异时加到某个权重上的最大偏移
+	Workers       int     // 并行进行游戏的 goroutine 数
+	Seed          int64   // 随机种子，相同的种子得到相同的训练结果
+}
+
+// DefaultTrainConfig 返回默认训练配置
+func DefaultTrainConfig() TrainConfig {
+	return TrainConfig{
+		Population:    30,
+		Generations:   20,
+		Games:         3,
+		MaxPieces:     300,
+		Elite:         3,
+		Tournament:    3,
+		MutationRate:  0.3,
+		MutationScale: 0.2,
+		Workers:       4,
+		Seed:          1,
+	}
+}
+
+// Validate 检查训练配置，population 至少为 2，generations、games 和 pieces 至少为 1
+func (c TrainConfig) Validate() error {
+	if c.Population < 2 || c.Generations < 1 || c.Games < 1 || c.MaxPieces < 1 {
+		return fmt.Errorf("population 至少为 2，generations、games 和 pieces 至少为 1")
+	}
+	return nil
+}
+
+// GenerationReport 一代训练的结果
+type GenerationReport struct {
+	Generation  int
+	Best        Weights // 本代最优个体的权重
+	BestFitness float64 // 最优个体每局的平均消除行数
+	Lines       []int   // 本代所有游戏的消除行数，从小到大排列
+}
+
+// Percentile 返回消除行数分布的百分位数（0 到 100）
+func (r GenerationReport) Percentile(p float64) int {
+	if len(r.Lines) == 0 {
+		return 0
+	}
+	index := int(math.Round(p / 100 * float64(len(r.Lines)-1)))
+	return r.Lines[index]
+}
+
+// Mean 返回本代所有游戏的平均消除行数
+func (r GenerationReport) Mean() float64 {
+	if len(r.Lines) == 0 {
+		return 0
+	}
+	total := 0
+	for _, lines := range r.Lines {
+		total += lines
+	}
+	return float64(total) / float64(len(r.Lines))
+}
+
+// individual 种群中的个体
+type individual struct {
+	weights Weights
+	lines   []int   // 每局的消除行数
+	fitness float64 // 平均消除行数
+}
+
+// Train 用遗传算法优化评估权重，每一代结束后调用 report，返回训练得到的最优权重，配置无效时返回错误。
+// 所有随机选择都在调用者的 goroutine 中进行，各局游戏使用确定的种子，因此结果与并行数无关
+func Train(config TrainConfig, report func(GenerationReport)) (Weights, error) {
+	if err := config.Validate(); err != nil {
+		return Weights{}, err
+	}
+	random := rand.New(rand.NewSource(config.Seed))
+
+	population := make([]*individual, config.Population)
+	population[0] = &individual{weights: normalize(DefaultWeights())}
+	for i := 1; i < len(population); i++ {
+		population[i] = &individual{weights: randomWeights(random)}
+	}
+
+	var best *individual
+	for generation := 1; generation <= config.Generations; generation++ {
+		// 同一代的个体在相同的方块序列上比较
+		seeds := make([]int64, config.Games)
+		for i := range seeds {
+			seeds[i] = random.Int63()
+		}
+		evaluatePopulation(population, seeds, config)
+
+		sort.SliceStable(population, func(i, j int) bool {
+			return population[i].fitness > population[j].fitness
+		})
+		best = population[0]
+
+		if report != nil {
+			var lines []int
+			for _, member := range population {
+				lines = append(lines, member.lines...)
+			}
+			sort.Ints(lines)
+			report(GenerationReport{
+				Generation:  generation,
+				Best:        best.weights,
+				BestFitness: best.fitness,
+				Lines:       lines,
+			})
+		}
+
+		if generation < config.Generations {
+			population = nextGeneration(population, config, random)
+		}
+	}
+
+	return best.weights, nil
+}
+
+// PlayGame 由电脑玩家进行一局游戏，直到堆到顶部或放置了 maxPieces 个方块，返回消除的行数
+func PlayGame(g game.Game, player Player, maxPieces int) int {
+	g.SetState(types.GameStatePlaying)
+	for pieces := 0; pieces < maxPieces && g.GetState() == types.GameStatePlaying; pieces++ {
+		if !player.Play(g) {
+			break
+		}
+	}
+	return g.GetLinesCleared()
+}
+
+// evaluatePopulation 并行地让每个个体在给定的种子上各进行一局游戏
+func evaluatePopulation(population []*individual, seeds []int64, config TrainConfig) {
+	type job struct {
+		member *individual
+		game   int
+	}
+
+	for _, member := range population {
+		member.lines = make([]int, len(seeds))
+	}
+
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				gameConfig := game.DefaultGameConfig()
+				gameConfig.Seed = seeds[j.game]
+				player := NewPlayer(Config{Weights: j.member.weights})
+				// 每个任务只写入自己的位置，不需要加锁
+				j.member.lines[j.game] = PlayGame(game.NewGame(gameConfig), player, config.MaxPieces)
+			}
+		}()
+	}
+
+	for _, member := range population {
+		for i := range seeds {
+			jobs <- job{member: member, game: i}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, member := range population {
+		total := 0
+		for _, lines := range member.lines {
+			total += lines
+		}
+		member.fitness = float64(total) / float64(len(member.lines))
+	}
+}
+
+// nextGeneration 保留最优个体，其余个体由锦标赛选出的父母交叉并变异产生
+func nextGeneration(population []*individual, config TrainConfig, random *rand.Rand) []*individual {
+	next := make([]*individual, 0, len(population))
+	for i := 0; i < config.Elite && i < len(population); i++ {
+		next = append(next, &individual{weights: population[i].weights})
+	}
+
+	for len(next) < len(population) {
+		a := tournament(population, config.Tournament, random)
+		b := tournament(population, config.Tournament, random)
+		child := crossover(a, b)
+		if random.Float64() < config.MutationRate {
+			child = mutate(child, config.MutationScale, random)
+		}
+		next = append(next, &individual{weights: child})
+	}
+	return next
+}
+
+// tournament 随机选出 size 个个体，返回其中最优的一个
+func tournament(population []*individual, size int, random *rand.Rand) *individual {
+	var winner *individual
+	for i := 0; i < size || winner == nil; i++ {
+		candidate := population[random.Intn(len(population))]
+		if winner == nil || candidate.fitness > winner.fitness {
+			winner = candidate
+		}
+	}
+	return winner
+}
+
+// crossover 按父母的适应度加权平均两组权重
+func crossover(a, b *individual) Weights {
+	fa, fb := a.fitness+1, b.fitness+1
+	va, vb := a.weights.vector(), b.weights.vector()
+	child := make([]float64, len(va))
+	for i := range child {
+		child[i] = (va[i]*fa + vb[i]*fb) / (fa + fb)
+	}
+	return normalize(weightsFromVector(child))
+}
+
+// mutate 随机调整其中一个权重
+func mutate(weights Weights, scale float64, random *rand.Rand) Weights {
+	vector := weights.vector()
+	vector[random.Intn(len(vector))] += (random.Float64()*2 - 1) * scale
+	return normalize(weightsFromVector(vector))
+}
+
+// randomWeights 返回各分量在 [-1, 1) 中均匀分布的随机权重
+func randomWeights(random *rand.Rand) Weights {
+	vector := Weights{}.vector()
+	for i := range vector {
+		vector[i] = random.Float64()*2 - 1
+	}
+	return normalize(weightsFromVector(vector))
+}
+
+// normalize 将权重缩放为单位长度，评估只比较相对大小，缩放不改变选择
+func normalize(weights Weights) Weights {
+	vector := weights.vector()
+	length := 0.0
+	for _, v := range vector {
+		length += v * v
+	}
+	if length == 0 {
+		return weights
+	}
+	length = math.Sqrt(length)
+	for i := range vector {
+		vector[i] /= length
+	}
+	return weightsFromVector(vector)
+}
+
+// vector 将权重转换为向量，顺序与 weightsFromVector 一致
+func (w Weights) vector() []float64 {
+	return []float64{
+		w.LandingHeight,
+		w.ErodedCells,
+		w.LinesCleared,
+		w.AggregateHeight,
+		w.Bumpiness,
+		w.Holes,
+		w.RowTransitions,
+		w.ColumnTransitions,
+		w.Wells,
+	}
+}
+
+// weightsFromVector 将向量转换为权重
+func weightsFromVector(v []float64) Weights {
+	return Weights{
+		LandingHeight:     v[0],
+		ErodedCells:       v[1],
+		LinesCleared:      v[2],
+		AggregateHeight:   v[3],
+		Bumpiness:         v[4],
+		Holes:             v[5],
+		RowTransitions:    v[6],
+		ColumnTransitions: v[7],
+		Wells:             v[8],
+	}
+}
@@ -0,0 +1,287 @@
+// Package fumen 实现社区通用的 fumen（v115）局面文本格式的编码和解码。
+// 只处理第一页：棋盘和注释，方块操作只读取方块类型，不支持多页的方块操作和行上升
+package fumen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// 棋盘尺寸：23 行可见区域，下面还有一行用于行上升的缓冲行
+const (
+	Width  = 10
+	Height = 23
+
+	fieldBlocks = (Height + 1) * Width
+)
+
+// 方块操作中的标志，操作的值为 方块 + 8×(旋转 + 4×(位置 + fieldBlocks×标志))
+const (
+	actionScale   = 8 * 4 * fieldBlocks
+	flagColorize  = 4 // 使用标准配色
+	flagComment   = 8 // 这一页有新的注释
+	commentDigits = 5 // 每 4 个注释字符编码为 5 个字符
+)
+
+// prefixes 支持的版本前缀，v 为查看、m 为移动端、d 为编辑数据，格式相同
+var prefixes = []string{"v115@", "m115@", "d115@"}
+
+// base64Table fumen 使用的 64 进制字符
+const base64Table = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// commentTable 注释使用的字符，注释先按 JavaScript 的 escape 转换为 ASCII，每个字符的值为在表中的下标
+const commentTable = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+
+// fumen 中的方块编号：0 为空，1-7 依次为 I、L、O、Z、T、J、S，8 为灰色
+var blockColors = []types.Color{
+	types.ColorEmpty, types.ColorI, types.ColorL, types.ColorO, types.ColorZ,
+	types.ColorT, types.ColorJ, types.ColorS, types.ColorGarbage,
+}
+
+// blockOf 返回颜色对应的 fumen 方块编号，自定义颜色作为灰色
+func blockOf(color types.Color) int {
+	for block, c := range blockColors {
+		if c == color {
+			return block
+		}
+	}
+	return 8
+}
+
+// Page fumen 的一页
+type Page struct {
+	Field   [][]types.Color // Height 行，从上到下排列，每行 Width 格
+	Piece   types.Color     // 这一页操作的方块，没有时为 ColorEmpty；只在解码时读取，编码时不写入方块操作
+	Comment string
+}
+
+// NewPage 创建棋盘为空的页
+func NewPage() Page {
+	field := make([][]types.Color, Height)
+	for y := range field {
+		field[y] = make([]types.Color, Width)
+	}
+	return Page{Field: field}
+}
+
+// Encode 将一页编码为 v115 格式的文本
+func Encode(page Page) (string, error) {
+	if len(page.Field) != Height {
+		return "", fmt.Errorf("fumen 棋盘应为 %d 行，实际为 %d 行", Height, len(page.Field))
+	}
+	blocks := make([]int, fieldBlocks)
+	for y, row := range page.Field {
+		if len(row) != Width {
+			return "", fmt.Errorf("fumen 棋盘应为 %d 列，实际为 %d 列", Width, len(row))
+		}
+		for x, color := range row {
+			blocks[y*Width+x] = blockOf(color)
+		}
+	}
+
+	var data []byte
+	poll := func(value, digits int) {
+		for i := 0; i < digits; i++ {
+			data = append(data, base64Table[value%64])
+			value /= 64
+		}
+	}
+
+	// 棋盘按与上一页（第一页为空棋盘）的差值游程编码，差值加 8 使其非负
+	for start := 0; start < fieldBlocks; {
+		end := start + 1
+		for end < fieldBlocks && blocks[end] == blocks[start] {
+			end++
+		}
+		poll((blocks[start]+8)*fieldBlocks+end-start-1, 2)
+		start = end
+	}
+	if blocks[0] == 0 && len(data) == 2 {
+		// 与上一页相同的棋盘后面跟着重复的页数
+		poll(0, 1)
+	}
+
+	// 方块操作：依次为方块、旋转、位置和行上升、镜像、标准配色、注释、不固定的标志，这里没有方块
+	comment := escape(page.Comment)
+	flags := flagColorize
+	if comment != "" {
+		flags |= flagComment
+	}
+	poll(actionScale*flags, 3)
+
+	if comment != "" {
+		if len(comment) >= 64*64 {
+			return "", fmt.Errorf("注释太长")
+		}
+		poll(len(comment), 2)
+		for i := 0; i < len(comment); i += 4 {
+			value, scale := 0, 1
+			for j := i; j < i+4 && j < len(comment); j++ {
+				value += strings.IndexByte(commentTable, comment[j]) * scale
+				scale *= len(commentTable) + 1
+			}
+			poll(value, commentDigits)
+		}
+	}
+
+	// 与官方编辑器一样，第一段 42 个字符，之后每 47 个字符插入一个 '?'
+	text := string(data)
+	if len(text) > 42 {
+		parts := []string{text[:42]}
+		for rest := text[42:]; rest != ""; {
+			n := 47
+			if len(rest) < n {
+				n = len(rest)
+			}
+			parts = append(parts, rest[:n])
+			rest = rest[n:]
+		}
+		text = strings.Join(parts, "?")
+	}
+	return prefixes[0] + text, nil
+}
+
+// Decode 解码 v115 格式文本的第一页，文本可以是完整的网址
+func Decode(text string) (Page, error) {
+	text = strings.TrimSpace(text)
+	index := strings.Index(text, "115@")
+	if index < 1 || !contains(prefixes, text[index-1:index+4]) {
+		return Page{}, fmt.Errorf("不是 v115 格式的 fumen")
+	}
+	data := strings.NewReplacer("?", "", "\n", "", "\r", "", " ", "").Replace(text[index+4:])
+
+	pos := 0
+	read := func(digits int) (int, error) {
+		if pos+digits > len(data) {
+			return 0, fmt.Errorf("fumen 数据不完整")
+		}
+		value, scale := 0, 1
+		for i := 0; i < digits; i++ {
+			v := strings.IndexByte(base64Table, data[pos+i])
+			if v < 0 {
+				return 0, fmt.Errorf("fumen 中有无效的字符 %q", data[pos+i])
+			}
+			value += v * scale
+			scale *= 64
+		}
+		pos += digits
+		return value, nil
+	}
+
+	blocks := make([]int, fieldBlocks)
+	for i := 0; i < fieldBlocks; {
+		value, err := read(2)
+		if err != nil {
+			return Page{}, err
+		}
+		diff, count := value/fieldBlocks-8, value%fieldBlocks+1
+		if i+count > fieldBlocks {
+			return Page{}, fmt.Errorf("fumen 棋盘数据无效")
+		}
+		for ; count > 0; count-- {
+			blocks[i] = diff
+			i++
+		}
+		if value == 8*fieldBlocks+fieldBlocks-1 {
+			// 与上一页相同的棋盘后面跟着重复的页数
+			if _, err := read(1); err != nil {
+				return Page{}, err
+			}
+		}
+	}
+
+	page := NewPage()
+	for i, block := range blocks[:Height*Width] {
+		if block < 0 || block >= len(blockColors) {
+			return Page{}, fmt.Errorf("fumen 中有无效的方块 %d", block)
+		}
+		page.Field[i/Width][i%Width] = blockColors[block]
+	}
+
+	action, err := read(3)
+	if err != nil {
+		return Page{}, err
+	}
+	if block := action % 8; block >= 1 && block <= 7 {
+		page.Piece = blockColors[block]
+	}
+	if flags := action / actionScale; flags&flagComment != 0 {
+		length, err := read(2)
+		if err != nil {
+			return Page{}, err
+		}
+		escaped := make([]byte, 0, length+3)
+		for len(escaped) < length {
+			value, err := read(commentDigits)
+			if err != nil {
+				return Page{}, err
+			}
+			for j := 0; j < 4; j++ {
+				c := value % (len(commentTable) + 1)
+				if c >= len(commentTable) {
+					return Page{}, fmt.Errorf("fumen 注释数据无效")
+				}
+				escaped = append(escaped, commentTable[c])
+				value /= len(commentTable) + 1
+			}
+		}
+		page.Comment = unescape(string(escaped[:length]))
+	}
+	return page, nil
+}
+
+// contains 判断字符串是否在列表中
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// escape 与 JavaScript 的 escape 相同：字母、数字和 @*_+-./ 不变，其他字符转换为 %XX 或 %uXXXX
+func escape(s string) string {
+	var b strings.Builder
+	for _, unit := range utf16.Encode([]rune(s)) {
+		switch {
+		case unit < 128 && (unit >= 'A' && unit <= 'Z' || unit >= 'a' && unit <= 'z' || unit >= '0' && unit <= '9' ||
+			strings.ContainsRune("@*_+-./", rune(unit))):
+			b.WriteByte(byte(unit))
+		case unit < 256:
+			fmt.Fprintf(&b, "%%%02X", unit)
+		default:
+			fmt.Fprintf(&b, "%%u%04X", unit)
+		}
+	}
+	return b.String()
+}
+
+// unescape 与 JavaScript 的 unescape 相同，无法识别的转义保持原样
+func unescape(s string) string {
+	var units []uint16
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' {
+			if i+6 <= len(s) && s[i+1] == 'u' {
+				if unit, err := strconv.ParseUint(s[i+2:i+6], 16, 16); err == nil {
+					units = append(units, uint16(unit))
+					i += 5
+					continue
+				}
+			}
+			if i+3 <= len(s) {
+				if unit, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+					units = append(units, uint16(unit))
+					i += 2
+					continue
+				}
+			}
+		}
+		units = append(units, uint16(s[i]))
+	}
+	return string(utf16.Decode(units))
+}
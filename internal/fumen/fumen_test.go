@@ -0,0 +1,109 @@
+package fumen
+
+import (
+	"strings"
+	"testing"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+func TestEncodeEmptyField(t *testing.T) {
+	text, err := Encode(NewPage())
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if text != "v115@vhAAgH" {
+		t.Errorf("空棋盘应编码为 v115@vhAAgH，实际为 %s", text)
+	}
+}
+
+func TestDecodeKnownField(t *testing.T) {
+	// 底部 4 行左边 6 格为灰色方块
+	page, err := Decode("https://fumen.zui.jp/?v115@9gF8DeF8DeF8DeF8NeAgH")
+	if err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			expected := types.ColorEmpty
+			if y >= Height-4 && x < 6 {
+				expected = types.ColorGarbage
+			}
+			if page.Field[y][x] != expected {
+				t.Fatalf("(%d, %d) 应为 %v，实际为 %v", x, y, expected, page.Field[y][x])
+			}
+		}
+	}
+	if page.Piece != types.ColorEmpty || page.Comment != "" {
+		t.Errorf("不应有方块和注释: %+v", page)
+	}
+}
+
+func TestRoundTripWithComment(t *testing.T) {
+	page := NewPage()
+	page.Field[Height-1] = []types.Color{
+		types.ColorI, types.ColorI, types.ColorI, types.ColorI, types.ColorEmpty,
+		types.ColorL, types.ColorO, types.ColorZ, types.ColorT, types.ColorJ,
+	}
+	page.Field[Height-2][9] = types.ColorS
+	page.Field[0][0] = types.ColorGarbage
+	// 注释足够长，编码结果中会插入 '?'
+	page.Comment = "#Q=[T](I)OSZJL 中文注释 with spaces and symbols !\"#$%&"
+
+	text, err := Encode(page)
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	decoded, err := Decode(text)
+	if err != nil {
+		t.Fatalf("解码 %s 失败: %v", text, err)
+	}
+	if decoded.Comment != page.Comment {
+		t.Errorf("注释应为 %q，实际为 %q", page.Comment, decoded.Comment)
+	}
+	for y := range page.Field {
+		for x := range page.Field[y] {
+			if decoded.Field[y][x] != page.Field[y][x] {
+				t.Fatalf("(%d, %d) 应为 %v，实际为 %v", x, y, page.Field[y][x], decoded.Field[y][x])
+			}
+		}
+	}
+}
+
+func TestEncodeNearSplitLength(t *testing.T) {
+	// 底部两行每隔一格放一个灰色方块，编码后正好 41 个字符，不需要插入 '?'
+	page := NewPage()
+	for x := 0; x < Width-1; x += 2 {
+		page.Field[Height-1][x] = types.ColorGarbage
+		if x < Width-2 {
+			page.Field[Height-2][x] = types.ColorGarbage
+		}
+	}
+
+	text, err := Encode(page)
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if data := text[len("v115@"):]; len(data) != 41 || strings.Contains(data, "?") {
+		t.Errorf("应编码为不含 '?' 的 41 个字符，实际为 %s（%d 个字符）", data, len(data))
+	}
+	decoded, err := Decode(text)
+	if err != nil {
+		t.Fatalf("解码 %s 失败: %v", text, err)
+	}
+	for y := range page.Field {
+		for x := range page.Field[y] {
+			if decoded.Field[y][x] != page.Field[y][x] {
+				t.Fatalf("(%d, %d) 应为 %v，实际为 %v", x, y, page.Field[y][x], decoded.Field[y][x])
+			}
+		}
+	}
+}
+
+func TestDecodeRejectsInvalidText(t *testing.T) {
+	for _, text := range []string{"", "v110@vhAAgH", "v115@vh", "v115@!!AAgH"} {
+		if _, err := Decode(text); err == nil {
+			t.Errorf("%q 应解码失败", text)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package fyneui 提供电脑玩家的自动演示和对手
+package fyneui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/ai"
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// 电脑玩家每次操作的间隔（毫秒），对手比自动演示慢，给玩家留出追赶的机会
+const (
+	autoplayInputInterval = 50
+	opponentInputInterval = 150
+)
+
+// aiController 按固定的间隔逐个执行电脑玩家规划的操作，使每一步都能在画面上看到
+type aiController struct {
+	player   ai.Player
+	interval int           // 每次操作的间隔（毫秒）
+	timer    int           // 距上一次操作经过的时间（毫秒）
+	inputs   []types.Input // 当前方块剩余的操作
+}
+
+// newAIPlayer 创建电脑玩家，存在训练得到的权重文件时使用其中的权重
+func newAIPlayer() ai.Player {
+	config := ai.DefaultConfig()
+	if weights, err := ai.LoadWeights(types.AIWeightsFile); err == nil {
+		config.Weights = weights
+	}
+	return ai.NewPlayer(config)
+}
+
+// newAIController 创建电脑玩家控制器
+func newAIController(interval int) *aiController {
+	return &aiController{
+		player:   newAIPlayer(),
+		interval: interval,
+	}
+}
+
+// reset 丢弃尚未执行的操作
+func (c *aiController) reset() {
+	c.timer = 0
+	c.inputs = nil
+}
+
+// update 按经过的时间执行操作，当前方块的操作执行完后为下一个方块重新规划
+func (c *aiController) update(gameInstance game.Game, deltaTime int) {
+	c.timer += deltaTime
+	for c.timer >= c.interval {
+		c.timer -= c.interval
+
+		// 出现延迟期间没有当前方块，等待新方块出现
+		if gameInstance.GetState() != types.GameStatePlaying || gameInstance.GetCurrentTetromino() == nil {
+			c.reset()
+			return
+		}
+
+		if len(c.inputs) == 0 {
+			best, ok := c.player.FindBest(gameInstance.GetBoard(), gameInstance.GetCurrentTetromino(), gameInstance.GetNextTetromino())
+			if !ok {
+				return
+			}
+			c.inputs = best.Inputs
+		}
+
+		gameInstance.ApplyInput(c.inputs[0])
+		c.inputs = c.inputs[1:]
+	}
+}
+
+// createAIControls 创建自动演示和 AI 对手的开关
+func (ui *GameUI) createAIControls() {
+	ui.autoplay = newAIController(autoplayInputInterval)
+	ui.autoCheck = widget.NewCheck("自动演示", func(bool) {
+		ui.autoplay.reset()
+	})
+	ui.opponentCheck = widget.NewCheck("AI 对手", func(checked bool) {
+		if checked {
+			ui.opponentPanel.Show()
+		} else {
+			ui.opponentPanel.Hide()
+		}
+	})
+}
+
+// createOpponentPanel 创建显示 AI 对手棋盘的面板，默认隐藏
+func (ui *GameUI) createOpponentPanel() {
+	var grid *fyne.Container
+	ui.opponentCells, grid = createBoardGrid(10, 2)
+	ui.opponentLabel = widget.NewLabel("AI 对手")
+
+	ui.opponentPanel = container.NewVBox(
+		ui.opponentLabel,
+		container.NewGridWrap(grid.Size(), grid),
+	)
+	ui.opponentPanel.Hide()
+}
+
+// startOpponent 开局时按相同的配置和模式创建 AI 对手的游戏
+func (ui *GameUI) startOpponent() {
+	ui.opponent = nil
+	if !ui.opponentCheck.Checked {
+		return
+	}
+
+	ui.opponent = game.NewGameWithMode(ui.game.GetConfig(), ui.newMode())
+	ui.opponent.SetState(types.GameStatePlaying)
+	ui.opponentAI = newAIController(opponentInputInterval)
+}
+
+// updateAI 推进自动演示和 AI 对手
+func (ui *GameUI) updateAI(deltaTime int) {
+	if ui.autoCheck.Checked {
+		ui.autoplay.update(ui.game, deltaTime)
+	}
+
+	if ui.opponent == nil {
+		return
+	}
+	if ui.opponent.GetState() == types.GameStatePlaying {
+		ui.opponentAI.update(ui.opponent, deltaTime)
+		ui.opponent.Update(deltaTime)
+	}
+	ui.updateOpponentDisplay()
+}
+
+// updateOpponentDisplay 更新 AI 对手的棋盘和分数
+func (ui *GameUI) updateOpponentDisplay() {
+	text := fmt.Sprintf("AI 对手  分数: %d  行数: %d", ui.opponent.GetScore(), ui.opponent.GetLinesCleared())
+	if isGameEnded(ui.opponent.GetState()) {
+		text += "（已结束）"
+	}
+	fyne.DoAndWait(func() {
+		ui.opponentLabel.SetText(text)
+	})
+	ui.drawBoard(ui.opponentCells, ui.opponent, nil)
+}
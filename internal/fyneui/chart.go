@@ -0,0 +1,137 @@
+// Package fyneui 提供统计界面使用的折线图
+package fyneui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// chartPadding 折线图四周留给坐标标签的空间
+const chartPadding = 40
+
+// lineChart 按顺序连接各个数值的折线图，横轴为序号，纵轴标出最小值和最大值
+type lineChart struct {
+	widget.BaseWidget
+	values []float64
+	format func(value float64) string // 纵轴标签的格式
+}
+
+// newLineChart 创建折线图
+func newLineChart(values []float64, format func(value float64) string) *lineChart {
+	chart := &lineChart{values: values, format: format}
+	chart.ExtendBaseWidget(chart)
+	return chart
+}
+
+// SetValues 替换图中的数值
+func (c *lineChart) SetValues(values []float64) {
+	c.values = values
+	c.Refresh()
+}
+
+// CreateRenderer 创建折线图的渲染器
+func (c *lineChart) CreateRenderer() fyne.WidgetRenderer {
+	return &lineChartRenderer{chart: c}
+}
+
+// lineChartRenderer 每次布局时按当前尺寸重新生成线段和标签
+type lineChartRenderer struct {
+	chart   *lineChart
+	objects []fyne.CanvasObject
+}
+
+// Layout 按尺寸生成坐标轴、折线和标签
+func (r *lineChartRenderer) Layout(size fyne.Size) {
+	r.objects = nil
+	foreground := theme.Color(theme.ColorNameForeground)
+	primary := theme.Color(theme.ColorNamePrimary)
+
+	left, top := float32(chartPadding), float32(chartPadding/2)
+	width, height := size.Width-left-chartPadding/2, size.Height-top-chartPadding/2
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	xAxis := canvas.NewLine(foreground)
+	xAxis.Position1 = fyne.NewPos(left, top+height)
+	xAxis.Position2 = fyne.NewPos(left+width, top+height)
+	yAxis := canvas.NewLine(foreground)
+	yAxis.Position1 = fyne.NewPos(left, top)
+	yAxis.Position2 = fyne.NewPos(left, top+height)
+	r.objects = append(r.objects, xAxis, yAxis)
+
+	values := r.chart.values
+	if len(values) == 0 {
+		empty := canvas.NewText("暂无数据", foreground)
+		empty.Move(fyne.NewPos(left+width/2-empty.MinSize().Width/2, top+height/2))
+		r.objects = append(r.objects, empty)
+		return
+	}
+
+	low, high := values[0], values[0]
+	for _, v := range values {
+		if v < low {
+			low = v
+		}
+		if v > high {
+			high = v
+		}
+	}
+	span := high - low
+	if span == 0 {
+		span = 1
+	}
+	point := func(i int) fyne.Position {
+		x := left + width/2
+		if len(values) > 1 {
+			x = left + width*float32(i)/float32(len(values)-1)
+		}
+		y := top + height - height*float32((values[i]-low)/span)
+		return fyne.NewPos(x, y)
+	}
+
+	for i := range values {
+		if i > 0 {
+			line := canvas.NewLine(primary)
+			line.StrokeWidth = 2
+			line.Position1 = point(i - 1)
+			line.Position2 = point(i)
+			r.objects = append(r.objects, line)
+		}
+		dot := canvas.NewCircle(primary)
+		dot.Resize(fyne.NewSize(6, 6))
+		dot.Move(point(i).Subtract(fyne.NewPos(3, 3)))
+		r.objects = append(r.objects, dot)
+	}
+
+	for _, label := range []struct {
+		value float64
+		y     float32
+	}{{high, top}, {low, top + height}} {
+		text := canvas.NewText(r.chart.format(label.value), foreground)
+		text.TextSize = theme.CaptionTextSize()
+		text.Move(fyne.NewPos(left-text.MinSize().Width-4, label.y-text.MinSize().Height/2))
+		r.objects = append(r.objects, text)
+	}
+}
+
+// MinSize 返回折线图的最小尺寸
+func (r *lineChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(320, 200)
+}
+
+// Refresh 数值改变后重新生成
+func (r *lineChartRenderer) Refresh() {
+	r.Layout(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+// Objects 返回折线图的所有图形
+func (r *lineChartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+// Destroy 折线图没有需要释放的资源
+func (r *lineChartRenderer) Destroy() {}
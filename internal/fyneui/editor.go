@@ -0,0 +1,433 @@
+// Package fyneui 提供局面编辑器：在棋盘上绘制方块，设置方块序列和暂存方块后开始练习或保存为谜题
+package fyneui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// 编辑器棋盘的单元格大小和边距
+const (
+	editorCellSize = float32(22)
+	editorMargin   = float32(10)
+)
+
+// 在棋盘上点击或拖动时使用的工具
+const (
+	toolPaint    = "绘制"
+	toolClearRow = "清空整行"
+)
+
+// paletteColors 编辑器的调色板，ColorEmpty 为橡皮擦
+var paletteColors = []types.Color{
+	types.ColorI, types.ColorO, types.ColorT, types.ColorS, types.ColorZ,
+	types.ColorJ, types.ColorL, types.ColorGarbage, types.ColorEmpty,
+}
+
+// paletteNames 调色板中颜色的名称
+var paletteNames = map[types.Color]string{
+	types.ColorI: "I", types.ColorO: "O", types.ColorT: "T", types.ColorS: "S", types.ColorZ: "Z",
+	types.ColorJ: "J", types.ColorL: "L", types.ColorGarbage: "垃圾", types.ColorEmpty: "橡皮",
+}
+
+// holdOptions 暂存方块的选项，第一项为不暂存
+var holdOptions = []string{"无", "I", "O", "T", "S", "Z", "J", "L"}
+
+// goalOptions 保存谜题时可以选择的目标
+var goalOptions = []struct {
+	name string
+	goal game.PuzzleGoalType
+}{
+	{"自由练习", game.PuzzleGoalPractice},
+	{"清空棋盘", game.PuzzleGoalClearAll},
+	{"T-spin 双消", game.PuzzleGoalTSpinDouble},
+	{"消除指定行数", game.PuzzleGoalLines},
+}
+
+// boardEditor 局面编辑器窗口
+type boardEditor struct {
+	ui     *GameUI
+	window fyne.Window
+	board  game.Board
+	cells  [][]*canvas.Rectangle
+
+	paint types.Color
+	tool  string
+
+	paintLabel *widget.Label
+	queueEntry *widget.Entry
+	holdSelect *widget.Select
+}
+
+// openEditor 打开局面编辑器，初始局面为当前游戏的棋盘（尺寸不是标准棋盘时为空棋盘）
+func (ui *GameUI) openEditor() {
+	editor := &boardEditor{
+		ui:     ui,
+		window: ui.app.NewWindow("局面编辑器"),
+		board:  game.NewBoard(types.BoardWidth, types.BoardHeight),
+		paint:  types.ColorGarbage,
+		tool:   toolPaint,
+	}
+
+	// 练习使用标准方块集，自定义方块集的颜色作为垃圾方块
+	current := ui.game.GetBoard()
+	if current.GetWidth() == types.BoardWidth && current.GetHeight() == types.BoardHeight {
+		for y := 0; y < types.BoardHeight; y++ {
+			for x := 0; x < types.BoardWidth; x++ {
+				color := current.GetCell(x, y)
+				if _, ok := paletteNames[color]; !ok {
+					color = types.ColorGarbage
+				}
+				editor.board.SetCell(x, y, color)
+			}
+		}
+	}
+
+	editor.setupUI()
+	editor.refresh()
+	editor.window.Show()
+}
+
+// setupUI 创建棋盘、调色板、编辑工具和操作按钮
+func (e *boardEditor) setupUI() {
+	var grid *fyne.Container
+	e.cells, grid = createBoardGrid(editorCellSize, editorMargin)
+	boardArea := newEditorCanvas(grid, e.apply)
+
+	e.paintLabel = widget.NewLabel("")
+	toolGroup := widget.NewRadioGroup([]string{toolPaint, toolClearRow}, func(tool string) {
+		e.tool = tool
+		e.updatePaintLabel()
+	})
+	toolGroup.Horizontal = true
+	toolGroup.Required = true
+	toolGroup.SetSelected(e.tool)
+
+	// 选择颜色时切换回绘制工具
+	palette := container.NewGridWithColumns(3)
+	for _, c := range paletteColors {
+		c := c
+		swatch := canvas.NewRectangle(colorForType(c, nil))
+		swatch.StrokeColor = boardStrokeColor
+		swatch.StrokeWidth = 1
+		button := widget.NewButton(paletteNames[c], func() {
+			e.paint = c
+			toolGroup.SetSelected(toolPaint)
+			e.updatePaintLabel()
+		})
+		button.Importance = widget.LowImportance
+		palette.Add(container.NewStack(swatch, button))
+	}
+	e.updatePaintLabel()
+
+	tools := container.NewGridWithColumns(2,
+		widget.NewButton("左右镜像", e.edit(func() { game.MirrorBoard(e.board) })),
+		widget.NewButton("清空棋盘", e.edit(e.board.Clear)),
+		widget.NewButton("整体上移", e.edit(func() { game.ShiftBoard(e.board, -1) })),
+		widget.NewButton("整体下移", e.edit(func() { game.ShiftBoard(e.board, 1) })),
+	)
+
+	e.queueEntry = widget.NewEntry()
+	e.queueEntry.SetPlaceHolder("如 TIOL，留空时随机生成")
+	e.holdSelect = widget.NewSelect(holdOptions, nil)
+	e.holdSelect.SetSelectedIndex(0)
+
+	side := container.NewVBox(
+		widget.NewLabel("颜色"),
+		palette,
+		e.paintLabel,
+		toolGroup,
+		tools,
+		widget.NewSeparator(),
+		widget.NewForm(
+			widget.NewFormItem("方块序列", e.queueEntry),
+			widget.NewFormItem("暂存方块", e.holdSelect),
+		),
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2,
+			widget.NewButton("导入 fumen", e.importFumen),
+			widget.NewButton("复制为 fumen", e.copyFumen),
+		),
+		widget.NewButton("保存为谜题…", e.savePuzzle),
+		widget.NewButton("开始练习", e.startPractice),
+	)
+
+	e.window.SetContent(container.NewBorder(nil, nil, boardArea, nil, container.NewPadded(side)))
+}
+
+// updatePaintLabel 显示当前的工具和颜色
+func (e *boardEditor) updatePaintLabel() {
+	if e.tool == toolClearRow {
+		e.paintLabel.SetText("点击棋盘清空所在的行")
+		return
+	}
+	e.paintLabel.SetText("当前颜色: " + paletteNames[e.paint])
+}
+
+// apply 在棋盘的 (x, y) 格上使用当前工具。点击（而不是拖动）已是当前颜色的格子时擦除该格
+func (e *boardEditor) apply(x, y int, tapped bool) {
+	switch {
+	case e.tool == toolClearRow:
+		game.ClearRow(e.board, y)
+	case tapped && e.board.GetCell(x, y) == e.paint:
+		e.board.SetCell(x, y, types.ColorEmpty)
+	default:
+		e.board.SetCell(x, y, e.paint)
+	}
+	e.refresh()
+}
+
+// edit 返回执行棋盘操作并刷新显示的按钮回调
+func (e *boardEditor) edit(action func()) func() {
+	return func() {
+		action()
+		e.refresh()
+	}
+}
+
+// refresh 按棋盘内容更新单元格的颜色
+func (e *boardEditor) refresh() {
+	for y, row := range e.cells {
+		for x, cell := range row {
+			cell.FillColor = colorForType(e.board.GetCell(x, y), nil)
+			cell.Refresh()
+		}
+	}
+}
+
+// puzzle 返回编辑器中的局面：棋盘从最高的方块到底部，方块序列和暂存方块来自输入框
+func (e *boardEditor) puzzle() (*game.Puzzle, error) {
+	puzzle := &game.Puzzle{
+		Name:  "自定义局面",
+		Board: game.BoardRows(e.board),
+		Goal:  game.PuzzleGoal{Type: game.PuzzleGoalPractice},
+	}
+	for _, r := range strings.TrimSpace(e.queueEntry.Text) {
+		tetrominoType, ok := game.ParseTetrominoLetter(r)
+		if !ok {
+			return nil, fmt.Errorf("方块序列中有无法识别的方块 %q", r)
+		}
+		puzzle.Queue = append(puzzle.Queue, tetrominoType)
+	}
+	if index := e.holdSelect.SelectedIndex(); index > 0 {
+		tetrominoType, _ := game.ParseTetrominoLetter(rune(holdOptions[index][0]))
+		puzzle.Hold = []types.TetrominoType{tetrominoType}
+	}
+	return puzzle, nil
+}
+
+// startPractice 从编辑器中的局面开始自由练习，编辑器保持打开以便修改后再次练习
+func (e *boardEditor) startPractice() {
+	if e.ui.isRunning {
+		dialog.ShowInformation("开始练习", "请先结束当前的游戏", e.window)
+		return
+	}
+	puzzle, err := e.puzzle()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	e.ui.startPuzzle(puzzle)
+	e.ui.window.RequestFocus()
+}
+
+// importFumen 用剪贴板中 fumen 文本的局面替换编辑器中的棋盘、方块序列和暂存方块
+func (e *boardEditor) importFumen() {
+	puzzle, err := game.DecodePuzzle(e.ui.app.Clipboard().Content())
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	e.board.Clear()
+	offset := types.BoardHeight - len(puzzle.Board)
+	for i, row := range puzzle.Board {
+		for x, color := range row {
+			e.board.SetCell(x, offset+i, color)
+		}
+	}
+
+	var queue strings.Builder
+	for _, tetrominoType := range puzzle.Queue {
+		queue.WriteRune(game.TetrominoLetter(tetrominoType))
+	}
+	e.queueEntry.SetText(queue.String())
+	e.holdSelect.SetSelectedIndex(0)
+	if len(puzzle.Hold) > 0 {
+		e.holdSelect.SetSelected(string(game.TetrominoLetter(puzzle.Hold[0])))
+	}
+	e.refresh()
+}
+
+// copyFumen 将编辑器中的局面编码为 fumen 文本并复制到剪贴板
+func (e *boardEditor) copyFumen() {
+	puzzle, err := e.puzzle()
+	if err == nil {
+		var text string
+		if text, err = game.EncodePuzzle(puzzle); err == nil {
+			e.ui.app.Clipboard().SetContent(text)
+			dialog.ShowInformation("复制局面", "已复制到剪贴板:\n"+text, e.window)
+			return
+		}
+	}
+	dialog.ShowError(err, e.window)
+}
+
+// savePuzzle 填写名称、说明和目标后将局面保存到谜题目录，之后可以在谜题列表中选择
+func (e *boardEditor) savePuzzle() {
+	puzzle, err := e.puzzle()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(puzzle.Name)
+	fileEntry := widget.NewEntry()
+	fileEntry.SetText("custom")
+	descriptionEntry := widget.NewMultiLineEntry()
+	goalNames := make([]string, len(goalOptions))
+	for i, option := range goalOptions {
+		goalNames[i] = option.name
+	}
+	goalSelect := widget.NewSelect(goalNames, nil)
+	goalSelect.SetSelectedIndex(0)
+	linesEntry := widget.NewEntry()
+	linesEntry.SetText("4")
+	piecesEntry := widget.NewEntry()
+	piecesEntry.SetText("0")
+	gravityCheck := widget.NewCheck("开启重力", nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("名称", nameEntry),
+		widget.NewFormItem("文件名", fileEntry),
+		widget.NewFormItem("说明", descriptionEntry),
+		widget.NewFormItem("目标", goalSelect),
+		widget.NewFormItem("目标行数", linesEntry),
+		widget.NewFormItem("方块数上限", piecesEntry),
+		widget.NewFormItem("", gravityCheck),
+	}
+	items[4].HintText = "仅用于“消除指定行数”"
+	items[5].HintText = "0 表示不限制"
+
+	form := dialog.NewForm("保存为谜题", "保存", "取消", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		lines, err := strconv.Atoi(strings.TrimSpace(linesEntry.Text))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("目标行数无效: %q", linesEntry.Text), e.window)
+			return
+		}
+		pieces, err := strconv.Atoi(strings.TrimSpace(piecesEntry.Text))
+		if err != nil || pieces < 0 {
+			dialog.ShowError(fmt.Errorf("方块数上限无效: %q", piecesEntry.Text), e.window)
+			return
+		}
+		fileName := strings.TrimSuffix(strings.TrimSpace(fileEntry.Text), ".json")
+		if fileName == "" || strings.ContainsAny(fileName, `/\:`) {
+			dialog.ShowError(fmt.Errorf("文件名无效: %q", fileEntry.Text), e.window)
+			return
+		}
+
+		puzzle.Name = strings.TrimSpace(nameEntry.Text)
+		puzzle.Description = strings.TrimSpace(descriptionEntry.Text)
+		puzzle.Gravity = gravityCheck.Checked
+		puzzle.Goal = game.PuzzleGoal{Type: goalOptions[goalSelect.SelectedIndex()].goal, Pieces: pieces}
+		if puzzle.Goal.Type == game.PuzzleGoalLines {
+			puzzle.Goal.Lines = lines
+		}
+
+		path := filepath.Join(types.PuzzleDir, fileName+".json")
+		save := func() {
+			if err := game.SavePuzzle(path, puzzle); err != nil {
+				dialog.ShowError(err, e.window)
+				return
+			}
+			dialog.ShowInformation("保存为谜题", "已保存到 "+path, e.window)
+		}
+		if _, err := os.Stat(path); err == nil {
+			dialog.ShowConfirm("保存为谜题", path+" 已存在，是否覆盖？", func(overwrite bool) {
+				if overwrite {
+					save()
+				}
+			}, e.window)
+			return
+		}
+		save()
+	}, e.window)
+	form.Resize(fyne.NewSize(420, 460))
+	form.Show()
+}
+
+// editorCanvas 覆盖在编辑器棋盘上，将点击和拖动转换为棋盘格坐标
+type editorCanvas struct {
+	widget.BaseWidget
+	grid   *fyne.Container
+	onCell func(x, y int, tapped bool)
+
+	lastX, lastY int // 拖动时上一次经过的格子，同一格只处理一次
+}
+
+// newEditorCanvas 创建编辑器棋盘，grid 为 createBoardGrid 创建的网格
+func newEditorCanvas(grid *fyne.Container, onCell func(x, y int, tapped bool)) *editorCanvas {
+	c := &editorCanvas{grid: grid, onCell: onCell, lastX: -1, lastY: -1}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// CreateRenderer 实现 fyne.Widget 接口
+func (c *editorCanvas) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewWithoutLayout(c.grid))
+}
+
+// MinSize 返回网格的大小
+func (c *editorCanvas) MinSize() fyne.Size {
+	return c.grid.Size()
+}
+
+// Tapped 实现 fyne.Tappable 接口
+func (c *editorCanvas) Tapped(event *fyne.PointEvent) {
+	if x, y, ok := c.cellAt(event.Position); ok {
+		c.onCell(x, y, true)
+	}
+}
+
+// Dragged 实现 fyne.Draggable 接口，拖动经过的格子依次使用当前工具
+func (c *editorCanvas) Dragged(event *fyne.DragEvent) {
+	x, y, ok := c.cellAt(event.Position)
+	if !ok || (x == c.lastX && y == c.lastY) {
+		return
+	}
+	c.lastX, c.lastY = x, y
+	c.onCell(x, y, false)
+}
+
+// DragEnd 实现 fyne.Draggable 接口
+func (c *editorCanvas) DragEnd() {
+	c.lastX, c.lastY = -1, -1
+}
+
+// cellAt 返回位置所在的棋盘格，位置在棋盘外时返回 false
+func (c *editorCanvas) cellAt(pos fyne.Position) (int, int, bool) {
+	if pos.X < editorMargin || pos.Y < editorMargin {
+		return 0, 0, false
+	}
+	x := int((pos.X - editorMargin) / editorCellSize)
+	y := int((pos.Y - editorMargin) / editorCellSize)
+	if x >= types.BoardWidth || y >= types.BoardHeight {
+		return 0, 0, false
+	}
+	return x, y, true
+}
@@ -0,0 +1,39 @@
+// Package fyneui 提供最简操作（finesse）统计
+package fyneui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/history"
+)
+
+// finesseStatus 返回本局最简操作统计的文字
+func finesseStatus(stats history.Finesse) string {
+	if stats.Pieces == 0 {
+		return "操作: 暂无"
+	}
+	return fmt.Sprintf("操作: %d/%d 个方块有多余按键，共多按 %d 次", stats.Faults, stats.Pieces, stats.Extra)
+}
+
+// createFinesseLabel 创建显示操作统计的标签
+func (ui *GameUI) createFinesseLabel() {
+	ui.finesseLabel = widget.NewLabel(finesseStatus(history.Finesse{}))
+}
+
+// watchFinesse 在统计检查完方块的最简操作后更新标签；检查在后台进行，不占用游戏循环
+func (ui *GameUI) watchFinesse(tracker *history.Tracker) {
+	tracker.SetFinesseHandler(func(stats history.Finesse) {
+		text := finesseStatus(stats)
+		fyne.Do(func() {
+			ui.finesseLabel.SetText(text)
+		})
+	})
+}
+
+// resetFinesse 开局时清空操作统计
+func (ui *GameUI) resetFinesse() {
+	ui.finesseLabel.SetText(finesseStatus(history.Finesse{}))
+}
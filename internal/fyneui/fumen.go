@@ -0,0 +1,68 @@
+// Package fyneui 提供通过剪贴板导入和分享 fumen 局面
+package fyneui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// positionMenu 返回局面菜单：从剪贴板导入 fumen 局面，把当前局面复制为 fumen，或打开局面编辑器
+func (ui *GameUI) positionMenu() *fyne.Menu {
+	return fyne.NewMenu("局面",
+		fyne.NewMenuItem("从剪贴板导入 fumen 并练习", ui.importPosition),
+		fyne.NewMenuItem("复制当前局面为 fumen", ui.copyPosition),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("局面编辑器…", ui.openEditor),
+	)
+}
+
+// importPosition 解码剪贴板中的 fumen 文本，从该局面开始自由练习
+func (ui *GameUI) importPosition() {
+	if ui.isRunning {
+		dialog.ShowInformation("导入局面", "请先结束当前的游戏", ui.window)
+		return
+	}
+	puzzle, err := game.DecodePuzzle(ui.app.Clipboard().Content())
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	ui.startPuzzle(puzzle)
+}
+
+// copyPosition 将当前的棋盘、当前和下一个方块以及暂存方块编码为 fumen 文本并复制到剪贴板
+func (ui *GameUI) copyPosition() {
+	board := ui.game.GetBoard()
+	position := &game.Puzzle{}
+	for y := 0; y < board.GetHeight(); y++ {
+		row := make([]types.Color, board.GetWidth())
+		for x := range row {
+			row[x] = board.GetCell(x, y)
+		}
+		position.Board = append(position.Board, row)
+	}
+
+	// fumen 只能表示标准的七种方块
+	standard := func(tetromino game.Tetromino) bool {
+		return tetromino != nil && game.TetrominoLetter(tetromino.GetType()) != 0
+	}
+	for _, tetromino := range []game.Tetromino{ui.game.GetCurrentTetromino(), ui.game.GetNextTetromino()} {
+		if standard(tetromino) {
+			position.Queue = append(position.Queue, tetromino.GetType())
+		}
+	}
+	if hold := ui.game.GetHoldTetromino(); standard(hold) {
+		position.Hold = []types.TetrominoType{hold.GetType()}
+	}
+
+	text, err := game.EncodePuzzle(position)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	ui.app.Clipboard().SetContent(text)
+	dialog.ShowInformation("复制局面", "已复制到剪贴板:\n"+text, ui.window)
+}
@@ -9,12 +9,21 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
 
 	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/highscore"
+	"goeluosifangkuai/internal/history"
+	"goeluosifangkuai/internal/profile"
+	"goeluosifangkuai/internal/replay"
 	"goeluosifangkuai/pkg/types"
 )
 
+// boardStrokeColor 棋盘格子的边框颜色
+var boardStrokeColor = color.RGBA{100, 100, 100, 255}
+
 // GameUI 游戏界面
 type GameUI struct {
 	app        fyne.App
@@ -30,26 +39,80 @@ type GameUI struct {
 	nextPieceCells  [][]*canvas.Rectangle
 	nextPieceCanvas *fyne.Container
 
+	// 暂存方块预览
+	holdPieceCells  [][]*canvas.Rectangle
+	holdPieceCanvas *fyne.Container
+
 	// 界面元素
 	scoreLabel  *widget.Label
 	levelLabel  *widget.Label
 	linesLabel  *widget.Label
+	modeLabel   *widget.Label
 	nextPanel   *fyne.Container
 	statusLabel *widget.Label
+	helpLabel   *widget.Label
 
 	// 控制按钮
 	startButton   *widget.Button
 	pauseButton   *widget.Button
 	restartButton *widget.Button
+	saveButton    *widget.Button
+	puzzleButton  *widget.Button
+	replayButton  *widget.Button
+	scoresButton  *widget.Button
+	profileButton *widget.Button
+	statsButton   *widget.Button
+	modeSelect    *widget.Select
+	bigCheck      *widget.Check
+
+	// 电脑玩家：自动演示操作玩家自己的游戏，AI 对手在旁边的小棋盘上同时进行
+	autoCheck     *widget.Check
+	opponentCheck *widget.Check
+	autoplay      *aiController
+	opponent      game.Game
+	opponentAI    *aiController
+	opponentCells [][]*canvas.Rectangle
+	opponentLabel *widget.Label
+	opponentPanel *fyne.Container
+	newMode       func() game.Mode // 创建与当前游戏相同的模式，用于 AI 对手
+
+	// 录像：modeID 为当前模式的标识，谜题模式没有标识，不录制
+	modeID   string
+	recorder *replay.Recorder
+
+	// 游戏历史中每局的方块统计
+	tracker *history.Tracker
+
+	// 排行榜，第一次使用时打开
+	highScores *highscore.Store
+
+	// 玩家档案，没有选择档案时为 nil；keyActions 为按键到档案中操作的映射
+	profile    *profile.Profile
+	keyActions map[fyne.KeyName]string
+
+	// 电脑玩家推荐的落点提示
+	hint      *hintController
+	hintLabel *widget.Label
+
+	// 最简操作统计
+	finesseLabel *widget.Label
+
+	// 可选的方块集，第一个为标准方块集
+	pieceSets      []*game.PieceSet
+	pieceSetSelect *widget.Select
 
 	// 游戏状态
 	isRunning bool
 	isPaused  bool
+
+	// 玩家的操作和保存并退出的请求，由游戏循环在两次更新之间处理；保存请求的值为 true 表示玩家正在关闭窗口
+	inputs       chan types.Input
+	saveRequests chan bool
 }
 
 // NewGameUI 创建新的游戏界面
 func NewGameUI(app fyne.App) *GameUI {
-	window := app.NewWindow("俄罗斯方块 - Tetris")
+	window := app.NewWindow(windowTitle)
 	window.Resize(fyne.NewSize(950, 750)) // 进一步增大窗口尺寸
 	window.CenterOnScreen()
 
@@ -58,16 +121,56 @@ func NewGameUI(app fyne.App) *GameUI {
 	gameInstance := game.NewGame(config)
 
 	ui := &GameUI{
-		app:    app,
-		window: window,
-		game:   gameInstance,
+		app:          app,
+		window:       window,
+		newMode:      game.NewClassicMode,
+		modeID:       "classic",
+		inputs:       make(chan types.Input, 64),
+		saveRequests: make(chan bool, 1),
 	}
+	ui.setGame(gameInstance)
 
 	ui.setupUI()
+	ui.setupMenu()
+	ui.bindKeys(profile.DefaultKeyBindings())
 	ui.setupKeyboardEvents()
+	ui.setupCloseIntercept()
 	return ui
 }
 
+// setGame 切换当前的游戏实例并订阅它的事件
+func (ui *GameUI) setGame(gameInstance game.Game) {
+	ui.game = gameInstance
+	ui.game.AddEventHandler(ui.handleGameEvent)
+	ui.tracker = history.NewTracker(gameInstance)
+	ui.watchFinesse(ui.tracker)
+	ui.recorder = nil
+	if ui.modeID != "" {
+		ui.recorder = replay.NewRecorder(gameInstance, ui.modeID)
+	}
+	ui.resizePiecePreviews()
+}
+
+// handleGameEvent 处理游戏事件，在状态栏显示提示
+func (ui *GameUI) handleGameEvent(event game.GameEvent) {
+	switch event.Type {
+	case game.GameEventPerfectClear:
+		text := "全消！"
+		if event.Clear.BackToBack {
+			text = "Back-to-Back 全消！"
+		}
+		fyne.Do(func() {
+			ui.statusLabel.SetText(text)
+		})
+	case game.GameEventLock:
+		ui.hint.onLock(event.Piece)
+		text := ui.hint.status()
+		fyne.Do(func() {
+			ui.hintLabel.SetText(text)
+		})
+	}
+}
+
 // setupUI 设置用户界面
 func (ui *GameUI) setupUI() {
 	// 创建游戏棋盘
@@ -85,19 +188,25 @@ func (ui *GameUI) setupUI() {
 
 // createGameBoard 创建游戏棋盘
 func (ui *GameUI) createGameBoard() {
+	cellSize := float32(25)    // 调整回更合适的单元格大小
+	boardMargin := float32(10) // 边距
+
+	// 直接使用棋盘容器，不再用Border包装
+	ui.boardCells, ui.gameCanvas = createBoardGrid(cellSize, boardMargin)
+}
+
+// createBoardGrid 创建标准尺寸的棋盘网格
+func createBoardGrid(cellSize, boardMargin float32) ([][]*canvas.Rectangle, *fyne.Container) {
 	// 初始化棋盘单元格
-	ui.boardCells = make([][]*canvas.Rectangle, types.BoardHeight)
+	cells := make([][]*canvas.Rectangle, types.BoardHeight)
 
 	boardContainer := container.NewWithoutLayout()
 
-	cellSize := float32(25)    // 调整回更合适的单元格大小
-	boardMargin := float32(10) // 边距
-
 	for y := 0; y < types.BoardHeight; y++ {
-		ui.boardCells[y] = make([]*canvas.Rectangle, types.BoardWidth)
+		cells[y] = make([]*canvas.Rectangle, types.BoardWidth)
 		for x := 0; x < types.BoardWidth; x++ {
 			cell := canvas.NewRectangle(color.RGBA{40, 40, 40, 255})
-			cell.StrokeColor = color.RGBA{100, 100, 100, 255}
+			cell.StrokeColor = boardStrokeColor
 			cell.StrokeWidth = 1
 
 			// 设置位置和大小
@@ -107,7 +216,7 @@ func (ui *GameUI) createGameBoard() {
 				boardMargin+float32(y)*cellSize,
 			))
 
-			ui.boardCells[y][x] = cell
+			cells[y][x] = cell
 			boardContainer.Add(cell)
 		}
 	}
@@ -119,23 +228,20 @@ func (ui *GameUI) createGameBoard() {
 	)
 	boardContainer.Resize(boardSize)
 
-	// 直接使用棋盘容器，不再用Border包装
-	ui.gameCanvas = boardContainer
+	return cells, boardContainer
 }
 
-// createNextPiecePreview 创建下一个方块预览区域
-func (ui *GameUI) createNextPiecePreview() {
-	// 初始化下一个方块预览单元格 (4x4 网格足够显示所有方块)
-	previewSize := 4
-	ui.nextPieceCells = make([][]*canvas.Rectangle, previewSize)
+// createPiecePreview 创建 previewSize×previewSize 的方块预览区域
+func (ui *GameUI) createPiecePreview(previewSize int) ([][]*canvas.Rectangle, *fyne.Container) {
+	cells := make([][]*canvas.Rectangle, previewSize)
 
-	nextContainer := container.NewWithoutLayout()
+	previewContainer := container.NewWithoutLayout()
 
 	cellSize := float32(15) // 较小的预览单元格
 	margin := float32(5)
 
 	for y := 0; y < previewSize; y++ {
-		ui.nextPieceCells[y] = make([]*canvas.Rectangle, previewSize)
+		cells[y] = make([]*canvas.Rectangle, previewSize)
 		for x := 0; x < previewSize; x++ {
 			cell := canvas.NewRectangle(color.RGBA{30, 30, 30, 255}) // 深灰色背景
 			cell.StrokeColor = color.RGBA{60, 60, 60, 255}
@@ -148,8 +254,8 @@ func (ui *GameUI) createNextPiecePreview() {
 				margin+float32(y)*cellSize,
 			))
 
-			ui.nextPieceCells[y][x] = cell
-			nextContainer.Add(cell)
+			cells[y][x] = cell
+			previewContainer.Add(cell)
 		}
 	}
 
@@ -158,9 +264,30 @@ func (ui *GameUI) createNextPiecePreview() {
 		float32(previewSize)*cellSize+margin*2,
 		float32(previewSize)*cellSize+margin*2,
 	)
-	nextContainer.Resize(previewCanvasSize)
+	previewContainer.Resize(previewCanvasSize)
 
-	ui.nextPieceCanvas = nextContainer
+	return cells, previewContainer
+}
+
+// resizePiecePreviews 按方块集中最大方块的尺寸（至少 4 格）重建下一个方块和暂存方块的预览区域
+func (ui *GameUI) resizePiecePreviews() {
+	previewSize := ui.game.GetPieceSet().MaxSize()
+	if previewSize < 4 {
+		previewSize = 4
+	}
+	if len(ui.nextPieceCells) == previewSize {
+		return
+	}
+
+	ui.nextPieceCells, ui.nextPieceCanvas = ui.createPiecePreview(previewSize)
+	ui.holdPieceCells, ui.holdPieceCanvas = ui.createPiecePreview(previewSize)
+
+	// 界面已经创建时替换面板中的预览区域
+	if ui.nextPanel != nil {
+		ui.nextPanel.Objects[1] = ui.nextPieceCanvas
+		ui.nextPanel.Objects[3] = ui.holdPieceCanvas
+		ui.nextPanel.Refresh()
+	}
 }
 
 // createInfoPanel 创建信息面板
@@ -177,17 +304,27 @@ func (ui *GameUI) createInfoPanel() {
 	ui.linesLabel = widget.NewLabel("行数: 0")
 	ui.linesLabel.TextStyle = fyne.TextStyle{Bold: true}
 
+	// 模式状态标签（如限时模式的剩余时间）
+	ui.modeLabel = widget.NewLabel("")
+
 	// 状态标签
 	ui.statusLabel = widget.NewLabel("准备开始")
 	ui.statusLabel.TextStyle = fyne.TextStyle{Italic: true}
 
-	// 创建下一个方块预览区域
-	ui.createNextPiecePreview()
+	// 提示开关和跟随统计、操作统计
+	ui.createHintLabel()
+	ui.createFinesseLabel()
+
+	// 创建下一个方块和暂存方块的预览区域
+	ui.resizePiecePreviews()
+	ui.createOpponentPanel()
 
 	// 下一个方块预览面板
 	ui.nextPanel = container.NewVBox(
 		widget.NewLabel("下一个方块:"),
 		ui.nextPieceCanvas, // 使用创建的预览画布
+		widget.NewLabel("暂存:"),
+		ui.holdPieceCanvas,
 	)
 
 	// 组织信息面板
@@ -196,10 +333,14 @@ func (ui *GameUI) createInfoPanel() {
 			ui.scoreLabel,
 			ui.levelLabel,
 			ui.linesLabel,
+			ui.modeLabel,
 			ui.statusLabel,
+			ui.hintLabel,
+			ui.finesseLabel,
 		)),
 		widget.NewSeparator(),
 		ui.nextPanel,
+		ui.opponentPanel,
 	)
 }
 
@@ -210,6 +351,44 @@ func (ui *GameUI) createControlPanel() {
 	ui.pauseButton.Disable()
 	ui.restartButton = widget.NewButton("重新开始", ui.restartGame)
 	ui.restartButton.Disable()
+	ui.saveButton = widget.NewButton("保存并退出", ui.saveAndQuit)
+	ui.saveButton.Disable()
+	ui.puzzleButton = widget.NewButton("谜题", ui.showPuzzleBrowser)
+	ui.replayButton = widget.NewButton("录像", ui.showReplayBrowser)
+	ui.scoresButton = widget.NewButton("排行榜", func() {
+		ui.showLeaderboard(highscore.CategoryOf(ui.modeID, ui.game.GetConfig()))
+	})
+	ui.profileButton = widget.NewButton("档案", ui.showProfileSettings)
+	ui.statsButton = widget.NewButton("统计", ui.showStatistics)
+	ui.createAIControls()
+
+	// 先选中默认模式再绑定回调，避免初始化时重复创建游戏
+	ui.modeSelect = widget.NewSelect(modeNames(), nil)
+	ui.modeSelect.SetSelectedIndex(0)
+	ui.modeSelect.OnChanged = ui.selectMode
+
+	// 大方块变体可以与任意模式组合
+	ui.bigCheck = widget.NewCheck("大方块", func(bool) {
+		ui.selectMode(ui.modeSelect.Selected)
+	})
+
+	// 方块集同样可以与任意模式组合
+	ui.pieceSets = []*game.PieceSet{game.StandardPieceSet()}
+	sets, err := game.LoadPieceSets(types.PieceSetDir)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+	}
+	ui.pieceSets = append(ui.pieceSets, sets...)
+
+	pieceSetNames := make([]string, len(ui.pieceSets))
+	for i, set := range ui.pieceSets {
+		pieceSetNames[i] = set.Name
+	}
+	ui.pieceSetSelect = widget.NewSelect(pieceSetNames, nil)
+	ui.pieceSetSelect.SetSelectedIndex(0)
+	ui.pieceSetSelect.OnChanged = func(string) {
+		ui.selectMode(ui.modeSelect.Selected)
+	}
 }
 
 // layoutUI 布局界面
@@ -239,19 +418,30 @@ func (ui *GameUI) layoutUI() {
 
 	// 控制按钮容器 - 水平排列
 	buttonContainer := container.NewHBox(
+		ui.modeSelect,
+		ui.bigCheck,
+		ui.pieceSetSelect,
+		ui.autoCheck,
+		ui.opponentCheck,
+		ui.puzzleButton,
+		ui.replayButton,
+		ui.scoresButton,
+		ui.profileButton,
+		ui.statsButton,
 		ui.startButton,
 		ui.pauseButton,
 		ui.restartButton,
+		ui.saveButton,
 	)
 
 	// 底部说明文字
-	helpLabel := widget.NewLabel("使用 A/D 左右移动，W 旋转，S 下降，空格快速下降")
-	helpLabel.Alignment = fyne.TextAlignCenter
+	ui.helpLabel = widget.NewLabel(helpText(profile.DefaultKeyBindings()))
+	ui.helpLabel.Alignment = fyne.TextAlignCenter
 
 	// 使用Border布局，确保游戏区域在中心，按钮在底部
 	mainContainer := container.NewBorder(
-		container.NewVBox(titleLabel, widget.NewSeparator()),                 // 顶部：标题
-		container.NewVBox(widget.NewSeparator(), buttonContainer, helpLabel), // 底部：按钮+帮助
+		container.NewVBox(titleLabel, widget.NewSeparator()),                    // 顶部：标题
+		container.NewVBox(widget.NewSeparator(), buttonContainer, ui.helpLabel), // 底部：按钮+帮助
 		nil,          // 左侧：无
 		nil,          // 右侧：无
 		gameMainArea, // 中心：游戏区域
@@ -260,36 +450,105 @@ func (ui *GameUI) layoutUI() {
 	ui.window.SetContent(mainContainer)
 }
 
+// setupMenu 设置窗口菜单
+func (ui *GameUI) setupMenu() {
+	ui.window.SetMainMenu(fyne.NewMainMenu(ui.positionMenu(), ui.statsMenu()))
+}
+
 // setupKeyboardEvents 设置键盘事件
 func (ui *GameUI) setupKeyboardEvents() {
+	// 桌面环境下左右移动使用按下/松开事件，由游戏引擎按 DAS/ARR 处理自动重复
+	deskCanvas, hasKeyUpDown := ui.window.Canvas().(desktop.Canvas)
+	if hasKeyUpDown {
+		deskCanvas.SetOnKeyDown(func(event *fyne.KeyEvent) {
+			if !ui.isRunning || ui.isPaused {
+				return
+			}
+
+			if input, ok := shiftInput(ui.keyActions[event.Name], true); ok {
+				ui.queueInput(input)
+			}
+		})
+		deskCanvas.SetOnKeyUp(func(event *fyne.KeyEvent) {
+			if input, ok := shiftInput(ui.keyActions[event.Name], false); ok {
+				ui.queueInput(input)
+			}
+		})
+	}
+
 	ui.window.Canvas().SetOnTypedKey(func(event *fyne.KeyEvent) {
+		action := ui.keyActions[event.Name]
+
+		// 提示可以随时开关
+		if action == profile.ActionHint {
+			ui.toggleHint()
+			if ui.isRunning {
+				ui.updateBoard()
+			}
+			return
+		}
+
 		if !ui.isRunning || ui.isPaused {
 			return
 		}
 
-		switch event.Name {
-		case fyne.KeyA:
-			ui.game.MoveTetromino(-1, 0)
-		case fyne.KeyD:
-			ui.game.MoveTetromino(1, 0)
-		case fyne.KeyS:
-			ui.game.MoveTetromino(0, 1)
-		case fyne.KeyW:
-			ui.game.RotateTetromino(types.DirectionRight)
-		case fyne.KeySpace:
-			ui.game.DropTetromino()
-		case fyne.KeyP:
+		switch action {
+		case profile.ActionLeft:
+			if !hasKeyUpDown {
+				ui.queueInput(types.InputLeft)
+			}
+		case profile.ActionRight:
+			if !hasKeyUpDown {
+				ui.queueInput(types.InputRight)
+			}
+		case profile.ActionSoftDrop:
+			ui.queueInput(types.InputSoftDrop)
+		case profile.ActionRotateRight:
+			ui.queueInput(types.InputRotateRight)
+		case profile.ActionRotateLeft:
+			ui.queueInput(types.InputRotateLeft)
+		case profile.ActionHold:
+			ui.queueInput(types.InputHold)
+		case profile.ActionHardDrop:
+			ui.queueInput(types.InputHardDrop)
+		case profile.ActionUndo:
+			ui.queueInput(types.InputUndo)
+		case profile.ActionRedo:
+			ui.queueInput(types.InputRedo)
+		case profile.ActionPause:
 			ui.togglePause()
 		}
-
-		ui.updateDisplay()
 	})
 }
 
+// queueInput 将玩家的操作交给游戏循环，在两次更新之间执行，使每个操作都落在确定的帧上，录像能一致地重现
+func (ui *GameUI) queueInput(input types.Input) {
+	select {
+	case ui.inputs <- input:
+	default:
+		// 游戏循环来不及处理时丢弃，正常情况下队列不会满
+	}
+}
+
+// shiftInput 返回按下或松开左右移动键对应的操作，action 为按键绑定的操作，其他操作返回 false
+func shiftInput(action string, pressed bool) (types.Input, bool) {
+	switch {
+	case action == profile.ActionLeft && pressed:
+		return types.InputShiftLeft, true
+	case action == profile.ActionRight && pressed:
+		return types.InputShiftRight, true
+	case action == profile.ActionLeft:
+		return types.InputReleaseLeft, true
+	case action == profile.ActionRight:
+		return types.InputReleaseRight, true
+	}
+	return 0, false
+}
+
 // startGame 开始游戏
 func (ui *GameUI) startGame() {
 	// 如果是游戏结束后重新开始，需要重置游戏
-	if ui.game.GetState() == types.GameStateGameOver {
+	if isGameEnded(ui.game.GetState()) {
 		ui.game.Reset()
 	}
 
@@ -299,7 +558,17 @@ func (ui *GameUI) startGame() {
 
 	ui.startButton.Disable()
 	ui.startButton.SetText("开始游戏") // 重置按钮文字
+	ui.modeSelect.Disable()
+	ui.bigCheck.Disable()
+	ui.pieceSetSelect.Disable()
+	ui.opponentCheck.Disable()
+	ui.puzzleButton.Disable()
+	ui.replayButton.Disable()
+	ui.scoresButton.Disable()
+	ui.profileButton.Disable()
+	ui.statsButton.Disable()
 	ui.pauseButton.Enable()
+	ui.saveButton.Enable()
 	ui.restartButton.Enable()
 
 	ui.statusLabel.SetText("游戏进行中")
@@ -307,30 +576,61 @@ func (ui *GameUI) startGame() {
 	// 更新显示后再启动定时器
 	ui.updateDisplay()
 
+	// AI 对手与玩家同时开局
+	ui.startOpponent()
+	ui.autoplay.reset()
+	ui.resetHint()
+	ui.resetFinesse()
+	ui.startRecording()
+	ui.tracker.Start()
+
 	// 使用定时器而不是单独的goroutine
 	ui.startGameTimer()
 }
 
 // startGameTimer 启动游戏定时器
 func (ui *GameUI) startGameTimer() {
-	ticker := time.NewTicker(time.Millisecond * 500)
+	// 每两个逻辑帧刷新一次，保证 20G、出现延迟等按帧计算的效果能及时显示
+	ticker := time.NewTicker(time.Millisecond * 2 * types.FrameDuration)
 	lastUpdate := time.Now()
 
+	// 丢弃上一局结束前没来得及处理的操作和保存请求
+	for drained := false; !drained; {
+		select {
+		case <-ui.inputs:
+		case <-ui.saveRequests:
+		default:
+			drained = true
+		}
+	}
+
 	go func() {
 		defer ticker.Stop()
 		for ui.isRunning {
 			select {
+			case input := <-ui.inputs:
+				ui.game.ApplyInput(input)
+				if isGameEnded(ui.game.GetState()) {
+					ui.handleGameOver()
+					return
+				}
+				ui.updateDisplay()
+			case closing := <-ui.saveRequests:
+				if ui.handleSaveRequest(closing) {
+					return
+				}
 			case <-ticker.C:
 				if !ui.isPaused {
 					now := time.Now()
 					deltaTime := int(now.Sub(lastUpdate) / time.Millisecond)
 					lastUpdate = now
 
-					// 更新游戏状态
+					// 电脑玩家先操作，再更新游戏状态
+					ui.updateAI(deltaTime)
 					ui.game.Update(deltaTime)
 
 					// 检查游戏结束
-					if ui.game.GetState() == types.GameStateGameOver {
+					if isGameEnded(ui.game.GetState()) {
 						ui.handleGameOver()
 						return
 					}
@@ -370,13 +670,30 @@ func (ui *GameUI) restartGame() {
 	ui.isPaused = false
 
 	ui.startButton.Disable()
+	ui.modeSelect.Disable()
+	ui.bigCheck.Disable()
+	ui.pieceSetSelect.Disable()
+	ui.opponentCheck.Disable()
+	ui.puzzleButton.Disable()
+	ui.replayButton.Disable()
+	ui.scoresButton.Disable()
+	ui.profileButton.Disable()
+	ui.statsButton.Disable()
 	ui.pauseButton.Enable()
+	ui.saveButton.Enable()
 	ui.pauseButton.SetText("暂停")
 	ui.restartButton.Enable()
 
 	ui.statusLabel.SetText("游戏进行中")
 	ui.updateDisplay()
 
+	ui.startOpponent()
+	ui.autoplay.reset()
+	ui.resetHint()
+	ui.resetFinesse()
+	ui.restartRecording()
+	ui.tracker.Start()
+
 	// 重新启动游戏循环
 	ui.startGameTimer()
 }
@@ -386,12 +703,51 @@ func (ui *GameUI) handleGameOver() {
 	ui.isRunning = false
 	ui.isPaused = false
 
-	ui.startButton.Enable()
-	ui.startButton.SetText("重新开始")
-	ui.pauseButton.Disable()
-	ui.restartButton.Enable()
+	// 重新绘制棋盘，隐形/渐隐模式在游戏结束后显示整个棋盘
+	ui.updateBoard()
+
+	fyne.DoAndWait(func() {
+		ui.startButton.Enable()
+		ui.startButton.SetText("重新开始")
+		ui.modeSelect.Enable()
+		ui.bigCheck.Enable()
+		ui.pieceSetSelect.Enable()
+		ui.opponentCheck.Enable()
+		ui.puzzleButton.Enable()
+		ui.replayButton.Enable()
+		ui.scoresButton.Enable()
+		ui.profileButton.Enable()
+		ui.statsButton.Enable()
+		ui.pauseButton.Disable()
+		ui.saveButton.Disable()
+		ui.restartButton.Enable()
+
+		ui.modeLabel.SetText(ui.game.GetMode().GetStatus(ui.game))
+		if ui.game.GetState() == types.GameStateGameClear {
+			ui.statusLabel.SetText(fmt.Sprintf("恭喜通关！最终分数: %d", ui.game.GetScore()))
+		} else {
+			ui.statusLabel.SetText(fmt.Sprintf("游戏结束！最终分数: %d", ui.game.GetScore()))
+		}
+	})
+
+	ui.recordStats()
 
-	ui.statusLabel.SetText(fmt.Sprintf("游戏结束！最终分数: %d", ui.game.GetScore()))
+	// 成绩在游戏结束时取得，录像保存成功后才连同录像路径一起记录；保存期间玩家可能已经开始了新的一局
+	record, recordHistory := ui.historyRecord()
+	category, entry, recordHighScore := ui.highScoreEntry()
+	ui.saveReplay(func(replayPath string) {
+		if recordHistory {
+			ui.recordHistory(record, replayPath)
+		}
+		if recordHighScore {
+			ui.recordHighScore(category, entry, replayPath)
+		}
+	})
+}
+
+// isGameEnded 判断游戏是否已经结束（堆到顶部或通关）
+func isGameEnded(state types.GameState) bool {
+	return state == types.GameStateGameOver || state == types.GameStateGameClear
 }
 
 // updateDisplay 更新显示
@@ -401,6 +757,7 @@ func (ui *GameUI) updateDisplay() {
 		ui.scoreLabel.SetText(fmt.Sprintf("分数: %d", ui.game.GetScore()))
 		ui.levelLabel.SetText(fmt.Sprintf("等级: %d", ui.game.GetLevel()))
 		ui.linesLabel.SetText(fmt.Sprintf("行数: %d", ui.game.GetLinesCleared()))
+		ui.modeLabel.SetText(ui.game.GetMode().GetStatus(ui.game))
 	})
 
 	// 更新棋盘显示
@@ -412,15 +769,56 @@ func (ui *GameUI) updateDisplay() {
 
 // updateBoard 更新棋盘显示
 func (ui *GameUI) updateBoard() {
-	board := ui.game.GetBoard()
-	currentTetromino := ui.game.GetCurrentTetromino()
+	ui.hint.update(ui.game)
+	target := ui.hint.visibleTarget()
+
+	// 练习模式给出的目标落点优先于提示
+	if mode, ok := ui.game.GetMode().(game.TargetMode); ok && mode.GetTarget() != nil {
+		target = mode.GetTarget()
+	}
+	ui.drawBoard(ui.boardCells, ui.game, target)
+}
 
-	// 创建渲染缓冲区
-	buffer := make([][]types.Color, types.BoardHeight)
+// drawBoard 将游戏的棋盘和当前方块绘制到棋盘网格上，hint 不为 nil 时同时绘制推荐或目标落点
+func (ui *GameUI) drawBoard(cells [][]*canvas.Rectangle, gameInstance game.Game, hint game.Tetromino) {
+	board := gameInstance.GetBoard()
+	currentTetromino := gameInstance.GetCurrentTetromino()
+	width, height := board.GetWidth(), board.GetHeight()
+
+	// 逻辑棋盘小于显示网格时（大方块变体），每个逻辑格子占用 scale×scale 个显示格子
+	scale := types.BoardWidth / width
+	if scale < 1 {
+		scale = 1
+	}
+
+	// 创建渲染缓冲区，opacity 记录隐形/渐隐模式下已固定方块的不透明度
+	buffer := make([][]types.Color, height)
+	opacity := make([][]float64, height)
 	for i := range buffer {
-		buffer[i] = make([]types.Color, types.BoardWidth)
+		buffer[i] = make([]types.Color, width)
+		opacity[i] = make([]float64, width)
 		for j := range buffer[i] {
 			buffer[i][j] = board.GetCell(j, i)
+			opacity[i][j] = game.CellOpacity(gameInstance, j, i)
+		}
+	}
+
+	// 渲染推荐落点，被当前方块覆盖的格子显示当前方块
+	hinted := make([][]bool, height)
+	for i := range hinted {
+		hinted[i] = make([]bool, width)
+	}
+	if hint != nil {
+		position := hint.GetPosition()
+		for _, block := range hint.GetBlocks() {
+			x := position.X + block.X
+			y := position.Y + block.Y
+
+			if x >= 0 && x < width && y >= 0 && y < height && buffer[y][x] == types.ColorEmpty {
+				buffer[y][x] = hint.GetColor()
+				opacity[y][x] = hintOpacity
+				hinted[y][x] = true
+			}
 		}
 	}
 
@@ -434,89 +832,126 @@ func (ui *GameUI) updateBoard() {
 			x := position.X + block.X
 			y := position.Y + block.Y
 
-			if x >= 0 && x < types.BoardWidth && y >= 0 && y < types.BoardHeight {
+			if x >= 0 && x < width && y >= 0 && y < height {
 				buffer[y][x] = tetrominoColor
+				opacity[y][x] = 1
+				hinted[y][x] = false
 			}
 		}
 	}
 
 	// 更新单元格颜色 - 在主UI线程中执行
+	pieceSet := gameInstance.GetPieceSet()
+	empty := colorForType(types.ColorEmpty, pieceSet)
 	fyne.DoAndWait(func() {
 		for y := 0; y < types.BoardHeight; y++ {
 			for x := 0; x < types.BoardWidth; x++ {
-				cellColor := ui.getColorForType(buffer[y][x])
-				ui.boardCells[y][x].FillColor = cellColor
-				ui.boardCells[y][x].Refresh()
+				cellColor := empty
+				strokeColor := boardStrokeColor
+				bx, by := x/scale, y/scale
+				if bx < width && by < height {
+					cellColor = colorForType(buffer[by][bx], pieceSet)
+					if opacity[by][bx] < 1 {
+						cellColor = fadeColor(cellColor, empty, opacity[by][bx])
+					}
+					if hinted[by][bx] {
+						strokeColor = hintStrokeColor
+					}
+				}
+				cells[y][x].FillColor = cellColor
+				cells[y][x].StrokeColor = strokeColor
+				cells[y][x].Refresh()
 			}
 		}
 	})
 }
 
-// updateNextPiece 更新下一个方块预览
+// updateNextPiece 更新下一个方块和暂存方块的预览
 func (ui *GameUI) updateNextPiece() {
-	nextTetromino := ui.game.GetNextTetromino()
-	if nextTetromino == nil {
-		return
-	}
+	ui.drawPiecePreview(ui.nextPieceCells, ui.game.GetNextTetromino())
+	ui.drawPiecePreview(ui.holdPieceCells, ui.game.GetHoldTetromino())
+}
 
-	// 获取下一个方块的块位置
-	blocks := nextTetromino.GetBlocks()
-	nextColor := nextTetromino.GetColor()
-	uiColor := ui.getColorForType(nextColor)
-
-	// 计算方块在预览区域的中心位置
-	// 找到方块的边界
-	minX, maxX := blocks[0].X, blocks[0].X
-	minY, maxY := blocks[0].Y, blocks[0].Y
-	for _, block := range blocks {
-		if block.X < minX {
-			minX = block.X
-		}
-		if block.X > maxX {
-			maxX = block.X
-		}
-		if block.Y < minY {
-			minY = block.Y
-		}
-		if block.Y > maxY {
-			maxY = block.Y
+// drawPiecePreview 在预览区域中居中绘制方块，tetromino 为 nil 时清空预览区域
+func (ui *GameUI) drawPiecePreview(cells [][]*canvas.Rectangle, tetromino game.Tetromino) {
+	var blocks []types.Position
+	var uiColor color.Color
+	minX, minY, offsetX, offsetY := 0, 0, 0, 0
+
+	if tetromino != nil {
+		// 获取方块的块位置
+		blocks = tetromino.GetBlocks()
+		uiColor = ui.getColorForType(tetromino.GetColor())
+
+		// 计算方块在预览区域的中心位置
+		// 找到方块的边界
+		maxX, maxY := blocks[0].X, blocks[0].Y
+		minX, minY = blocks[0].X, blocks[0].Y
+		for _, block := range blocks {
+			if block.X < minX {
+				minX = block.X
+			}
+			if block.X > maxX {
+				maxX = block.X
+			}
+			if block.Y < minY {
+				minY = block.Y
+			}
+			if block.Y > maxY {
+				maxY = block.Y
+			}
 		}
-	}
 
-	// 计算偏移量以将方块居中显示
-	offsetX := (4 - (maxX - minX + 1)) / 2
-	offsetY := (4 - (maxY - minY + 1)) / 2
+		// 计算偏移量以将方块居中显示
+		offsetX = (len(cells) - (maxX - minX + 1)) / 2
+		offsetY = (len(cells) - (maxY - minY + 1)) / 2
+	}
 
 	// 在主UI线程中更新预览区域
 	fyne.DoAndWait(func() {
 		// 清空预览区域
-		for y := 0; y < 4; y++ {
-			for x := 0; x < 4; x++ {
-				ui.nextPieceCells[y][x].FillColor = color.RGBA{30, 30, 30, 255} // 深灰色背景
+		for y := range cells {
+			for x := range cells[y] {
+				cells[y][x].FillColor = color.RGBA{30, 30, 30, 255} // 深灰色背景
 			}
 		}
 
-		// 渲染下一个方块
+		// 渲染方块
 		for _, block := range blocks {
 			x := block.X - minX + offsetX
 			y := block.Y - minY + offsetY
 
-			if x >= 0 && x < 4 && y >= 0 && y < 4 {
-				ui.nextPieceCells[y][x].FillColor = uiColor
+			if y >= 0 && y < len(cells) && x >= 0 && x < len(cells[y]) {
+				cells[y][x].FillColor = uiColor
 			}
 		}
 
 		// 刷新所有预览单元格
-		for y := 0; y < 4; y++ {
-			for x := 0; x < 4; x++ {
-				ui.nextPieceCells[y][x].Refresh()
+		for y := range cells {
+			for x := range cells[y] {
+				cells[y][x].Refresh()
 			}
 		}
 	})
 }
 
+// fadeColor 按不透明度将颜色与背景色混合
+func fadeColor(c, background color.Color, opacity float64) color.Color {
+	r1, g1, b1, _ := c.RGBA()
+	r2, g2, b2, _ := background.RGBA()
+	mix := func(from, to uint32) uint8 {
+		return uint8((float64(to) + (float64(from)-float64(to))*opacity) / 257)
+	}
+	return color.RGBA{mix(r1, r2), mix(g1, g2), mix(b1, b2), 255}
+}
+
 // getColorForType 根据方块类型获取颜色
 func (ui *GameUI) getColorForType(colorType types.Color) color.Color {
+	return colorForType(colorType, ui.game.GetPieceSet())
+}
+
+// colorForType 根据方块类型获取颜色，自定义方块的颜色由方块集决定
+func colorForType(colorType types.Color, pieceSet *game.PieceSet) color.Color {
 	switch colorType {
 	case types.ColorEmpty:
 		return color.RGBA{40, 40, 40, 255} // 深灰色背景
@@ -534,18 +969,34 @@ func (ui *GameUI) getColorForType(colorType types.Color) color.Color {
 		return color.RGBA{0, 0, 255, 255} // 蓝色
 	case types.ColorL:
 		return color.RGBA{255, 165, 0, 255} // 橙色
+	case types.ColorGarbage:
+		return color.RGBA{128, 128, 128, 255} // 灰色
 	default:
+		// 自定义方块集的颜色
+		if custom, ok := pieceSet.CustomColor(colorType); ok {
+			return custom
+		}
 		return color.RGBA{40, 40, 40, 255}
 	}
 }
 
 // Show 显示窗口
 func (ui *GameUI) Show() {
+	ui.chooseProfile(ui.offerResume)
 	ui.window.ShowAndRun()
 }
 
-// Close 关闭窗口
+// Close 关闭窗口。有可以保存的进行中的游戏时先保存，下次启动时可以继续
 func (ui *GameUI) Close() {
+	if ui.isRunning && ui.modeID != "" {
+		ui.requestSave(true)
+		return
+	}
 	ui.isRunning = false
+	ui.closeWindow()
+}
+
+// closeWindow 不保存直接关闭窗口
+func (ui *GameUI) closeWindow() {
 	ui.window.Close()
 }
@@ -0,0 +1,162 @@
+// Package fyneui 提供排行榜界面和新纪录的名字输入
+package fyneui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/highscore"
+	"goeluosifangkuai/pkg/types"
+)
+
+// openHighScores 第一次使用时打开排行榜，文件损坏时提示已从备份恢复
+func (ui *GameUI) openHighScores() (*highscore.Store, error) {
+	if ui.highScores != nil {
+		return ui.highScores, nil
+	}
+	// 使用档案时每个档案有自己的排行榜
+	var store *highscore.Store
+	var err error
+	if ui.profile != nil {
+		store, err = highscore.Open(ui.profile.HighScorePath(), types.HighScoreLimit)
+	} else {
+		store, err = highscore.OpenDefault()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if store.Recovered() {
+		fyne.Do(func() {
+			dialog.ShowInformation("排行榜", "排行榜文件已损坏，已从备份恢复", ui.window)
+		})
+	}
+	ui.highScores = store
+	return store, nil
+}
+
+// highScoreEntry 返回结束的游戏在排行榜中的分类和成绩，不记录排行榜的游戏返回 false
+func (ui *GameUI) highScoreEntry() (highscore.Category, highscore.Entry, bool) {
+	// 谜题没有模式标识，自动演示的成绩不是玩家的
+	if ui.modeID == "" || ui.autoCheck.Checked {
+		return highscore.Category{}, highscore.Entry{}, false
+	}
+	// 按用时排名的模式（竞速）只记录通关的成绩
+	if info, ok := game.FindMode(ui.modeID); ok && info.RankByTime && ui.game.GetState() != types.GameStateGameClear {
+		return highscore.Category{}, highscore.Entry{}, false
+	}
+
+	category := highscore.CategoryOf(ui.modeID, ui.game.GetConfig())
+	entry := highscore.Entry{
+		Date:  time.Now(),
+		Score: ui.game.GetScore(),
+		Lines: ui.game.GetLinesCleared(),
+		Time:  ui.game.GetElapsedTime(),
+	}
+	return category, entry, true
+}
+
+// recordHighScore 成绩能进入排行榜时请玩家输入名字，replayPath 为这局游戏已经保存的录像
+func (ui *GameUI) recordHighScore(category highscore.Category, entry highscore.Entry, replayPath string) {
+	store, err := ui.openHighScores()
+	if err != nil {
+		fyne.Do(func() {
+			dialog.ShowError(err, ui.window)
+		})
+		return
+	}
+
+	entry.Replay = replayPath
+	rank := store.Rank(category, entry)
+	if rank == 0 {
+		return
+	}
+
+	fyne.Do(func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(store.LastName())
+		items := []*widget.FormItem{widget.NewFormItem("名字", nameEntry)}
+		title := fmt.Sprintf("新纪录！%s 第 %d 名", category, rank)
+		form := dialog.NewForm(title, "保存", "取消", items, func(ok bool) {
+			if !ok {
+				return
+			}
+			entry.Name = strings.TrimSpace(nameEntry.Text)
+			if entry.Name == "" {
+				entry.Name = "无名"
+			}
+			if _, err := store.Add(category, entry); err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			ui.showLeaderboard(category)
+		}, ui.window)
+		form.Show()
+		ui.window.Canvas().Focus(nameEntry)
+	})
+}
+
+// showLeaderboard 显示排行榜，selected 为默认显示的分类；选中有录像的成绩时可以播放录像
+func (ui *GameUI) showLeaderboard(selected highscore.Category) {
+	store, err := ui.openHighScores()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	categories := store.Categories()
+	if len(categories) == 0 {
+		dialog.ShowInformation("排行榜", "还没有成绩", ui.window)
+		return
+	}
+
+	var entries []highscore.Entry
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := entries[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%2d. %s  %d 分  %d 行  %s  %s",
+				id+1, entry.Name, entry.Score, entry.Lines, formatTime(entry.Time), entry.Date.Format("2006-01-02")))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		list.UnselectAll()
+		path := entries[id].Replay
+		if path == "" {
+			return
+		}
+		if _, err := os.Stat(path); err != nil {
+			dialog.ShowInformation("排行榜", "这条成绩的录像已被删除或移动", ui.window)
+			return
+		}
+		ui.openReplay(path)
+	}
+
+	names := make([]string, len(categories))
+	index := 0
+	for i, category := range categories {
+		names[i] = category.String()
+		if category == selected {
+			index = i
+		}
+	}
+	categorySelect := widget.NewSelect(names, nil)
+	categorySelect.OnChanged = func(string) {
+		entries = store.Top(categories[categorySelect.SelectedIndex()])
+		list.Refresh()
+	}
+	categorySelect.SetSelectedIndex(index)
+
+	hint := widget.NewLabel("点击有录像的成绩可以播放录像")
+	content := container.NewBorder(categorySelect, hint, nil, nil, list)
+	leaderboard := dialog.NewCustom("排行榜", "关闭", content, ui.window)
+	leaderboard.Resize(fyne.NewSize(560, 440))
+	leaderboard.Show()
+}
@@ -0,0 +1,130 @@
+// Package fyneui 提供电脑玩家的放置提示
+package fyneui
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/ai"
+	"goeluosifangkuai/internal/game"
+)
+
+// 提示格子的显示：填充为方块颜色的淡色，并用亮色描边与其他格子区分
+const hintOpacity = 0.35
+
+var hintStrokeColor = color.RGBA{255, 255, 255, 255}
+
+// hintController 为当前方块计算电脑玩家推荐的落点，并统计玩家按提示放置的次数
+type hintController struct {
+	player   ai.Player
+	enabled  bool
+	target   game.Tetromino // 当前方块的推荐落点
+	key      string         // 推荐落点对应的棋盘和方块，落地、暂存、撤销或重做使其变化时重新计算；空字符串表示需要重新计算
+	placed   int            // 显示了提示的方块数
+	followed int            // 其中按提示放置的方块数
+}
+
+// newHintController 创建提示控制器，与自动演示使用相同的权重
+func newHintController() *hintController {
+	return &hintController{
+		player: newAIPlayer(),
+	}
+}
+
+// reset 开局时清空提示和统计
+func (h *hintController) reset() {
+	h.target = nil
+	h.key = ""
+	h.placed = 0
+	h.followed = 0
+}
+
+// update 棋盘、当前方块或下一个方块变化时重新计算推荐落点
+func (h *hintController) update(gameInstance game.Game) {
+	current := gameInstance.GetCurrentTetromino()
+	if !h.enabled || current == nil {
+		return
+	}
+	key := hintKey(gameInstance)
+	if key == h.key {
+		return
+	}
+
+	h.key = key
+	h.target = nil
+	if best, ok := h.player.FindBest(gameInstance.GetBoard(), current, gameInstance.GetNextTetromino()); ok {
+		h.target = best.Piece
+	}
+}
+
+// hintKey 返回决定推荐落点的局面：棋盘上的格子、当前方块和下一个方块的类型
+func hintKey(gameInstance game.Game) string {
+	board := gameInstance.GetBoard()
+	key := make([]byte, 0, board.GetWidth()*board.GetHeight()+2)
+	for y := 0; y < board.GetHeight(); y++ {
+		for x := 0; x < board.GetWidth(); x++ {
+			key = append(key, byte(board.GetCell(x, y)))
+		}
+	}
+	key = append(key, byte(gameInstance.GetCurrentTetromino().GetType()))
+	if next := gameInstance.GetNextTetromino(); next != nil {
+		key = append(key, byte(next.GetType()))
+	}
+	return string(key)
+}
+
+// onLock 方块落地时统计是否按提示放置
+func (h *hintController) onLock(piece game.Tetromino) {
+	if h.enabled && h.target != nil {
+		h.placed++
+		if game.SameCells(piece, h.target) {
+			h.followed++
+		}
+	}
+	h.target = nil
+	h.key = ""
+}
+
+// visibleTarget 返回需要绘制的推荐落点，关闭提示时返回 nil
+func (h *hintController) visibleTarget() game.Tetromino {
+	if !h.enabled {
+		return nil
+	}
+	return h.target
+}
+
+// status 返回提示开关和跟随统计的文字
+func (h *hintController) status() string {
+	if !h.enabled && h.placed == 0 {
+		return "提示: 关闭（H 键开启）"
+	}
+	text := "提示: 开启"
+	if !h.enabled {
+		text = "提示: 关闭"
+	}
+	if h.placed > 0 {
+		text += fmt.Sprintf("  跟随 %d/%d (%.0f%%)", h.followed, h.placed, float64(h.followed)*100/float64(h.placed))
+	}
+	return text
+}
+
+// createHintLabel 创建提示控制器和显示统计的标签
+func (ui *GameUI) createHintLabel() {
+	ui.hint = newHintController()
+	ui.hintLabel = widget.NewLabel(ui.hint.status())
+}
+
+// toggleHint 切换提示的显示
+func (ui *GameUI) toggleHint() {
+	ui.hint.enabled = !ui.hint.enabled
+	ui.hint.key = ""
+	ui.hintLabel.SetText(ui.hint.status())
+}
+
+// resetHint 开局时清空提示和统计
+func (ui *GameUI) resetHint() {
+	ui.hint.reset()
+	ui.hintLabel.SetText(ui.hint.status())
+}
@@ -0,0 +1,223 @@
+// Package fyneui 提供游戏历史的记录和统计界面
+package fyneui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/highscore"
+	"goeluosifangkuai/internal/history"
+)
+
+// historyPath 返回当前档案的游戏历史文件，没有档案时使用用户数据目录中的文件
+func (ui *GameUI) historyPath() (string, error) {
+	if ui.profile != nil {
+		return ui.profile.HistoryPath(), nil
+	}
+	return history.DefaultPath()
+}
+
+// historyRecord 返回结束的游戏在游戏历史中的记录。与排行榜一样，谜题和自动演示的游戏不记录
+func (ui *GameUI) historyRecord() (history.Record, bool) {
+	if ui.modeID == "" || ui.autoCheck.Checked || ui.tracker == nil {
+		return history.Record{}, false
+	}
+	return ui.tracker.Record(ui.modeID), true
+}
+
+// recordHistory 将结束的游戏追加到游戏历史，replayPath 为这局游戏已经保存的录像
+func (ui *GameUI) recordHistory(record history.Record, replayPath string) {
+	record.Replay = replayPath
+
+	path, err := ui.historyPath()
+	if err == nil {
+		err = history.Append(path, record)
+	}
+	if err != nil {
+		fyne.Do(func() {
+			dialog.ShowError(err, ui.window)
+		})
+	}
+}
+
+// statsMenu 返回统计菜单：统计图表和游戏历史的导出
+func (ui *GameUI) statsMenu() *fyne.Menu {
+	return fyne.NewMenu("统计",
+		fyne.NewMenuItem("统计图表", ui.showStatistics),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("导出游戏历史（CSV）…", func() { ui.exportHistory("csv") }),
+		fyne.NewMenuItem("导出游戏历史（JSON）…", func() { ui.exportHistory("json") }),
+	)
+}
+
+// exportHistory 将当前档案的游戏历史导出为 CSV 或 JSON 文件，格式与 tetris-stats 命令相同
+func (ui *GameUI) exportHistory(format string) {
+	path, err := ui.historyPath()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	records, _, err := history.Load(path)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if format == "csv" {
+			err = history.WriteCSV(writer, records)
+		} else {
+			profileName := ""
+			if ui.profile != nil {
+				profileName = ui.profile.Name
+			}
+			err = history.WriteJSON(writer, profileName, records)
+		}
+		if err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		dialog.ShowInformation("导出游戏历史", fmt.Sprintf("已导出 %d 局游戏到 %s", len(records), writer.URI().Name()), ui.window)
+	}, ui.window)
+	save.SetFileName("history." + format)
+	save.Show()
+}
+
+// showStatistics 显示游戏历史的统计图表：成绩（竞速为通关用时）、PPS 和个人最佳的变化
+func (ui *GameUI) showStatistics() {
+	path, err := ui.historyPath()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	records, skipped, err := history.Load(path)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	categories := history.Categories(records)
+	if len(categories) == 0 {
+		dialog.ShowInformation("统计", "还没有游戏历史", ui.window)
+		return
+	}
+
+	summaryLabel := widget.NewLabel("")
+	resultChart := newLineChart(nil, nil)
+	ppsChart := newLineChart(nil, func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	})
+	bestChart := newLineChart(nil, nil)
+
+	var bests []history.Record
+	var byTime bool
+	bestList := widget.NewList(
+		func() int { return len(bests) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			r := bests[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%s  %s", r.Date.Format("2006-01-02 15:04"), formatResult(r, byTime)))
+		},
+	)
+
+	showCategory := func(category highscore.Category) {
+		games := history.Filter(records, category)
+		bests = history.PersonalBests(records, category)
+		byTime = category.RankByTime()
+
+		// 按用时排名的模式只画通关的用时，其他模式画分数
+		format := func(v float64) string { return strconv.Itoa(int(v)) }
+		if byTime {
+			format = func(v float64) string { return formatTime(int(v)) }
+		}
+		var results, pps, best []float64
+		cleared := 0
+		totalPPS := 0.0
+		for _, r := range games {
+			pps = append(pps, r.PPS)
+			totalPPS += r.PPS
+			if r.Cleared() {
+				cleared++
+			}
+			if !byTime {
+				results = append(results, float64(r.Score))
+			} else if r.Cleared() {
+				results = append(results, float64(r.Time))
+			}
+		}
+		for _, r := range bests {
+			if byTime {
+				best = append(best, float64(r.Time))
+			} else {
+				best = append(best, float64(r.Score))
+			}
+		}
+
+		resultChart.format = format
+		resultChart.SetValues(results)
+		ppsChart.SetValues(pps)
+		bestChart.format = format
+		bestChart.SetValues(best)
+		bestList.Refresh()
+		summaryLabel.SetText(fmt.Sprintf("共 %d 局，通关 %d 局，平均 PPS %.2f，首局 %s，最近一局 %s",
+			len(games), cleared, totalPPS/float64(len(games)),
+			games[0].Date.Format("2006-01-02"), games[len(games)-1].Date.Format("2006-01-02")))
+	}
+
+	// 默认显示当前模式的统计，没有时显示最近玩过的分类
+	current := highscore.CategoryOf(ui.modeID, ui.game.GetConfig())
+	index := 0
+	names := make([]string, len(categories))
+	for i, category := range categories {
+		names[i] = category.String()
+		if category == records[len(records)-1].Category {
+			index = i
+		}
+	}
+	for i, category := range categories {
+		if category == current {
+			index = i
+		}
+	}
+	categorySelect := widget.NewSelect(names, nil)
+	categorySelect.OnChanged = func(string) {
+		showCategory(categories[categorySelect.SelectedIndex()])
+	}
+	categorySelect.SetSelectedIndex(index)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("成绩", resultChart),
+		container.NewTabItem("PPS", ppsChart),
+		container.NewTabItem("个人最佳", container.NewVSplit(bestChart, bestList)),
+	)
+	hint := "横轴为按时间顺序排列的各局游戏；竞速模式的成绩为通关用时"
+	if skipped > 0 {
+		hint = fmt.Sprintf("%s（有 %d 行记录损坏，已跳过）", hint, skipped)
+	}
+	top := container.NewVBox(categorySelect, summaryLabel)
+	content := container.NewBorder(top, widget.NewLabel(hint), nil, nil, tabs)
+	statistics := dialog.NewCustom("统计", "关闭", content, ui.window)
+	statistics.Resize(fyne.NewSize(680, 560))
+	statistics.Show()
+}
+
+// formatResult 返回一局游戏的成绩，按用时排名的模式为用时
+func formatResult(r history.Record, byTime bool) string {
+	if byTime {
+		return formatTime(r.Time)
+	}
+	return fmt.Sprintf("%d 分", r.Score)
+}
@@ -0,0 +1,41 @@
+// Package fyneui 提供游戏模式选择
+package fyneui
+
+import (
+	"goeluosifangkuai/internal/game"
+)
+
+// modeNames 返回所有可选模式的名称
+func modeNames() []string {
+	modes := game.Modes()
+	names := make([]string, len(modes))
+	for i, info := range modes {
+		names[i] = info.Name
+	}
+	return names
+}
+
+// selectMode 切换到指定名称的模式，并创建新的游戏实例
+func (ui *GameUI) selectMode(name string) {
+	if ui.isRunning {
+		return
+	}
+
+	config := game.DefaultGameConfig()
+	if ui.bigCheck.Checked {
+		config = game.BigGameConfig(config)
+	}
+	config.PieceSet = ui.pieceSets[ui.pieceSetSelect.SelectedIndex()]
+	config.Handling = ui.handling()
+
+	for _, info := range game.Modes() {
+		if info.Name == name {
+			ui.newMode = info.Create
+			ui.modeID = info.ID
+			ui.setGame(game.NewGameWithMode(config, info.Create()))
+			ui.statusLabel.SetText("准备开始")
+			ui.updateDisplay()
+			return
+		}
+	}
+}
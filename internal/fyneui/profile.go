@@ -0,0 +1,307 @@
+// Package fyneui 提供玩家档案的选择和设置界面
+package fyneui
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/profile"
+)
+
+// windowTitle 窗口标题，使用档案时后面加上档案名
+const windowTitle = "俄罗斯方块 - Tetris"
+
+// themeNames 可选主题的显示名称，顺序与 themeIDs 相同
+var (
+	themeIDs   = []string{profile.ThemeSystem, profile.ThemeDark, profile.ThemeLight}
+	themeNames = []string{"跟随系统", "深色", "浅色"}
+)
+
+// variantTheme 固定使用深色或浅色的主题，忽略系统设置
+type variantTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+}
+
+// Color 按固定的深浅返回颜色
+func (t variantTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	return t.Theme.Color(name, t.variant)
+}
+
+// applyTheme 切换应用的主题
+func applyTheme(app fyne.App, name string) {
+	switch name {
+	case profile.ThemeDark:
+		app.Settings().SetTheme(variantTheme{theme.DefaultTheme(), theme.VariantDark})
+	case profile.ThemeLight:
+		app.Settings().SetTheme(variantTheme{theme.DefaultTheme(), theme.VariantLight})
+	default:
+		app.Settings().SetTheme(theme.DefaultTheme())
+	}
+}
+
+// chooseProfile 启动时选择或创建玩家档案，选好（或选择不使用档案）后调用 next
+func (ui *GameUI) chooseProfile(next func()) {
+	root, err := profile.Root()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		next()
+		return
+	}
+	profiles, err := profile.List(root)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+	}
+
+	selected := -1
+	list := widget.NewList(
+		func() int { return len(profiles) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			p := profiles[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%s  %d 局  %d 行  最近使用 %s",
+				p.Name, p.Stats.GamesPlayed, p.Stats.TotalLines, p.LastUsed.Format("2006-01-02")))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+	if len(profiles) > 0 {
+		list.Select(0)
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("输入名字创建新档案")
+	content := container.NewBorder(nil, nameEntry, nil, nil, list)
+	chooser := dialog.NewCustomConfirm("选择玩家档案", "使用", "不使用档案", content, func(ok bool) {
+		if !ok {
+			ui.useNoProfile()
+			next()
+			return
+		}
+
+		var chosen *profile.Profile
+		if name := strings.TrimSpace(nameEntry.Text); name != "" {
+			created, err := profile.Create(root, name)
+			if err != nil {
+				// 名字不可用时重新选择
+				errorDialog := dialog.NewError(err, ui.window)
+				errorDialog.SetOnClosed(func() {
+					ui.chooseProfile(next)
+				})
+				errorDialog.Show()
+				return
+			}
+			chosen = created
+		} else if selected >= 0 {
+			chosen = profiles[selected]
+		}
+		if chosen != nil {
+			ui.useProfile(chosen)
+		}
+		next()
+	}, ui.window)
+	chooser.Resize(fyne.NewSize(480, 400))
+	chooser.Show()
+}
+
+// useProfile 切换到指定的档案：使用它的设置和排行榜
+func (ui *GameUI) useProfile(p *profile.Profile) {
+	if err := p.Use(); err != nil {
+		dialog.ShowError(err, ui.window)
+	}
+	if p.Recovered() {
+		dialog.ShowInformation("玩家档案", "档案文件已损坏，已从备份恢复", ui.window)
+	}
+	ui.profile = p
+	ui.highScores = nil
+	ui.applySettings()
+}
+
+// useNoProfile 不使用档案：恢复默认设置，排行榜和游戏历史改用用户数据目录中的文件
+func (ui *GameUI) useNoProfile() {
+	ui.profile = nil
+	ui.highScores = nil
+	ui.applySettings()
+}
+
+// applySettings 应用当前档案的按键、主题和操作手感，没有档案时使用默认设置
+func (ui *GameUI) applySettings() {
+	settings := profile.DefaultSettings()
+	title := windowTitle
+	if ui.profile != nil {
+		settings = ui.profile.Settings
+		title += " - " + ui.profile.Name
+	}
+	ui.window.SetTitle(title)
+	ui.bindKeys(settings.Keys)
+	ui.helpLabel.SetText(helpText(settings.Keys))
+	applyTheme(ui.app, settings.Theme)
+
+	// 用新的操作手感重新创建游戏
+	ui.selectMode(ui.modeSelect.Selected)
+}
+
+// bindKeys 根据按键设置建立按键到操作的映射
+func (ui *GameUI) bindKeys(keys profile.KeyBindings) {
+	ui.keyActions = make(map[fyne.KeyName]string, len(keys))
+	for action, key := range keys {
+		ui.keyActions[fyne.KeyName(key)] = action
+	}
+}
+
+// handling 返回当前档案的操作手感，没有档案或使用模式的设置时返回 nil
+func (ui *GameUI) handling() *game.Handling {
+	if ui.profile == nil {
+		return nil
+	}
+	return ui.profile.Settings.Handling
+}
+
+// keyLabel 返回按键的显示名称
+func keyLabel(key string) string {
+	if key == string(fyne.KeySpace) {
+		return "空格"
+	}
+	return key
+}
+
+// helpText 返回底部的操作说明
+func helpText(keys profile.KeyBindings) string {
+	k := func(action string) string {
+		return keyLabel(keys[action])
+	}
+	return fmt.Sprintf("使用 %s/%s 左右移动，%s/%s 旋转，%s 下降，%s 快速下降，%s 暂存，%s/%s 撤销/重做（禅模式），%s 暂停，%s 提示",
+		k(profile.ActionLeft), k(profile.ActionRight), k(profile.ActionRotateRight), k(profile.ActionRotateLeft),
+		k(profile.ActionSoftDrop), k(profile.ActionHardDrop), k(profile.ActionHold),
+		k(profile.ActionUndo), k(profile.ActionRedo), k(profile.ActionPause), k(profile.ActionHint))
+}
+
+// recordStats 将结束的游戏计入档案的累计统计，自动演示的游戏不计入
+func (ui *GameUI) recordStats() {
+	if ui.profile == nil || ui.autoCheck.Checked {
+		return
+	}
+	ui.profile.Stats.Record(ui.modeID, ui.game.GetState(), ui.game.GetLinesCleared(), ui.game.GetElapsedTime())
+	if err := ui.profile.Save(); err != nil {
+		fyne.Do(func() {
+			dialog.ShowError(err, ui.window)
+		})
+	}
+}
+
+// statsText 返回档案累计统计的说明
+func statsText(stats profile.Stats) string {
+	best := "未通关"
+	if stats.BestSprint > 0 {
+		best = formatTime(stats.BestSprint)
+	}
+	return fmt.Sprintf("已玩 %d 局，共消除 %d 行，游戏时间 %s，竞速最佳 %s",
+		stats.GamesPlayed, stats.TotalLines, formatTime(stats.TimePlayed), best)
+}
+
+// showProfileSettings 显示当前档案的统计并修改它的设置，没有档案时选择档案
+func (ui *GameUI) showProfileSettings() {
+	if ui.profile == nil {
+		ui.chooseProfile(ui.offerResume)
+		return
+	}
+	settings := ui.profile.Settings
+
+	var items []*widget.FormItem
+	items = append(items, widget.NewFormItem("统计", widget.NewLabel(statsText(ui.profile.Stats))))
+
+	keyEntries := make(map[string]*widget.Entry)
+	for _, action := range profile.Actions() {
+		entry := widget.NewEntry()
+		entry.SetText(settings.Keys[action.ID])
+		keyEntries[action.ID] = entry
+		items = append(items, widget.NewFormItem(action.Name, entry))
+	}
+
+	// 操作手感以帧为单位，不自定义时使用各模式的设置
+	handling := game.Handling{DAS: game.DefaultTiming().DAS, ARR: game.DefaultTiming().ARR}
+	if settings.Handling != nil {
+		handling = *settings.Handling
+	}
+	dasEntry := widget.NewEntry()
+	dasEntry.SetText(strconv.Itoa(handling.DAS))
+	arrEntry := widget.NewEntry()
+	arrEntry.SetText(strconv.Itoa(handling.ARR))
+	enableHandling := func(checked bool) {
+		if checked {
+			dasEntry.Enable()
+			arrEntry.Enable()
+		} else {
+			dasEntry.Disable()
+			arrEntry.Disable()
+		}
+	}
+	handlingCheck := widget.NewCheck("自定义（否则使用模式的设置）", enableHandling)
+	handlingCheck.Checked = settings.Handling != nil
+	enableHandling(handlingCheck.Checked)
+	items = append(items,
+		widget.NewFormItem("操作手感", handlingCheck),
+		widget.NewFormItem("DAS（帧）", dasEntry),
+		widget.NewFormItem("ARR（帧）", arrEntry),
+	)
+
+	themeSelect := widget.NewSelect(themeNames, nil)
+	themeSelect.SetSelectedIndex(0)
+	for i, id := range themeIDs {
+		if id == settings.Theme {
+			themeSelect.SetSelectedIndex(i)
+		}
+	}
+	items = append(items, widget.NewFormItem("主题", themeSelect))
+
+	var form dialog.Dialog
+	switchButton := widget.NewButton("切换档案", func() {
+		form.Hide()
+		ui.chooseProfile(ui.offerResume)
+	})
+	items = append(items, widget.NewFormItem("", switchButton))
+
+	form = dialog.NewForm("玩家档案："+ui.profile.Name, "保存", "取消", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		updated := profile.Settings{
+			Keys:  make(profile.KeyBindings),
+			Theme: themeIDs[themeSelect.SelectedIndex()],
+		}
+		for action, entry := range keyEntries {
+			updated.Keys[action] = strings.TrimSpace(entry.Text)
+		}
+		if err := updated.Keys.Validate(); err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		if handlingCheck.Checked {
+			das, dasErr := strconv.Atoi(strings.TrimSpace(dasEntry.Text))
+			arr, arrErr := strconv.Atoi(strings.TrimSpace(arrEntry.Text))
+			if dasErr != nil || arrErr != nil || das < 0 || arr < 0 {
+				dialog.ShowError(fmt.Errorf("DAS 和 ARR 应为不小于 0 的整数"), ui.window)
+				return
+			}
+			updated.Handling = &game.Handling{DAS: das, ARR: arr}
+		}
+
+		ui.profile.Settings = updated
+		if err := ui.profile.Save(); err != nil {
+			dialog.ShowError(err, ui.window)
+		}
+		ui.applySettings()
+	}, ui.window)
+	form.Resize(fyne.NewSize(520, 600))
+	form.Show()
+}
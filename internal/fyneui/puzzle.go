@@ -0,0 +1,66 @@
+// Package fyneui 提供谜题浏览界面
+package fyneui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// showPuzzleBrowser 显示谜题列表，选中后开始该谜题
+func (ui *GameUI) showPuzzleBrowser() {
+	if ui.isRunning {
+		return
+	}
+
+	puzzles, err := game.LoadPuzzles(types.PuzzleDir)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	if len(puzzles) == 0 {
+		dialog.ShowInformation("谜题", "没有找到谜题文件（"+types.PuzzleDir+"/*.json）", ui.window)
+		return
+	}
+
+	description := widget.NewLabel("")
+	description.Wrapping = fyne.TextWrapWord
+
+	list := widget.NewList(
+		func() int { return len(puzzles) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(puzzles[id].Name)
+		},
+	)
+
+	selected := -1
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		description.SetText(puzzles[id].Description)
+	}
+
+	content := container.NewBorder(nil, description, nil, nil, list)
+	browser := dialog.NewCustomConfirm("选择谜题", "开始", "取消", content, func(ok bool) {
+		if ok && selected >= 0 {
+			ui.startPuzzle(puzzles[selected])
+		}
+	}, ui.window)
+	browser.Resize(fyne.NewSize(400, 400))
+	browser.Show()
+}
+
+// startPuzzle 以谜题模式创建新游戏并立即开始，失败后可用“重新开始”重试
+func (ui *GameUI) startPuzzle(puzzle *game.Puzzle) {
+	// 谜题按标准棋盘尺寸编写，不与大方块变体组合
+	ui.newMode = func() game.Mode { return game.NewPuzzleMode(puzzle) }
+	ui.modeID = ""
+	config := game.DefaultGameConfig()
+	config.Handling = ui.handling()
+	ui.setGame(game.NewGameWithMode(config, ui.newMode()))
+	ui.startGame()
+}
@@ -0,0 +1,69 @@
+// Package fyneui 提供游戏录像的录制和保存
+package fyneui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+
+	"goeluosifangkuai/internal/replay"
+)
+
+// startRecording 开局时从头开始录制，谜题等没有模式标识的游戏不录制。
+// 继续保存的游戏时录像已经恢复，接着录制
+func (ui *GameUI) startRecording() {
+	if ui.recorder != nil && !ui.recorder.IsRecording() {
+		ui.recorder.Start()
+	}
+}
+
+// restartRecording 重新开始时放弃当前的录像，从头开始录制
+func (ui *GameUI) restartRecording() {
+	if ui.recorder != nil {
+		ui.recorder.Stop()
+		ui.recorder.Start()
+	}
+}
+
+// saveReplay 游戏结束后校验录像能否一致地重现，通过后保存到用户数据目录中的录像目录。
+// 校验和保存在后台进行，完成后以录像的绝对路径调用 done；没有录制或保存失败时以空字符串调用
+func (ui *GameUI) saveReplay(done func(path string)) {
+	var recorded *replay.Replay
+	if ui.recorder != nil {
+		recorded = ui.recorder.Stop()
+	}
+	if recorded == nil {
+		done("")
+		return
+	}
+
+	// 重现整局游戏需要一些时间，不阻塞界面
+	go func() {
+		path, err := verifyAndSaveReplay(recorded)
+		text := "录像已保存: " + path
+		if err != nil {
+			text = err.Error()
+		}
+		fyne.Do(func() {
+			ui.statusLabel.SetText(ui.statusLabel.Text + "\n" + text)
+		})
+		done(path)
+	}()
+}
+
+// verifyAndSaveReplay 校验录像后保存到录像目录，返回保存的路径，失败时返回空字符串和错误
+func verifyAndSaveReplay(recorded *replay.Replay) (string, error) {
+	if err := replay.Verify(recorded); err != nil {
+		return "", fmt.Errorf("录像校验失败，未保存: %w", err)
+	}
+	dir, err := replay.DefaultDir()
+	if err != nil {
+		return "", fmt.Errorf("保存录像失败: %w", err)
+	}
+	path := filepath.Join(dir, replay.FileName(recorded))
+	if err := replay.Save(path, recorded); err != nil {
+		return "", fmt.Errorf("保存录像失败: %w", err)
+	}
+	return path, nil
+}
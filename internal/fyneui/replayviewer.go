@@ -0,0 +1,294 @@
+// Package fyneui 提供录像的浏览和播放界面
+package fyneui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/replay"
+	"goeluosifangkuai/pkg/types"
+)
+
+// replaySpeeds 可选的播放速度
+var replaySpeeds = []struct {
+	name  string
+	speed float64
+}{
+	{"0.25x", 0.25},
+	{"0.5x", 0.5},
+	{"1x", 1},
+	{"2x", 2},
+	{"4x", 4},
+	{"8x", 8},
+}
+
+// replayViewer 在单独的窗口中播放录像。播放器只在播放协程中访问，
+// 界面上的操作作为命令发送给播放协程执行，避免与界面线程互相等待
+type replayViewer struct {
+	ui       *GameUI
+	window   fyne.Window
+	player   *replay.Player
+	commands chan func()
+	done     chan struct{}
+
+	cells      [][]*canvas.Rectangle
+	infoLabel  *widget.Label
+	frameLabel *widget.Label
+	playButton *widget.Button
+	slider     *widget.Slider
+
+	// 正在由刷新设置进度条的位置，只在界面线程中访问。SetValue 同样会触发 OnChangeEnded，
+	// 此时不是玩家拖动进度条，不应跳转
+	settingSlider bool
+
+	// 以下字段只在播放协程中访问
+	playing bool
+	speed   float64
+	pending float64 // 累积的不足一帧的播放进度
+}
+
+// showReplayBrowser 显示录像列表，选中后在新窗口中播放
+func (ui *GameUI) showReplayBrowser() {
+	if ui.isRunning {
+		return
+	}
+
+	dir, err := replay.DefaultDir()
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	paths, err := replay.List(dir)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	if len(paths) == 0 {
+		dialog.ShowInformation("录像", "没有找到录像文件（"+filepath.Join(dir, "*"+types.ReplayExt)+"）", ui.window)
+		return
+	}
+
+	list := widget.NewList(
+		func() int { return len(paths) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(filepath.Base(paths[id]))
+		},
+	)
+
+	selected := -1
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+
+	browser := dialog.NewCustomConfirm("选择录像", "播放", "取消", list, func(ok bool) {
+		if ok && selected >= 0 {
+			ui.openReplay(paths[selected])
+		}
+	}, ui.window)
+	browser.Resize(fyne.NewSize(400, 400))
+	browser.Show()
+}
+
+// openReplay 加载录像文件并打开播放窗口
+func (ui *GameUI) openReplay(path string) {
+	recorded, err := replay.Load(path)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	player, err := replay.NewPlayer(recorded)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+
+	modeName := recorded.Mode
+	if info, ok := game.FindMode(recorded.Mode); ok {
+		modeName = info.Name
+	}
+	title := fmt.Sprintf("录像 - %s %s", modeName, recorded.Date.Format("2006-01-02 15:04"))
+
+	viewer := &replayViewer{
+		ui:       ui,
+		window:   ui.app.NewWindow(title),
+		player:   player,
+		commands: make(chan func(), 16),
+		done:     make(chan struct{}),
+		speed:    1,
+	}
+	viewer.setupUI()
+	viewer.window.SetOnClosed(func() {
+		close(viewer.done)
+	})
+	viewer.window.Show()
+	go viewer.run()
+}
+
+// setupUI 创建棋盘、进度条和播放控制
+func (v *replayViewer) setupUI() {
+	var board *fyne.Container
+	v.cells, board = createBoardGrid(20, 10)
+	v.infoLabel = widget.NewLabel("")
+	v.frameLabel = widget.NewLabel("")
+
+	v.slider = widget.NewSlider(0, float64(v.player.Length()))
+	v.slider.Step = 1
+	v.slider.OnChangeEnded = func(value float64) {
+		if v.settingSlider {
+			return
+		}
+		v.send(func() { v.seek(int(value)) })
+	}
+
+	v.playButton = widget.NewButton("播放", func() {
+		v.send(v.togglePlaying)
+	})
+	previousButton := widget.NewButton("上一帧", func() {
+		v.send(func() { v.seek(v.player.Frame() - 1) })
+	})
+	nextButton := widget.NewButton("下一帧", func() {
+		v.send(v.stepFrame)
+	})
+
+	speedNames := make([]string, len(replaySpeeds))
+	for i, option := range replaySpeeds {
+		speedNames[i] = option.name
+	}
+	speedSelect := widget.NewSelect(speedNames, nil)
+	speedSelect.SetSelected("1x")
+	speedSelect.OnChanged = func(string) {
+		speed := replaySpeeds[speedSelect.SelectedIndex()].speed
+		v.send(func() { v.speed = speed })
+	}
+
+	controls := container.NewBorder(nil, nil,
+		container.NewHBox(v.playButton, previousButton, nextButton, speedSelect),
+		v.frameLabel,
+		v.slider,
+	)
+	v.window.SetContent(container.NewBorder(nil, controls, nil,
+		container.NewVBox(v.infoLabel),
+		container.NewCenter(container.NewGridWrap(board.Size(), board)),
+	))
+
+	// 空格播放/暂停，左右方向键逐帧后退/前进
+	v.window.Canvas().SetOnTypedKey(func(event *fyne.KeyEvent) {
+		switch event.Name {
+		case fyne.KeySpace:
+			v.send(v.togglePlaying)
+		case fyne.KeyLeft:
+			v.send(func() { v.seek(v.player.Frame() - 1) })
+		case fyne.KeyRight:
+			v.send(v.stepFrame)
+		}
+	})
+	v.window.Resize(fyne.NewSize(640, 640))
+}
+
+// send 将命令交给播放协程执行，命令堆积时丢弃新的命令
+func (v *replayViewer) send(command func()) {
+	select {
+	case v.commands <- command:
+	default:
+	}
+}
+
+// run 播放协程：执行界面发来的命令，播放时按速度推进帧，每次变化后刷新显示
+func (v *replayViewer) run() {
+	ticker := time.NewTicker(time.Millisecond * 2 * types.FrameDuration)
+	defer ticker.Stop()
+	lastUpdate := time.Now()
+	v.refresh()
+
+	for {
+		select {
+		case <-v.done:
+			return
+		case command := <-v.commands:
+			command()
+		case now := <-ticker.C:
+			elapsed := int(now.Sub(lastUpdate) / time.Millisecond)
+			lastUpdate = now
+			if !v.playing {
+				continue
+			}
+			v.pending += float64(elapsed) / types.FrameDuration * v.speed
+			for ; v.pending >= 1; v.pending-- {
+				if !v.player.Step() {
+					v.setPlaying(false)
+					break
+				}
+			}
+		}
+		v.refresh()
+	}
+}
+
+// togglePlaying 切换播放/暂停，在结尾处播放时从头开始
+func (v *replayViewer) togglePlaying() {
+	if !v.playing && v.player.Finished() {
+		v.seek(0)
+	}
+	v.setPlaying(!v.playing)
+}
+
+// setPlaying 设置是否正在播放并更新按钮文字
+func (v *replayViewer) setPlaying(playing bool) {
+	v.playing = playing
+	v.pending = 0
+	text := "播放"
+	if playing {
+		text = "暂停"
+	}
+	fyne.Do(func() {
+		v.playButton.SetText(text)
+	})
+}
+
+// stepFrame 暂停并前进一帧
+func (v *replayViewer) stepFrame() {
+	v.setPlaying(false)
+	v.player.Step()
+}
+
+// seek 暂停并跳转到指定的帧
+func (v *replayViewer) seek(frame int) {
+	v.setPlaying(false)
+	if err := v.player.Seek(frame); err != nil {
+		fyne.Do(func() {
+			dialog.ShowError(err, v.window)
+		})
+	}
+}
+
+// refresh 绘制当前帧的棋盘并更新信息和进度
+func (v *replayViewer) refresh() {
+	g := v.player.Game()
+	v.ui.drawBoard(v.cells, g, nil)
+
+	frame := v.player.Frame()
+	info := fmt.Sprintf("分数: %d\n等级: %d\n行数: %d\n%s",
+		g.GetScore(), g.GetLevel(), g.GetLinesCleared(), g.GetMode().GetStatus(g))
+	progress := fmt.Sprintf("%s / %s", formatTime(frame*types.FrameDuration), formatTime(v.player.Length()*types.FrameDuration))
+	fyne.Do(func() {
+		v.infoLabel.SetText(info)
+		v.frameLabel.SetText(progress)
+		v.settingSlider = true
+		v.slider.SetValue(float64(frame))
+		v.settingSlider = false
+	})
+}
+
+// formatTime 将毫秒数格式化为 分:秒.百分秒
+func formatTime(millis int) string {
+	return fmt.Sprintf("%d:%02d.%02d", millis/60000, millis/1000%60, millis/10%100)
+}
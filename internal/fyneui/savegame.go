@@ -0,0 +1,145 @@
+// Package fyneui 提供未完成游戏的保存和继续
+package fyneui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/savegame"
+	"goeluosifangkuai/pkg/types"
+)
+
+// saveAndQuit 保存进行中的游戏（包括到目前为止的录像）并关闭窗口，下次启动时可以继续
+func (ui *GameUI) saveAndQuit() {
+	if !ui.isRunning {
+		return
+	}
+	if ui.modeID == "" {
+		dialog.ShowInformation("保存游戏", "谜题不能保存", ui.window)
+		return
+	}
+	ui.requestSave(false)
+}
+
+// setupCloseIntercept 关闭窗口时先保存进行中的游戏，谜题和没有进行中的游戏时直接关闭
+func (ui *GameUI) setupCloseIntercept() {
+	ui.window.SetCloseIntercept(ui.Close)
+}
+
+// requestSave 请求游戏循环在两次更新之间保存游戏，保存时游戏状态不会变化。
+// 保存成功后由游戏循环停止自身并关闭窗口；closing 为 true 表示玩家正在关闭窗口，保存失败时询问是否不保存直接关闭
+func (ui *GameUI) requestSave(closing bool) {
+	select {
+	case ui.saveRequests <- closing:
+	default:
+		// 已经有一个保存请求在等待处理
+	}
+}
+
+// handleSaveRequest 在游戏循环中保存游戏，返回 true 表示保存成功，游戏循环应当退出
+func (ui *GameUI) handleSaveRequest(closing bool) bool {
+	save, err := savegame.New(ui.game, ui.modeID, ui.recorder)
+	if err == nil {
+		var path string
+		if path, err = ui.saveGamePath(); err == nil {
+			err = savegame.Save(path, save)
+		}
+	}
+	if err == nil {
+		ui.isRunning = false
+		fyne.Do(ui.closeWindow)
+		return true
+	}
+
+	fyne.Do(func() {
+		if !closing {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		message := fmt.Sprintf("保存游戏失败: %v\n仍然关闭窗口吗？这局游戏将不会保存。", err)
+		dialog.ShowConfirm("保存游戏", message, func(ok bool) {
+			if ok {
+				ui.isRunning = false
+				ui.closeWindow()
+			}
+		}, ui.window)
+	})
+	return false
+}
+
+// saveGamePath 返回当前档案的存档文件，没有档案时使用用户数据目录中的文件
+func (ui *GameUI) saveGamePath() (string, error) {
+	if ui.profile != nil {
+		return ui.profile.SaveGamePath(), nil
+	}
+	return savegame.DefaultPath()
+}
+
+// offerResume 发现当前档案保存的游戏时询问是否继续，放弃时删除存档
+func (ui *GameUI) offerResume() {
+	path, err := ui.saveGamePath()
+	if err != nil || !savegame.Exists(path) {
+		return
+	}
+	save, err := savegame.Load(path)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("存档已损坏，将被删除: %w", err), ui.window)
+		savegame.Remove(path)
+		return
+	}
+
+	modeName := save.Mode
+	if info, ok := game.FindMode(save.Mode); ok {
+		modeName = info.Name
+	}
+	message := fmt.Sprintf("发现 %s 保存的%s模式游戏，是否继续？", save.Date.Format("2006-01-02 15:04"), modeName)
+	confirm := dialog.NewConfirm("继续游戏", message, func(ok bool) {
+		if ok {
+			ui.resumeGame(path, save)
+		} else {
+			savegame.Remove(path)
+		}
+	}, ui.window)
+	confirm.SetConfirmText("继续")
+	confirm.SetDismissText("放弃")
+	confirm.Show()
+}
+
+// resumeGame 恢复保存的游戏和录像并继续进行，存档只能继续一次，恢复后删除 path 处的存档
+func (ui *GameUI) resumeGame(path string, save *savegame.SaveGame) {
+	restored, recorded, err := save.Restore()
+	savegame.Remove(path)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+
+	info, _ := game.FindMode(save.Mode)
+	ui.newMode = info.Create
+	ui.modeID = info.ID
+	ui.showGameSettings(info.Name, restored.GetConfig())
+	ui.setGame(restored)
+	if ui.recorder != nil && recorded != nil {
+		ui.recorder.Resume(recorded)
+	}
+	ui.startGame()
+}
+
+// showGameSettings 让模式、大方块和方块集控件显示恢复的游戏的设置，不创建新游戏
+func (ui *GameUI) showGameSettings(modeName string, config game.GameConfig) {
+	onModeChanged := ui.modeSelect.OnChanged
+	ui.modeSelect.OnChanged = nil
+	ui.modeSelect.SetSelected(modeName)
+	ui.modeSelect.OnChanged = onModeChanged
+
+	ui.bigCheck.Checked = config.BoardWidth < types.BoardWidth
+	ui.bigCheck.Refresh()
+
+	onPieceSetChanged := ui.pieceSetSelect.OnChanged
+	ui.pieceSetSelect.OnChanged = nil
+	ui.pieceSetSelect.SetSelected(config.PieceSet.Name)
+	ui.pieceSetSelect.OnChanged = onPieceSetChanged
+}
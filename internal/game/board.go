@@ -7,28 +7,47 @@ import (
 
 // board 是 Board 接口的具体实现
 type board struct {
-	width  int
-	height int
-	cells  [][]types.Color
+	width      int
+	height     int
+	cells      [][]types.Color
+	lockFrames [][]int // 每个单元格被固定时的逻辑帧，空单元格为 -1
+	clock      int     // 当前逻辑帧
 }
 
 // NewBoard 创建新的游戏棋盘
 func NewBoard(width, height int) Board {
 	cells := make([][]types.Color, height)
+	lockFrames := make([][]int, height)
 	for i := range cells {
 		cells[i] = make([]types.Color, width)
+		lockFrames[i] = make([]int, width)
 		for j := range cells[i] {
 			cells[i][j] = types.ColorEmpty
+			lockFrames[i][j] = -1
 		}
 	}
 
 	return &board{
-		width:  width,
-		height: height,
-		cells:  cells,
+		width:      width,
+		height:     height,
+		cells:      cells,
+		lockFrames: lockFrames,
 	}
 }
 
+// CloneBoard 复制棋盘上的方块，不复制固定时间
+func CloneBoard(board Board) Board {
+	clone := NewBoard(board.GetWidth(), board.GetHeight())
+	for y := 0; y < board.GetHeight(); y++ {
+		for x := 0; x < board.GetWidth(); x++ {
+			if color := board.GetCell(x, y); color != types.ColorEmpty {
+				clone.SetCell(x, y, color)
+			}
+		}
+	}
+	return clone
+}
+
 // GetWidth 返回棋盘宽度
 func (b *board) GetWidth() int {
 	return b.width
@@ -50,10 +69,33 @@ func (b *board) GetCell(x, y int) types.Color {
 // SetCell 设置指定位置的单元格颜色
 func (b *board) SetCell(x, y int, color types.Color) {
 	if x >= 0 && x < b.width && y >= 0 && y < b.height {
-		b.cells[y][x] = color
+		b.setCell(x, y, color)
 	}
 }
 
+// setCell 设置单元格颜色并记录固定时间
+func (b *board) setCell(x, y int, color types.Color) {
+	b.cells[y][x] = color
+	if color == types.ColorEmpty {
+		b.lockFrames[y][x] = -1
+	} else {
+		b.lockFrames[y][x] = b.clock
+	}
+}
+
+// SetClock 设置当前逻辑帧
+func (b *board) SetClock(frame int) {
+	b.clock = frame
+}
+
+// GetLockFrame 返回单元格被固定时的逻辑帧
+func (b *board) GetLockFrame(x, y int) int {
+	if x < 0 || x >= b.width || y < 0 || y >= b.height {
+		return -1
+	}
+	return b.lockFrames[y][x]
+}
+
 // IsValidPosition 检查方块是否可以放置在指定位置
 func (b *board) IsValidPosition(tetromino Tetromino) bool {
 	position := tetromino.GetPosition()
@@ -89,7 +131,7 @@ func (b *board) PlaceTetromino(tetromino Tetromino) {
 
 		// 只在有效范围内放置方块
 		if x >= 0 && x < b.width && y >= 0 && y < b.height {
-			b.cells[y][x] = color
+			b.setCell(x, y, color)
 		}
 	}
 }
@@ -110,6 +152,48 @@ func (b *board) ClearLines() int {
 	return clearedLines
 }
 
+// InsertRows 从底部插入行，原有方块整体上移
+func (b *board) InsertRows(rows [][]types.Color) bool {
+	count := len(rows)
+	if count == 0 {
+		return true
+	}
+	if count > b.height {
+		rows = rows[count-b.height:]
+		count = b.height
+	}
+
+	// 检查被挤出顶部的行是否有方块
+	fits := true
+	for y := 0; y < count; y++ {
+		for x := 0; x < b.width; x++ {
+			if b.cells[y][x] != types.ColorEmpty {
+				fits = false
+			}
+		}
+	}
+
+	// 将所有行向上移动
+	for y := 0; y < b.height-count; y++ {
+		copy(b.cells[y], b.cells[y+count])
+		copy(b.lockFrames[y], b.lockFrames[y+count])
+	}
+
+	// 在底部填入新行
+	for i, row := range rows {
+		y := b.height - count + i
+		for x := 0; x < b.width; x++ {
+			if x < len(row) {
+				b.setCell(x, y, row[x])
+			} else {
+				b.setCell(x, y, types.ColorEmpty)
+			}
+		}
+	}
+
+	return fits
+}
+
 // isLineFull 检查指定行是否已满
 func (b *board) isLineFull(y int) bool {
 	if y < 0 || y >= b.height {
@@ -131,19 +215,21 @@ func (b *board) clearLine(lineY int) {
 	for y := lineY; y > 0; y-- {
 		for x := 0; x < b.width; x++ {
 			b.cells[y][x] = b.cells[y-1][x]
+			b.lockFrames[y][x] = b.lockFrames[y-1][x]
 		}
 	}
 
 	// 清空顶部行
 	for x := 0; x < b.width; x++ {
-		b.cells[0][x] = types.ColorEmpty
+		b.setCell(x, 0, types.ColorEmpty)
 	}
 }
 
 // IsGameOver 检查游戏是否结束
 func (b *board) IsGameOver() bool {
-	// 检查顶部几行是否有方块
-	for y := 0; y < 4; y++ {
+	// 检查顶部几行是否有方块，危险区域按棋盘高度等比例缩放（20 行的棋盘为 4 行）
+	dangerRows := b.height / 5
+	for y := 0; y < dangerRows; y++ {
 		for x := 0; x < b.width; x++ {
 			if b.cells[y][x] != types.ColorEmpty {
 				return true
@@ -154,11 +240,23 @@ func (b *board) IsGameOver() bool {
 	return false
 }
 
+// IsEmpty 检查棋盘上是否没有任何方块
+func (b *board) IsEmpty() bool {
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			if b.cells[y][x] != types.ColorEmpty {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // Clear 清空棋盘
 func (b *board) Clear() {
 	for y := 0; y < b.height; y++ {
 		for x := 0; x < b.width; x++ {
-			b.cells[y][x] = types.ColorEmpty
+			b.setCell(x, y, types.ColorEmpty)
 		}
 	}
 }
@@ -0,0 +1,80 @@
+// Package game 实现局面编辑器使用的棋盘操作
+package game
+
+import (
+	"goeluosifangkuai/pkg/types"
+)
+
+// mirroredColors 左右镜像后方块颜色的对应关系：J 与 L、S 与 Z 互为镜像
+var mirroredColors = map[types.Color]types.Color{
+	types.ColorJ: types.ColorL,
+	types.ColorL: types.ColorJ,
+	types.ColorS: types.ColorZ,
+	types.ColorZ: types.ColorS,
+}
+
+// MirrorBoard 将棋盘左右翻转，J 与 L、S 与 Z 的颜色随之互换，与 fumen 编辑器的镜像相同
+func MirrorBoard(b Board) {
+	width := b.GetWidth()
+	mirror := func(color types.Color) types.Color {
+		if mirrored, ok := mirroredColors[color]; ok {
+			return mirrored
+		}
+		return color
+	}
+	for y := 0; y < b.GetHeight(); y++ {
+		for x := 0; x < width/2; x++ {
+			left, right := b.GetCell(x, y), b.GetCell(width-1-x, y)
+			b.SetCell(x, y, mirror(right))
+			b.SetCell(width-1-x, y, mirror(left))
+		}
+		if width%2 == 1 {
+			b.SetCell(width/2, y, mirror(b.GetCell(width/2, y)))
+		}
+	}
+}
+
+// ShiftBoard 将棋盘上的方块整体上移（dy < 0）或下移（dy > 0）|dy| 行，移出棋盘的方块被丢弃
+func ShiftBoard(b Board, dy int) {
+	height := b.GetHeight()
+	rows := make([][]types.Color, height)
+	for y := range rows {
+		rows[y] = make([]types.Color, b.GetWidth())
+		for x := range rows[y] {
+			rows[y][x] = b.GetCell(x, y-dy)
+		}
+	}
+	for y, row := range rows {
+		for x, color := range row {
+			b.SetCell(x, y, color)
+		}
+	}
+}
+
+// ClearRow 清空指定的行，其他行保持不动
+func ClearRow(b Board, y int) {
+	for x := 0; x < b.GetWidth(); x++ {
+		b.SetCell(x, y, types.ColorEmpty)
+	}
+}
+
+// BoardRows 返回棋盘上从最高的方块到底部的行（从上到下），可以直接用作谜题的初始棋盘
+func BoardRows(b Board) [][]types.Color {
+	top := b.GetHeight()
+	for y := b.GetHeight() - 1; y >= 0; y-- {
+		for x := 0; x < b.GetWidth(); x++ {
+			if b.GetCell(x, y) != types.ColorEmpty {
+				top = y
+			}
+		}
+	}
+	var rows [][]types.Color
+	for y := top; y < b.GetHeight(); y++ {
+		row := make([]types.Color, b.GetWidth())
+		for x := range row {
+			row[x] = b.GetCell(x, y)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -9,33 +9,88 @@ import (
 
 // TetrominoFactory 俄罗斯方块工厂
 type TetrominoFactory struct {
-	random *rand.Rand
+	random   *rand.Rand
+	pieceSet *PieceSet
+
+	// 随机生成方式，7-bag 时 bag 为当前袋中剩余的方块
+	randomizer types.Randomizer
+	bag        []types.TetrominoType
+
+	// 固定的方块序列，设置后按顺序生成方块而不再随机
+	sequence    []types.TetrominoType
+	useSequence bool
 }
 
 // NewTetrominoFactory 创建新的方块工厂
 func NewTetrominoFactory() *TetrominoFactory {
+	return NewTetrominoFactoryWithRandom(rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// NewTetrominoFactoryWithRandom 使用指定的随机数生成器创建方块工厂
+func NewTetrominoFactoryWithRandom(random *rand.Rand) *TetrominoFactory {
 	return &TetrominoFactory{
-		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+		random:   random,
+		pieceSet: StandardPieceSet(),
 	}
 }
 
-// CreateRandomTetromino 创建随机的俄罗斯方块
+// SetPieceSet 设置生成方块所用的方块集，并清空当前的 7-bag
+func (f *TetrominoFactory) SetPieceSet(pieceSet *PieceSet) {
+	f.pieceSet = pieceSet
+	f.bag = nil
+}
+
+// CreateRandomTetromino 从方块集中随机创建方块
 func (f *TetrominoFactory) CreateRandomTetromino() Tetromino {
-	tetrominoTypes := []types.TetrominoType{
-		types.TetrominoI,
-		types.TetrominoO,
-		types.TetrominoT,
-		types.TetrominoS,
-		types.TetrominoZ,
-		types.TetrominoJ,
-		types.TetrominoL,
+	pieceTypes := f.pieceSet.Types()
+	randomType := pieceTypes[f.random.Intn(len(pieceTypes))]
+	return f.pieceSet.NewPiece(randomType)
+}
+
+// SetRandomizer 设置随机生成方式，并清空当前的 7-bag
+func (f *TetrominoFactory) SetRandomizer(randomizer types.Randomizer) {
+	f.randomizer = randomizer
+	f.bag = nil
+}
+
+// createBagTetromino 从 7-bag 中取出下一个方块，袋子取空后重新打乱方块集中的所有方块
+func (f *TetrominoFactory) createBagTetromino() Tetromino {
+	if len(f.bag) == 0 {
+		f.bag = f.pieceSet.Types()
+		f.random.Shuffle(len(f.bag), func(i, j int) {
+			f.bag[i], f.bag[j] = f.bag[j], f.bag[i]
+		})
+	}
+
+	tetrominoType := f.bag[0]
+	f.bag = f.bag[1:]
+	return f.pieceSet.NewPiece(tetrominoType)
+}
+
+// SetSequence 设置固定的方块序列，nil 表示恢复随机生成
+func (f *TetrominoFactory) SetSequence(sequence []types.TetrominoType) {
+	f.sequence = append([]types.TetrominoType(nil), sequence...)
+	f.useSequence = sequence != nil
+}
+
+// CreateNextTetromino 创建下一个方块：设置了固定序列时按序列生成，序列用完后返回 nil；否则按随机生成方式生成
+func (f *TetrominoFactory) CreateNextTetromino() Tetromino {
+	if !f.useSequence {
+		if f.randomizer == types.RandomizerBag {
+			return f.createBagTetromino()
+		}
+		return f.CreateRandomTetromino()
+	}
+	if len(f.sequence) == 0 {
+		return nil
 	}
 
-	randomType := tetrominoTypes[f.random.Intn(len(tetrominoTypes))]
-	return NewTetromino(randomType)
+	tetrominoType := f.sequence[0]
+	f.sequence = f.sequence[1:]
+	return f.pieceSet.NewPiece(tetrominoType)
 }
 
 // CreateSpecificTetromino 创建指定类型的俄罗斯方块
 func (f *TetrominoFactory) CreateSpecificTetromino(tetrominoType types.TetrominoType) Tetromino {
-	return NewTetromino(tetrominoType)
+	return f.pieceSet.NewPiece(tetrominoType)
 }
@@ -0,0 +1,207 @@
+// Package game 实现最简操作（finesse）检查
+package game
+
+import (
+	"container/heap"
+	"strings"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// FinesseResult 一个方块的最简操作检查结果。按键只计算左右移动和旋转，
+// 按住左右键自动重复移动到墙边只计一次，下降不计
+type FinesseResult struct {
+	Spawn   Tetromino     // 方块出现时的位置和旋转状态
+	Piece   Tetromino     // 落地固定的方块
+	Inputs  int           // 玩家实际的按键次数
+	Minimal int           // 到达该放置最少需要的按键次数
+	Optimal []types.Input // 最少按键的操作序列，以快速下降结束；按住移动到墙边表示为 InputShiftLeft/InputShiftRight
+	Faults  int           // 多余的按键次数，0 表示符合最简操作
+}
+
+// Placement 一次固定方块的记录，用于在需要时计算最简操作。记录中的棋盘和方块是固定时的副本，
+// 之后不会被游戏修改，可以在其他 goroutine 中检查
+type Placement struct {
+	Board  Board     // 固定前的棋盘
+	Spawn  Tetromino // 方块出现时的位置和旋转状态
+	Piece  Tetromino // 方块固定时的位置和旋转状态
+	Inputs int       // 玩家的按键次数
+}
+
+// Finesse 检查这次放置的最简操作
+func (p Placement) Finesse() FinesseResult {
+	return CheckFinesse(p.Board, p.Spawn, p.Piece, p.Inputs)
+}
+
+// CheckFinesse 比较玩家的按键次数与从出现位置到达放置的最少按键次数
+func CheckFinesse(board Board, spawn, placed Tetromino, inputs int) FinesseResult {
+	result := FinesseResult{Spawn: spawn, Piece: placed, Inputs: inputs, Minimal: inputs}
+
+	optimal, minimal, ok := findFinesse(board, spawn, placed)
+	if !ok {
+		return result
+	}
+	result.Optimal = optimal
+	result.Minimal = minimal
+	if inputs > result.Minimal {
+		result.Faults = inputs - result.Minimal
+	}
+	return result
+}
+
+// finesseInputs 最简操作搜索中的操作，顺序决定了按键次数相同时优先选择的操作
+var finesseInputs = []types.Input{
+	types.InputLeft,
+	types.InputRight,
+	types.InputShiftLeft,
+	types.InputShiftRight,
+	types.InputRotateRight,
+	types.InputRotateLeft,
+	types.InputSoftDrop,
+}
+
+// finesseItem 最简操作搜索的优先队列中的节点
+type finesseItem struct {
+	node  *pathNode
+	keys  int // 按键次数
+	drops int // 软降次数，按键次数相同时优先选择软降少的序列
+	order int // 入队顺序，其他条件相同时先入队的优先
+}
+
+// finesseQueue 按 (按键次数, 软降次数, 操作数, 入队顺序) 排序的优先队列
+type finesseQueue []finesseItem
+
+func (q finesseQueue) Len() int      { return len(q) }
+func (q finesseQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q finesseQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	if a.keys != b.keys {
+		return a.keys < b.keys
+	}
+	if a.drops != b.drops {
+		return a.drops < b.drops
+	}
+	if a.node.depth != b.node.depth {
+		return a.node.depth < b.node.depth
+	}
+	return a.order < b.order
+}
+func (q *finesseQueue) Push(x interface{}) { *q = append(*q, x.(finesseItem)) }
+func (q *finesseQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// findFinesse 对 (x, y, 旋转状态) 做最短路搜索，返回到达与 target 占据相同格子的放置最少的按键次数和操作序列：
+// 点按左右和旋转各计一次，按住左右键移动到墙边（或被挡住）计一次，软降和快速下降不计。无法到达时返回 false
+func findFinesse(board Board, spawn, target Tetromino) ([]types.Input, int, bool) {
+	if spawn == nil || target == nil || !board.IsValidPosition(spawn) {
+		return nil, 0, false
+	}
+	key := cellsKey(target)
+
+	settled := newPathStates(board, spawn, 1)
+	queue := &finesseQueue{{node: &pathNode{piece: spawn.Clone()}}}
+	order := 1
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(finesseItem)
+		node := item.node
+		if !settled.mark(node.piece, 0) {
+			continue
+		}
+		// 按代价递增的顺序确定状态，第一个能落到目标的状态即为最少按键
+		if cellsKey(DropPiece(board, node.piece)) == key {
+			return append(node.inputs(), types.InputHardDrop), item.keys, true
+		}
+
+		for _, input := range finesseInputs {
+			next, ok := applyFinesseInput(board, node.piece, input)
+			if !ok {
+				continue
+			}
+			child := finesseItem{
+				node:  &pathNode{piece: next, parent: node, input: input, depth: node.depth + 1},
+				keys:  item.keys,
+				drops: item.drops,
+				order: order,
+			}
+			if input == types.InputSoftDrop {
+				child.drops++
+			} else {
+				child.keys++
+			}
+			order++
+			heap.Push(queue, child)
+		}
+	}
+	return nil, 0, false
+}
+
+// applyFinesseInput 按引擎规则对方块执行一次最简操作搜索中的操作，按住左右键时一直移动到无法移动
+func applyFinesseInput(board Board, piece Tetromino, input types.Input) (Tetromino, bool) {
+	dx := 0
+	switch input {
+	case types.InputShiftLeft:
+		dx = -1
+	case types.InputShiftRight:
+		dx = 1
+	default:
+		return applyPathInput(board, piece, input)
+	}
+
+	moved, ok := MovePiece(board, piece, dx, 0)
+	if !ok {
+		return nil, false
+	}
+	for {
+		next, ok := MovePiece(board, moved, dx, 0)
+		if !ok {
+			return moved, true
+		}
+		moved = next
+	}
+}
+
+// InputName 返回操作的显示名称
+func InputName(input types.Input) string {
+	switch input {
+	case types.InputLeft:
+		return "←"
+	case types.InputRight:
+		return "→"
+	case types.InputSoftDrop:
+		return "↓"
+	case types.InputHardDrop:
+		return "⤓"
+	case types.InputRotateRight:
+		return "↻"
+	case types.InputRotateLeft:
+		return "↺"
+	case types.InputHold:
+		return "暂存"
+	case types.InputShiftLeft:
+		return "按住←"
+	case types.InputShiftRight:
+		return "按住→"
+	case types.InputReleaseLeft:
+		return "松开←"
+	case types.InputReleaseRight:
+		return "松开→"
+	case types.InputUndo:
+		return "撤销"
+	case types.InputRedo:
+		return "重做"
+	}
+	return "?"
+}
+
+// FormatInputs 将操作序列格式化为以空格分隔的显示名称
+func FormatInputs(inputs []types.Input) string {
+	names := make([]string, len(inputs))
+	for i, input := range inputs {
+		names[i] = InputName(input)
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,179 @@
+// Package game 实现棋盘和局面与 fumen 文本之间的转换
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"goeluosifangkuai/internal/fumen"
+	"goeluosifangkuai/pkg/types"
+)
+
+// quizPrefix fumen 问题格式的注释前缀，完整格式为 "#Q=[暂存](当前)后续;说明"
+const quizPrefix = "#Q="
+
+// Encode 将棋盘编码为 fumen（v115）文本，棋盘与 fumen 棋盘的底部对齐
+func (b *board) Encode() (string, error) {
+	page, err := fieldPage(b.GetAllCells())
+	if err != nil {
+		return "", err
+	}
+	return fumen.Encode(page)
+}
+
+// Decode 用 fumen（v115）文本第一页的棋盘替换棋盘上的方块，超出棋盘高度的方块返回错误
+func (b *board) Decode(text string) error {
+	if b.width != fumen.Width {
+		return fmt.Errorf("fumen 只支持宽度为 %d 的棋盘", fumen.Width)
+	}
+	page, err := fumen.Decode(text)
+	if err != nil {
+		return err
+	}
+	rows, err := pageRows(page, b.height)
+	if err != nil {
+		return err
+	}
+	b.Clear()
+	offset := b.height - len(rows)
+	for i, row := range rows {
+		for x, color := range row {
+			if color != types.ColorEmpty {
+				b.setCell(x, offset+i, color)
+			}
+		}
+	}
+	return nil
+}
+
+// fieldPage 返回棋盘对应的 fumen 页，rows 为从上到下的行，与 fumen 棋盘的底部对齐
+func fieldPage(rows [][]types.Color) (fumen.Page, error) {
+	page := fumen.NewPage()
+	offset := fumen.Height - len(rows)
+	for i, row := range rows {
+		if len(row) != fumen.Width {
+			return page, fmt.Errorf("fumen 只支持宽度为 %d 的棋盘", fumen.Width)
+		}
+		for x, color := range row {
+			if color == types.ColorEmpty {
+				continue
+			}
+			if offset+i < 0 {
+				return page, fmt.Errorf("fumen 棋盘最多 %d 行", fumen.Height)
+			}
+			page.Field[offset+i][x] = color
+		}
+	}
+	return page, nil
+}
+
+// pageRows 返回 fumen 页中从最高的方块到底部的行，超过 height 行时返回错误
+func pageRows(page fumen.Page, height int) ([][]types.Color, error) {
+	top := len(page.Field)
+	for y := len(page.Field) - 1; y >= 0; y-- {
+		for _, color := range page.Field[y] {
+			if color != types.ColorEmpty {
+				top = y
+			}
+		}
+	}
+	rows := page.Field[top:]
+	if len(rows) > height {
+		return nil, fmt.Errorf("局面有 %d 行，超出了 %d 行的棋盘", len(rows), height)
+	}
+	return rows, nil
+}
+
+// EncodePuzzle 将谜题的局面编码为 fumen 文本：棋盘写入第一页，
+// 方块序列、暂存方块和说明按 fumen 的问题格式写入注释（如 "#Q=[T](I)OSZ;说明"）
+func EncodePuzzle(puzzle *Puzzle) (string, error) {
+	page, err := fieldPage(puzzle.Board)
+	if err != nil {
+		return "", err
+	}
+	if len(puzzle.Queue) > 0 {
+		var comment strings.Builder
+		comment.WriteString(quizPrefix + "[")
+		if len(puzzle.Hold) > 0 {
+			comment.WriteRune(TetrominoLetter(puzzle.Hold[0]))
+		}
+		comment.WriteString("](")
+		comment.WriteRune(TetrominoLetter(puzzle.Queue[0]))
+		comment.WriteString(")")
+		for _, tetrominoType := range puzzle.Queue[1:] {
+			comment.WriteRune(TetrominoLetter(tetrominoType))
+		}
+		if puzzle.Description != "" {
+			comment.WriteString(";" + puzzle.Description)
+		}
+		page.Comment = comment.String()
+	} else {
+		page.Comment = puzzle.Description
+	}
+	return fumen.Encode(page)
+}
+
+// DecodePuzzle 将 fumen 文本解码为自由练习的局面：棋盘来自第一页，方块序列和暂存方块来自问题格式的注释；
+// 注释不是问题格式时使用第一页操作的方块作为当前方块，都没有时方块随机生成
+func DecodePuzzle(text string) (*Puzzle, error) {
+	page, err := fumen.Decode(text)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := pageRows(page, types.BoardHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	puzzle := &Puzzle{
+		Name: "fumen",
+		Goal: PuzzleGoal{Type: PuzzleGoalPractice},
+	}
+	for _, row := range rows {
+		puzzle.Board = append(puzzle.Board, append([]types.Color(nil), row...))
+	}
+
+	if strings.HasPrefix(page.Comment, quizPrefix) {
+		if err := parseQuiz(puzzle, page.Comment[len(quizPrefix):]); err != nil {
+			return nil, err
+		}
+	} else {
+		puzzle.Description = page.Comment
+		if tetrominoType, ok := tetrominoTypeForColor(page.Piece); ok {
+			puzzle.Queue = []types.TetrominoType{tetrominoType}
+		}
+	}
+	return puzzle, nil
+}
+
+// parseQuiz 解析问题格式的注释（去掉前缀后的 "[暂存](当前)后续;说明"）
+func parseQuiz(puzzle *Puzzle, quiz string) error {
+	quiz, puzzle.Description, _ = strings.Cut(quiz, ";")
+	if !strings.HasPrefix(quiz, "[") || !strings.Contains(quiz, "](") || !strings.Contains(quiz, ")") {
+		return fmt.Errorf("无法识别的 fumen 问题格式: %q", quiz)
+	}
+	hold, rest, _ := strings.Cut(quiz[1:], "](")
+	current, next, _ := strings.Cut(rest, ")")
+
+	holdPieces, err := parsePieceList(hold)
+	if err != nil || len(holdPieces) > 1 {
+		return fmt.Errorf("fumen 问题的暂存方块无效: %q", hold)
+	}
+	queue, err := parsePieceList(current + next)
+	if err != nil {
+		return fmt.Errorf("fumen 问题的方块序列无效: %w", err)
+	}
+	puzzle.Hold = holdPieces
+	puzzle.Queue = queue
+	return nil
+}
+
+// tetrominoTypeForColor 返回颜色对应的标准方块类型
+func tetrominoTypeForColor(color types.Color) (types.TetrominoType, bool) {
+	for tetrominoType, c := range tetrominoColors {
+		if c == color {
+			return tetrominoType, true
+		}
+	}
+	return 0, false
+}
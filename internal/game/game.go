@@ -2,6 +2,9 @@
 package game
 
 import (
+	"math/rand"
+	"time"
+
 	"goeluosifangkuai/pkg/types"
 )
 
@@ -11,21 +14,63 @@ type gameImpl struct {
 	board            Board
 	currentTetromino Tetromino
 	nextTetromino    Tetromino
+	holdTetromino    Tetromino
+	holdUsed         bool // 当前方块是否已经使用过暂存
 	factory          *TetrominoFactory
+	mode             Mode
+	random           *rand.Rand
+	source           *countingSource // random 的随机数源，用于保存随机数状态
 
 	// 游戏统计
 	score        int
 	level        int
 	linesCleared int
 
+	// 最近一次固定方块的消行信息
+	lastClear       ClearInfo
+	lastMoveRotated bool // 当前方块最后一次成功的操作是否为旋转，用于判定 T-spin
+	backToBack      bool // 上一次消行是否为四消或 T-spin 消行
+
+	// 最简操作检查：当前方块出现时的状态和玩家的按键次数，以及最近一次固定的方块
+	spawnPiece    Tetromino
+	pieceInputs   int
+	lastPlacement *Placement
+	lastFinesse   *FinesseResult // 按需计算后缓存
+
+	// 游戏事件的处理函数
+	eventHandlers []func(event GameEvent)
+
 	// 游戏时间控制
 	dropTimer    int
 	dropInterval int
 
-	// 游戏配置
-	config GameConfig
+	// 逻辑时钟：frame 为已经过的帧数，frameTimer 累积不足一帧的时间
+	frame      int
+	frameTimer int
+
+	// 重力开关，关闭后方块不会自动下落
+	gravityEnabled bool
+
+	// 时间参数相关的计时器（帧）
+	gravityCounter int // 累积的重力
+	lockTimer      int // 方块着地的时间
+	spawnDelay     int // 剩余的出现延迟，期间没有当前方块
+	shiftDirection int // 按住的左右方向
+	shiftTimer     int // 按住左右键的时间
+
+	// 方块放置历史，用于撤销和重做
+	spawnSnapshot *gameSnapshot // 当前方块出现时的状态
+	undoStack     []*gameSnapshot
+	redoStack     []*gameSnapshot
+
+	// 游戏配置，seedFixed 表示种子由调用者指定，重置时沿用同一种子
+	config    GameConfig
+	seedFixed bool
 }
 
+// EngineVersion 游戏逻辑的版本，改变规则、时间参数或随机数的使用方式时递增，录像据此判断能否重现
+const EngineVersion = 1
+
 // GameConfig 游戏配置
 type GameConfig struct {
 	BoardWidth           int
@@ -36,6 +81,9 @@ type GameConfig struct {
 	ScorePerLine         int
 	ScoreLevelMultiplier int
 	LinesPerLevel        int
+	Seed                 int64     // 随机种子，为 0 时使用当前时间
+	PieceSet             *PieceSet // 方块集，为 nil 时使用标准的七种四连方块
+	Handling             *Handling // 玩家的操作手感，为 nil 时使用模式的设置
 }
 
 // DefaultGameConfig 返回默认游戏配置
@@ -52,23 +100,55 @@ func DefaultGameConfig() GameConfig {
 	}
 }
 
-// NewGame 创建新的游戏实例
+// BigGameConfig 返回大方块变体的配置：逻辑棋盘的宽高减半，每个格子渲染为 2x2，
+// 因此方块、移动和消行都会在画面上加倍，而棋盘逻辑仍然只处理单位大小的格子
+func BigGameConfig(config GameConfig) GameConfig {
+	config.BoardWidth /= types.BigScale
+	config.BoardHeight /= types.BigScale
+	return config
+}
+
+// NewGame 创建新的经典模式游戏实例
 func NewGame(config GameConfig) Game {
-	factory := NewTetrominoFactory()
+	return NewGameWithMode(config, NewClassicMode())
+}
+
+// NewGameWithMode 创建指定模式的游戏实例
+func NewGameWithMode(config GameConfig, mode Mode) Game {
+	seedFixed := config.Seed != 0
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	if config.PieceSet == nil {
+		config.PieceSet = StandardPieceSet()
+	}
+	source := newCountingSource(config.Seed)
+	random := rand.New(source)
+	factory := NewTetrominoFactoryWithRandom(random)
+	factory.SetPieceSet(config.PieceSet)
 	board := NewBoard(config.BoardWidth, config.BoardHeight)
 
 	game := &gameImpl{
 		state:        types.GameStateMenu,
 		board:        board,
 		factory:      factory,
+		mode:         mode,
+		random:       random,
+		source:       source,
 		config:       config,
+		seedFixed:    seedFixed,
 		score:        0,
 		level:        1,
 		linesCleared: 0,
 		dropTimer:    0,
 		dropInterval: config.InitialDropInterval,
+
+		gravityEnabled: true,
 	}
 
+	// 先让模式初始化棋盘等状态，再生成第一个方块
+	game.factory.SetRandomizer(mode.GetRandomizer())
+	game.mode.Start(game)
 	game.generateNextTetromino()
 	game.spawnNewTetromino()
 
@@ -110,33 +190,71 @@ func (g *gameImpl) GetLevel() int {
 	return g.level
 }
 
+// SetLevel 设置当前等级并调整下落速度
+func (g *gameImpl) SetLevel(level int) {
+	if level < 1 {
+		level = 1
+	}
+	g.level = level
+	g.updateDropInterval()
+}
+
 // GetLinesCleared 返回已消除的行数
 func (g *gameImpl) GetLinesCleared() int {
 	return g.linesCleared
 }
 
+// GetMode 返回当前游戏模式
+func (g *gameImpl) GetMode() Mode {
+	return g.mode
+}
+
+// GetFrame 返回逻辑时钟当前的帧数
+func (g *gameImpl) GetFrame() int {
+	return g.frame
+}
+
+// GetElapsedTime 返回逻辑时钟经过的时间（毫秒）
+func (g *gameImpl) GetElapsedTime() int {
+	return g.frame * types.FrameDuration
+}
+
+// SetGravityEnabled 开启或关闭重力
+func (g *gameImpl) SetGravityEnabled(enabled bool) {
+	g.gravityEnabled = enabled
+	g.dropTimer = 0
+}
+
+// GetConfig 返回游戏配置
+func (g *gameImpl) GetConfig() GameConfig {
+	return g.config
+}
+
+// GetPieceSet 返回游戏使用的方块集
+func (g *gameImpl) GetPieceSet() *PieceSet {
+	return g.config.PieceSet
+}
+
+// GetRandom 返回游戏的随机数生成器
+func (g *gameImpl) GetRandom() *rand.Rand {
+	return g.random
+}
+
 // MoveTetromino 移动当前方块
 func (g *gameImpl) MoveTetromino(dx, dy int) bool {
 	if g.state != types.GameStatePlaying || g.currentTetromino == nil {
 		return false
 	}
 
-	// 创建新位置的方块副本
-	newTetromino := g.currentTetromino.Clone()
-	currentPos := newTetromino.GetPosition()
-	newPos := types.Position{
-		X: currentPos.X + dx,
-		Y: currentPos.Y + dy,
-	}
-	newTetromino.SetPosition(newPos)
-
 	// 检查新位置是否有效
-	if g.board.IsValidPosition(newTetromino) {
-		g.currentTetromino = newTetromino
-		return true
+	newTetromino, ok := MovePiece(g.board, g.currentTetromino, dx, dy)
+	if !ok {
+		return false
 	}
 
-	return false
+	g.currentTetromino = newTetromino
+	g.lastMoveRotated = false
+	return true
 }
 
 // RotateTetromino 旋转当前方块
@@ -145,44 +263,71 @@ func (g *gameImpl) RotateTetromino(direction types.Direction) bool {
 		return false
 	}
 
-	// 尝试旋转
-	rotatedTetromino := g.currentTetromino.Rotate(direction)
-
-	// 检查旋转后的位置是否有效
-	if g.board.IsValidPosition(rotatedTetromino) {
-		g.currentTetromino = rotatedTetromino
-		return true
+	rotatedTetromino, ok := RotatePiece(g.board, g.currentTetromino, direction)
+	if !ok {
+		return false
 	}
 
-	// 如果直接旋转失败，尝试踢墙算法（简单版本）
-	return g.tryWallKick(rotatedTetromino)
+	g.currentTetromino = rotatedTetromino
+	g.lastMoveRotated = true
+	return true
 }
 
-// tryWallKick 尝试踢墙算法
-func (g *gameImpl) tryWallKick(rotatedTetromino Tetromino) bool {
-	// 简单的踢墙尝试：左右各一格
-	kickTests := []types.Position{
-		{X: -1, Y: 0}, // 向左踢
-		{X: 1, Y: 0},  // 向右踢
-		{X: 0, Y: -1}, // 向上踢
+// HoldTetromino 暂存当前方块，与已暂存的方块交换，每个方块只能暂存一次
+func (g *gameImpl) HoldTetromino() bool {
+	if g.state != types.GameStatePlaying || g.currentTetromino == nil || g.holdUsed {
+		return false
+	}
+	if g.holdTetromino == nil && g.nextTetromino == nil {
+		return false
 	}
 
-	originalPos := rotatedTetromino.GetPosition()
+	// 暂存的方块恢复为初始的旋转状态
+	held := g.holdTetromino
+	g.holdTetromino = g.factory.CreateSpecificTetromino(g.currentTetromino.GetType())
 
-	for _, kick := range kickTests {
-		testPos := types.Position{
-			X: originalPos.X + kick.X,
-			Y: originalPos.Y + kick.Y,
-		}
-		rotatedTetromino.SetPosition(testPos)
+	if held == nil {
+		g.spawnNewTetromino()
+	} else {
+		held.SetPosition(g.spawnPosition())
+		g.spawnTetromino(held)
+	}
 
-		if g.board.IsValidPosition(rotatedTetromino) {
-			g.currentTetromino = rotatedTetromino
-			return true
-		}
+	g.holdUsed = true
+	return true
+}
+
+// GetHoldTetromino 返回暂存的方块
+func (g *gameImpl) GetHoldTetromino() Tetromino {
+	return g.holdTetromino
+}
+
+// SetHoldTetromino 设置暂存的方块，nil 表示清空暂存
+func (g *gameImpl) SetHoldTetromino(tetromino Tetromino) {
+	g.holdTetromino = tetromino
+	g.holdUsed = false
+}
+
+// SetPieceSequence 之后出现的方块按指定序列依次生成，序列用完后不再生成新方块
+func (g *gameImpl) SetPieceSequence(sequence []types.TetrominoType) {
+	g.factory.SetSequence(sequence)
+}
+
+// AddEventHandler 注册游戏事件的处理函数
+func (g *gameImpl) AddEventHandler(handler func(event GameEvent)) {
+	g.eventHandlers = append(g.eventHandlers, handler)
+}
+
+// emitEvent 依次通知所有的事件处理函数
+func (g *gameImpl) emitEvent(event GameEvent) {
+	for _, handler := range g.eventHandlers {
+		handler(event)
 	}
+}
 
-	return false
+// GetLastClear 返回最近一次固定方块的消行信息
+func (g *gameImpl) GetLastClear() ClearInfo {
+	return g.lastClear
 }
 
 // DropTetromino 快速下落当前方块
@@ -201,27 +346,125 @@ func (g *gameImpl) DropTetromino() {
 	g.lockCurrentTetromino()
 }
 
+// ApplyInput 执行一次操作输入，返回操作是否生效
+func (g *gameImpl) ApplyInput(input types.Input) bool {
+	// 松开按键在暂停时同样生效，其他操作只在游戏进行中生效，录像只需要记录生效的操作
+	if g.state == types.GameStatePlaying || input == types.InputReleaseLeft || input == types.InputReleaseRight {
+		g.emitEvent(GameEvent{Type: GameEventInput, Frame: g.frame, Input: input})
+	}
+
+	g.countInput(input)
+	switch input {
+	case types.InputLeft:
+		return g.MoveTetromino(-1, 0)
+	case types.InputRight:
+		return g.MoveTetromino(1, 0)
+	case types.InputSoftDrop:
+		return g.MoveTetromino(0, 1)
+	case types.InputHardDrop:
+		if g.state != types.GameStatePlaying || g.currentTetromino == nil {
+			return false
+		}
+		g.DropTetromino()
+		return true
+	case types.InputRotateRight:
+		return g.RotateTetromino(types.DirectionRight)
+	case types.InputRotateLeft:
+		return g.RotateTetromino(types.DirectionLeft)
+	case types.InputHold:
+		return g.HoldTetromino()
+	case types.InputShiftLeft:
+		g.StartShift(-1)
+		return true
+	case types.InputShiftRight:
+		g.StartShift(1)
+		return true
+	case types.InputReleaseLeft:
+		g.StopShift(-1)
+		return true
+	case types.InputReleaseRight:
+		g.StopShift(1)
+		return true
+	case types.InputUndo:
+		return g.Undo()
+	case types.InputRedo:
+		return g.Redo()
+	}
+	return false
+}
+
+// countInput 统计当前方块的左右移动和旋转按键，失败的按键同样计入
+func (g *gameImpl) countInput(input types.Input) {
+	if g.state != types.GameStatePlaying || g.currentTetromino == nil {
+		return
+	}
+	switch input {
+	case types.InputLeft, types.InputRight, types.InputRotateRight, types.InputRotateLeft:
+		g.pieceInputs++
+	}
+}
+
+// GetLastFinesse 返回最近一次固定的方块的最简操作检查结果，还没有固定过方块时返回 false
+func (g *gameImpl) GetLastFinesse() (FinesseResult, bool) {
+	if g.lastPlacement == nil {
+		return FinesseResult{}, false
+	}
+	if g.lastFinesse == nil {
+		result := g.lastPlacement.Finesse()
+		g.lastFinesse = &result
+	}
+	return *g.lastFinesse, true
+}
+
+// GetLastPlacement 返回最近一次固定的方块的记录，还没有固定过方块时返回 false
+func (g *gameImpl) GetLastPlacement() (Placement, bool) {
+	if g.lastPlacement == nil {
+		return Placement{}, false
+	}
+	return *g.lastPlacement, true
+}
+
 // Update 更新游戏状态（用于游戏循环）
+// deltaTime 按逻辑帧切分，游戏逻辑只依赖帧数而不依赖调用时机，保证结果可复现
 func (g *gameImpl) Update(deltaTime int) bool {
 	if g.state != types.GameStatePlaying {
 		return false
 	}
 
-	// 更新下落计时器
-	g.dropTimer += deltaTime
+	g.frameTimer += deltaTime
+	for g.frameTimer >= types.FrameDuration && g.state == types.GameStatePlaying {
+		g.frameTimer -= types.FrameDuration
+		g.step()
+	}
 
-	// 检查是否需要自动下落
-	if g.dropTimer >= g.dropInterval {
-		g.dropTimer = 0
+	return true
+}
 
-		// 尝试向下移动
-		if !g.MoveTetromino(0, 1) {
-			// 无法向下移动，固定当前方块
-			g.lockCurrentTetromino()
-		}
+// step 推进一个逻辑帧
+func (g *gameImpl) step() {
+	g.frame++
+	g.board.SetClock(g.frame)
+	timing := g.timing()
+
+	g.updateSpawnDelay()
+	g.updateShift(timing)
+	g.applyGravity(timing)
+
+	if g.state != types.GameStatePlaying {
+		return
 	}
 
-	return true
+	g.mode.Update(g)
+	g.checkModeFinished()
+}
+
+// checkModeFinished 检查模式的结束条件，达成目标时返回 true
+func (g *gameImpl) checkModeFinished() bool {
+	if g.state == types.GameStatePlaying && g.mode.IsFinished(g) {
+		g.state = types.GameStateGameClear
+		return true
+	}
+	return false
 }
 
 // lockCurrentTetromino 固定当前方块到棋盘
@@ -230,24 +473,67 @@ func (g *gameImpl) lockCurrentTetromino() {
 		return
 	}
 
+	// 记录放置前的状态，用于撤销
+	g.recordPlacement()
+
+	// T-spin 需要在放置和消行之前根据周围的格子判定
+	tSpin := DetectTSpin(g.board, g.currentTetromino, g.lastMoveRotated)
+
+	// 记录固定前的棋盘，最简操作只在需要时才计算
+	g.lastPlacement = &Placement{
+		Board:  CloneBoard(g.board),
+		Spawn:  g.spawnPiece,
+		Piece:  g.currentTetromino.Clone(),
+		Inputs: g.pieceInputs,
+	}
+	g.lastFinesse = nil
+
 	// 将方块放置到棋盘上
 	g.board.PlaceTetromino(g.currentTetromino)
 
 	// 清除完整的行
 	clearedLines := g.board.ClearLines()
+	g.lastClear = ClearInfo{Lines: clearedLines, TSpin: tSpin}
 	if clearedLines > 0 {
+		g.updateBackToBack()
+		g.lastClear.PerfectClear = g.board.IsEmpty()
 		g.updateScore(clearedLines)
 		g.updateLevel()
 	}
+	g.emitEvent(GameEvent{Type: GameEventLock, Frame: g.frame, Clear: g.lastClear, Piece: g.currentTetromino})
+	if g.lastClear.PerfectClear {
+		g.emitEvent(GameEvent{Type: GameEventPerfectClear, Frame: g.frame, Clear: g.lastClear})
+	}
+	g.mode.OnLock(g, clearedLines)
+
+	// 模式可能在 OnLock 中判定失败并结束游戏
+	if g.state != types.GameStatePlaying {
+		return
+	}
 
 	// 检查游戏是否结束
-	if g.board.IsGameOver() {
+	if g.board.IsGameOver() && !g.mode.OnTopOut(g) {
 		g.state = types.GameStateGameOver
 		return
 	}
 
-	// 生成新的方块
-	g.spawnNewTetromino()
+	// 达成模式目标后不再生成新方块
+	if g.checkModeFinished() {
+		return
+	}
+
+	// 生成新的方块，设置了出现延迟时等待延迟结束后再生成
+	timing := g.timing()
+	delay := timing.ARE
+	if clearedLines > 0 {
+		delay += timing.LineClearDelay
+	}
+	if delay > 0 {
+		g.currentTetromino = nil
+		g.spawnDelay = delay
+	} else {
+		g.spawnNewTetromino()
+	}
 }
 
 // updateScore 更新分数
@@ -266,40 +552,112 @@ func (g *gameImpl) updateScore(clearedLines int) {
 	}
 
 	g.score += baseScore * multiplier * g.level
+	g.score += perfectClearBonus(g.lastClear) * g.level
 	g.linesCleared += clearedLines
 }
 
+// updateBackToBack 更新 Back-to-Back 状态：四消和 T-spin 消行为困难消行，连续的困难消行构成 Back-to-Back
+func (g *gameImpl) updateBackToBack() {
+	difficult := g.lastClear.Lines == 4 || g.lastClear.TSpin != TSpinNone
+	g.lastClear.BackToBack = difficult && g.backToBack
+	g.backToBack = difficult
+}
+
+// perfectClearBonus 返回全消的额外奖励（未乘等级）
+func perfectClearBonus(clear ClearInfo) int {
+	if !clear.PerfectClear {
+		return 0
+	}
+
+	switch clear.Lines {
+	case 1:
+		return types.PerfectClearSingle
+	case 2:
+		return types.PerfectClearDouble
+	case 3:
+		return types.PerfectClearTriple
+	case 4:
+		if clear.BackToBack {
+			return types.PerfectClearB2BTetris
+		}
+		return types.PerfectClearTetris
+	}
+	return 0
+}
+
 // updateLevel 更新等级和下落速度
 func (g *gameImpl) updateLevel() {
 	newLevel := (g.linesCleared / g.config.LinesPerLevel) + 1
 	if newLevel > g.level {
 		g.level = newLevel
-		// 增加下落速度
-		g.dropInterval = g.config.InitialDropInterval - (g.level-1)*50
-		if g.dropInterval < g.config.MinDropInterval {
-			g.dropInterval = g.config.MinDropInterval
-		}
+		g.updateDropInterval()
 	}
 }
 
-// spawnNewTetromino 生成新的当前方块
+// updateDropInterval 根据当前等级计算下落速度
+func (g *gameImpl) updateDropInterval() {
+	g.dropInterval = g.config.InitialDropInterval - (g.level-1)*50
+	if g.dropInterval < g.config.MinDropInterval {
+		g.dropInterval = g.config.MinDropInterval
+	}
+}
+
+// spawnNewTetromino 将下一个方块作为新的当前方块
 func (g *gameImpl) spawnNewTetromino() {
-	g.currentTetromino = g.nextTetromino
+	current := g.nextTetromino
 	g.generateNextTetromino()
+	g.holdUsed = false
+	g.spawnTetromino(current)
+}
 
-	// 检查新方块是否可以放置
-	if g.currentTetromino != nil && !g.board.IsValidPosition(g.currentTetromino) {
+// spawnTetromino 让指定方块出现在棋盘上，没有方块可用（序列已用完）时游戏结束
+func (g *gameImpl) spawnTetromino(tetromino Tetromino) {
+	g.currentTetromino = tetromino
+	g.gravityCounter = 0
+	g.lockTimer = 0
+	g.spawnDelay = 0
+	g.lastMoveRotated = false
+
+	if g.currentTetromino == nil {
 		g.state = types.GameStateGameOver
+		return
+	}
+
+	// 检查新方块是否可以放置，模式可以接管堆到顶部的情况
+	if !g.board.IsValidPosition(g.currentTetromino) {
+		if !g.mode.OnTopOut(g) || !g.board.IsValidPosition(g.currentTetromino) {
+			g.state = types.GameStateGameOver
+			return
+		}
 	}
+
+	g.spawnPiece = g.currentTetromino.Clone()
+	g.pieceInputs = 0
+	g.recordSpawn()
+	g.mode.OnSpawn(g)
 }
 
-// generateNextTetromino 生成下一个方块
+// generateNextTetromino 生成下一个方块，序列用完时为 nil
 func (g *gameImpl) generateNextTetromino() {
-	g.nextTetromino = g.factory.CreateRandomTetromino()
+	g.nextTetromino = g.factory.CreateNextTetromino()
+	if g.nextTetromino != nil {
+		g.nextTetromino.SetPosition(g.spawnPosition())
+	}
+}
+
+// spawnPosition 返回方块的出现位置，按实际棋盘宽度居中
+func (g *gameImpl) spawnPosition() types.Position {
+	return types.Position{X: g.board.GetWidth() / 2, Y: 0}
 }
 
 // Reset 重置游戏
 func (g *gameImpl) Reset() {
+	// 没有指定种子时每局使用新的种子，重新设置随机数使每局都可以按配置重现
+	if !g.seedFixed {
+		g.config.Seed = time.Now().UnixNano()
+	}
+	g.random.Seed(g.config.Seed)
+
 	g.state = types.GameStateMenu
 	g.board.Clear()
 	g.score = 0
@@ -307,7 +665,23 @@ func (g *gameImpl) Reset() {
 	g.linesCleared = 0
 	g.dropTimer = 0
 	g.dropInterval = g.config.InitialDropInterval
-
+	g.frame = 0
+	g.frameTimer = 0
+	g.board.SetClock(0)
+	g.gravityEnabled = true
+	g.shiftDirection = 0
+	g.shiftTimer = 0
+	g.holdTetromino = nil
+	g.holdUsed = false
+	g.lastClear = ClearInfo{}
+	g.backToBack = false
+	g.lastPlacement = nil
+	g.lastFinesse = nil
+	g.factory.SetSequence(nil)
+	g.factory.SetRandomizer(g.mode.GetRandomizer())
+	g.clearHistory()
+
+	g.mode.Start(g)
 	g.generateNextTetromino()
 	g.spawnNewTetromino()
 }
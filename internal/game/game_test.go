@@ -2,7 +2,11 @@
 package game
 
 import (
+	"encoding/json"
 	"goeluosifangkuai/pkg/types"
+	"math/rand"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -135,3 +139,1070 @@ func TestGameCreation(t *testing.T) {
 		t.Errorf("游戏应该有有效的棋盘")
 	}
 }
+
+func TestUltraModeEndsOnLogicalClock(t *testing.T) {
+	config := DefaultGameConfig()
+	config.InitialDropInterval = 1000000 // 避免方块落地影响测试
+	game := NewGameWithMode(config, NewUltraMode(1000))
+	game.SetState(types.GameStatePlaying)
+
+	game.Update(999)
+	if game.GetState() != types.GameStatePlaying {
+		t.Fatalf("时间未到时游戏不应结束，当前状态为 %v", game.GetState())
+	}
+
+	// 分多次更新，累计时间应与一次更新相同
+	for i := 0; i < 10; i++ {
+		game.Update(1)
+	}
+	if game.GetState() != types.GameStateGameClear {
+		t.Errorf("时间用完后游戏应通关结束，当前状态为 %v", game.GetState())
+	}
+
+	if game.GetElapsedTime() < 1000 {
+		t.Errorf("逻辑时钟应至少经过 1000 毫秒，实际为 %d", game.GetElapsedTime())
+	}
+}
+
+func TestMarathonLevelProgression(t *testing.T) {
+	fixed := NewMarathonMode(DefaultMarathonConfig()).(*marathonMode)
+	if fixed.goalLines() != 150 {
+		t.Errorf("固定目标制通关行数应为 150，实际为 %d", fixed.goalLines())
+	}
+	if level := fixed.levelForLines(25); level != 3 {
+		t.Errorf("固定目标制消除 25 行后应为 3 级，实际为 %d", level)
+	}
+
+	config := DefaultMarathonConfig()
+	config.GoalType = LineGoalVariable
+	variable := NewMarathonMode(config).(*marathonMode)
+	// 累计 5、15、30、50 行分别升到 2、3、4、5 级
+	if level := variable.levelForLines(40); level != 4 {
+		t.Errorf("变动目标制消除 40 行后应为 4 级，实际为 %d", level)
+	}
+	if variable.goalLines() != 600 {
+		t.Errorf("变动目标制通关行数应为 600，实际为 %d", variable.goalLines())
+	}
+}
+
+func TestBoardInsertRows(t *testing.T) {
+	board := NewBoard(4, 5)
+	board.SetCell(0, 4, types.ColorI)
+
+	row := []types.Color{types.ColorGarbage, types.ColorEmpty, types.ColorGarbage, types.ColorGarbage}
+	if !board.InsertRows([][]types.Color{row, row}) {
+		t.Fatalf("棋盘顶部为空时插入行不应溢出")
+	}
+
+	if board.GetCell(0, 2) != types.ColorI {
+		t.Errorf("原有方块应上移两行")
+	}
+	if board.GetCell(0, 4) != types.ColorGarbage || board.GetCell(1, 4) != types.ColorEmpty {
+		t.Errorf("底部应为插入的垃圾行")
+	}
+
+	board.SetCell(3, 0, types.ColorT)
+	if board.InsertRows([][]types.Color{row}) {
+		t.Errorf("顶部有方块时插入行应报告溢出")
+	}
+}
+
+func TestDigModeRefillsGarbage(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 1
+	game := NewGameWithMode(config, NewDigMode(DigConfig{GoalLines: 12, Height: 8, Messiness: 0}))
+	board := game.GetBoard()
+
+	if rows := countGarbageRows(board); rows != 8 {
+		t.Fatalf("开局应铺设 8 行垃圾，实际为 %d", rows)
+	}
+
+	// 混乱度为 0 时所有垃圾行的空洞在同一列
+	hole := -1
+	for x := 0; x < board.GetWidth(); x++ {
+		if board.GetCell(x, board.GetHeight()-1) == types.ColorEmpty {
+			hole = x
+		}
+	}
+	for y := board.GetHeight() - 8; y < board.GetHeight(); y++ {
+		if board.GetCell(hole, y) != types.ColorEmpty {
+			t.Errorf("第 %d 行的空洞应在第 %d 列", y, hole)
+		}
+	}
+
+	// 填满空洞并清除底部三行
+	for y := board.GetHeight() - 3; y < board.GetHeight(); y++ {
+		board.SetCell(hole, y, types.ColorI)
+	}
+	cleared := board.ClearLines()
+	mode := game.GetMode()
+	mode.OnLock(game, cleared)
+
+	if rows := countGarbageRows(board); rows != 8 {
+		t.Errorf("清除后应补充到 8 行垃圾，实际为 %d", rows)
+	}
+	if status := mode.GetStatus(game); status != "垃圾行: 3/12" {
+		t.Errorf("状态显示不正确: %s", status)
+	}
+}
+
+func TestDigModeRefillTopsOut(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 1
+	game := NewGameWithMode(config, NewDigMode(DigConfig{GoalLines: 12, Height: 8, Messiness: 0}))
+	game.SetState(types.GameStatePlaying)
+	board := game.GetBoard()
+
+	// 顶行的方块会被补充的垃圾行挤出棋盘，下面几行为空，棋盘本身不会判定为堆到顶部
+	bottom := board.GetHeight() - 1
+	for x := 0; x < board.GetWidth(); x++ {
+		board.SetCell(x, bottom, types.ColorI)
+	}
+	cleared := board.ClearLines()
+	board.SetCell(0, 0, types.ColorT)
+	game.GetMode().OnLock(game, cleared)
+
+	if game.GetState() != types.GameStateGameOver {
+		t.Errorf("补充的垃圾行把方块挤出顶部时游戏应结束，实际状态为 %v", game.GetState())
+	}
+}
+
+func TestZenModeUndoRedo(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 1
+	game := NewGameWithMode(config, NewZenMode(ZenConfig{Gravity: false, UndoLimit: 2}))
+	game.SetState(types.GameStatePlaying)
+
+	if game.Undo() {
+		t.Fatalf("没有放置历史时不应能撤销")
+	}
+
+	game.DropTetromino()
+	afterFirst := game.GetScore()
+	game.DropTetromino()
+	game.DropTetromino()
+
+	// 只保留最近两步
+	if !game.Undo() || !game.Undo() {
+		t.Fatalf("应能撤销两步")
+	}
+	if game.Undo() {
+		t.Errorf("超过撤销上限后不应继续撤销")
+	}
+	if game.GetScore() != afterFirst {
+		t.Errorf("撤销后分数应恢复为 %d，实际为 %d", afterFirst, game.GetScore())
+	}
+
+	if !game.Redo() {
+		t.Fatalf("撤销后应能重做")
+	}
+	game.Undo()
+
+	// 撤销后重新放置，重做记录应被清空
+	game.DropTetromino()
+	if game.Redo() {
+		t.Errorf("新的放置之后不应能重做")
+	}
+}
+
+func TestZenModeTopOutClearsBoard(t *testing.T) {
+	game := NewGameWithMode(DefaultGameConfig(), NewZenMode(DefaultZenConfig()))
+	game.SetState(types.GameStatePlaying)
+
+	// 堆满除一列外的所有行，使方块无法出现
+	board := game.GetBoard()
+	for y := 0; y < board.GetHeight(); y++ {
+		for x := 1; x < board.GetWidth(); x++ {
+			board.SetCell(x, y, types.ColorGarbage)
+		}
+	}
+	game.DropTetromino()
+
+	if game.GetState() != types.GameStatePlaying {
+		t.Errorf("禅模式堆到顶部后游戏应继续，当前状态为 %v", game.GetState())
+	}
+	if board.GetCell(5, board.GetHeight()-1) != types.ColorEmpty {
+		t.Errorf("禅模式堆到顶部后应清空棋盘")
+	}
+}
+
+func TestMasterModeLevelStopsAtSectionEnd(t *testing.T) {
+	game := NewGameWithMode(DefaultGameConfig(), NewMasterMode())
+	mode := game.GetMode().(*masterMode)
+
+	mode.level = 98
+	mode.OnLock(game, 0)
+	mode.OnLock(game, 0)
+	if mode.level != 99 {
+		t.Errorf("未消行时等级应停在 99，实际为 %d", mode.level)
+	}
+
+	mode.OnLock(game, 2)
+	if mode.level != 102 {
+		t.Errorf("在 99 级消除两行后应为 102 级，实际为 %d", mode.level)
+	}
+	if game.GetLevel() != 2 {
+		t.Errorf("显示的等级应为第 2 段，实际为 %d", game.GetLevel())
+	}
+
+	mode.level = 500
+	if timing := mode.GetTiming(game); timing.Gravity != types.Gravity20G || timing.DAS != 8 {
+		t.Errorf("500 级应为 20G 且 DAS 为 8，实际为 %+v", timing)
+	}
+}
+
+func TestTwentyGravityDropsOnSpawnAndWaitsForLockDelay(t *testing.T) {
+	game := NewGameWithMode(DefaultGameConfig(), NewMasterMode())
+	mode := game.GetMode().(*masterMode)
+	mode.level = 500
+	game.SetState(types.GameStatePlaying)
+
+	game.Update(types.FrameDuration)
+	if impl := game.(*gameImpl); !impl.isGrounded() {
+		t.Fatalf("20G 下方块应在一帧内落到底部")
+	}
+
+	// 锁定延迟为 30 帧，到时后方块固定并进入出现延迟
+	game.Update(29 * types.FrameDuration)
+	if game.GetCurrentTetromino() != nil {
+		t.Errorf("锁定后出现延迟期间不应有当前方块")
+	}
+
+	game.Update(25 * types.FrameDuration)
+	if game.GetCurrentTetromino() == nil {
+		t.Errorf("出现延迟结束后应生成新方块")
+	}
+}
+
+func TestFadingModeCellOpacity(t *testing.T) {
+	game := NewGameWithMode(DefaultGameConfig(), NewFadingMode(NewClassicMode(), 10, 20))
+	game.SetState(types.GameStatePlaying)
+	board := game.GetBoard()
+	board.SetCell(0, 19, types.ColorGarbage)
+
+	if opacity := CellOpacity(game, 0, 19); opacity != 1 {
+		t.Errorf("刚固定的方块应完全可见，实际不透明度为 %v", opacity)
+	}
+
+	game.Update(20 * types.FrameDuration)
+	if opacity := CellOpacity(game, 0, 19); opacity != 0.5 {
+		t.Errorf("渐隐到一半时不透明度应为 0.5，实际为 %v", opacity)
+	}
+
+	game.SetState(types.GameStateGameOver)
+	if opacity := CellOpacity(game, 0, 19); opacity != 1 {
+		t.Errorf("游戏结束后应显示整个棋盘，实际不透明度为 %v", opacity)
+	}
+
+	// 延迟为 0 时方块固定后立即开始渐隐，负数时使用默认值
+	immediate := NewGameWithMode(DefaultGameConfig(), NewFadingMode(NewClassicMode(), 0, 20))
+	immediate.SetState(types.GameStatePlaying)
+	immediate.GetBoard().SetCell(0, 19, types.ColorGarbage)
+	immediate.Update(10 * types.FrameDuration)
+	if opacity := CellOpacity(immediate, 0, 19); opacity != 0.5 {
+		t.Errorf("延迟为 0 时应立即开始渐隐，10 帧后不透明度应为 0.5，实际为 %v", opacity)
+	}
+	if fade := NewFadingMode(NewClassicMode(), -1, -1).GetFade(); fade.Delay != types.FadeDelay || fade.Duration != types.FadeDuration {
+		t.Errorf("负数应使用默认的渐隐参数，实际为 %+v", fade)
+	}
+
+	invisible := NewGameWithMode(DefaultGameConfig(), NewInvisibleMode(NewClassicMode()))
+	invisible.SetState(types.GameStatePlaying)
+	invisible.GetBoard().SetCell(0, 19, types.ColorGarbage)
+	if opacity := CellOpacity(invisible, 0, 19); opacity != 0 {
+		t.Errorf("隐形模式中固定的方块应立即隐形，实际不透明度为 %v", opacity)
+	}
+}
+
+func TestBigGameConfig(t *testing.T) {
+	game := NewGame(BigGameConfig(DefaultGameConfig()))
+	board := game.GetBoard()
+
+	if board.GetWidth() != 5 || board.GetHeight() != 10 {
+		t.Fatalf("大方块变体的逻辑棋盘应为 5x10，实际为 %dx%d", board.GetWidth(), board.GetHeight())
+	}
+
+	// 方块应在逻辑棋盘中居中出现
+	if x := game.GetCurrentTetromino().GetPosition().X; x != 2 {
+		t.Errorf("方块应出现在第 2 列，实际为第 %d 列", x)
+	}
+
+	// 危险区域按比例缩小为顶部 2 行
+	board.SetCell(0, 2, types.ColorGarbage)
+	if board.IsGameOver() {
+		t.Errorf("第 3 行有方块时不应判定为游戏结束")
+	}
+	board.SetCell(0, 1, types.ColorGarbage)
+	if !board.IsGameOver() {
+		t.Errorf("顶部 2 行有方块时应判定为游戏结束")
+	}
+}
+
+func TestHoldTetromino(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 1
+	game := NewGame(config)
+	game.SetState(types.GameStatePlaying)
+
+	first := game.GetCurrentTetromino().GetType()
+	if !game.HoldTetromino() {
+		t.Fatalf("第一次暂存应成功")
+	}
+	if game.GetHoldTetromino().GetType() != first {
+		t.Errorf("暂存的方块应为 %v，实际为 %v", first, game.GetHoldTetromino().GetType())
+	}
+	if game.HoldTetromino() {
+		t.Errorf("同一个方块固定前不应能再次暂存")
+	}
+
+	game.DropTetromino()
+	if !game.HoldTetromino() {
+		t.Fatalf("新方块出现后应能再次暂存")
+	}
+	if game.GetCurrentTetromino().GetType() != first {
+		t.Errorf("交换后当前方块应为之前暂存的 %v", first)
+	}
+}
+
+func TestDetectTSpin(t *testing.T) {
+	board := NewBoard(types.BoardWidth, types.BoardHeight)
+	for x := 0; x < 4; x++ {
+		board.SetCell(x, 17, types.ColorGarbage)
+	}
+	board.SetCell(3, 19, types.ColorGarbage)
+	board.SetCell(5, 19, types.ColorGarbage)
+
+	tetromino := NewTetromino(types.TetrominoT)
+	tetromino.SetPosition(types.Position{X: 4, Y: 18})
+
+	if DetectTSpin(board, tetromino, false) != TSpinNone {
+		t.Errorf("最后一次操作不是旋转时不应判定为 T-spin")
+	}
+	if DetectTSpin(board, tetromino, true) != TSpinFull {
+		t.Errorf("三个角被占据且凸起一侧两角都被占据时应判定为 T-spin")
+	}
+
+	// 凸起一侧只有一个角被占据时为 Mini
+	board.SetCell(5, 19, types.ColorEmpty)
+	board.SetCell(5, 17, types.ColorGarbage)
+	if DetectTSpin(board, tetromino, true) != TSpinMini {
+		t.Errorf("凸起一侧只有一个角被占据时应判定为 T-spin Mini")
+	}
+}
+
+func TestPuzzleTSpinDouble(t *testing.T) {
+	puzzle, err := ParsePuzzle([]byte(`{
+		"name": "tsd",
+		"board": ["XXXX......", "XXX...XXXX", "XXXX.XXXXX"],
+		"queue": "T",
+		"goal": {"type": "tsd"}
+	}`))
+	if err != nil {
+		t.Fatalf("解析谜题失败: %v", err)
+	}
+
+	game := NewGameWithMode(DefaultGameConfig(), NewPuzzleMode(puzzle))
+	game.SetState(types.GameStatePlaying)
+
+	// 竖直的 T 方块沿第 4 列落到底，再旋转进入缺口
+	game.MoveTetromino(0, 1)
+	game.RotateTetromino(types.DirectionLeft)
+	game.MoveTetromino(-1, 0)
+	for game.MoveTetromino(0, 1) {
+	}
+	if !game.RotateTetromino(types.DirectionRight) {
+		t.Fatalf("T 方块应能旋转进入缺口")
+	}
+	game.DropTetromino()
+
+	if clear := game.GetLastClear(); clear.Lines != 2 || clear.TSpin != TSpinFull {
+		t.Errorf("应为 T-spin 双消，实际为 %+v", clear)
+	}
+	if game.GetState() != types.GameStateGameClear {
+		t.Errorf("完成目标后应通关，实际状态为 %v", game.GetState())
+	}
+}
+
+func TestPuzzleFailsWhenPiecesRunOut(t *testing.T) {
+	puzzle, err := ParsePuzzle([]byte(`{
+		"name": "lines",
+		"board": ["XXXXXXXXX.", "XXXXXXXXX."],
+		"queue": "OO",
+		"goal": {"type": "lines", "lines": 2, "pieces": 1}
+	}`))
+	if err != nil {
+		t.Fatalf("解析谜题失败: %v", err)
+	}
+
+	game := NewGameWithMode(DefaultGameConfig(), NewPuzzleMode(puzzle))
+	game.SetState(types.GameStatePlaying)
+	game.DropTetromino()
+
+	if game.GetState() != types.GameStateGameOver {
+		t.Errorf("超过方块数限制仍未完成目标应失败，实际状态为 %v", game.GetState())
+	}
+
+	// 重新开始后恢复谜题的初始局面
+	game.Reset()
+	if game.GetBoard().GetCell(0, types.BoardHeight-1) != types.ColorGarbage {
+		t.Errorf("重新开始后应恢复初始棋盘")
+	}
+	if game.GetCurrentTetromino().GetType() != types.TetrominoO {
+		t.Errorf("重新开始后方块序列应从头开始")
+	}
+}
+
+func TestLoadPuzzleFiles(t *testing.T) {
+	puzzles, err := LoadPuzzles("../../" + types.PuzzleDir)
+	if err != nil {
+		t.Fatalf("加载谜题文件失败: %v", err)
+	}
+	if len(puzzles) == 0 {
+		t.Errorf("应至少加载一个谜题")
+	}
+
+	if _, err := ParsePuzzle([]byte(`{"name": "bad", "queue": "T", "goal": {"type": "unknown"}}`)); err == nil {
+		t.Errorf("未知的目标类型应返回错误")
+	}
+}
+
+func TestPerfectClearScoring(t *testing.T) {
+	puzzle, err := ParsePuzzle([]byte(`{
+		"name": "pc",
+		"board": ["XXXXXXXXX.", "XXXXXXXXX.", "XXXXXXXXX.", "XXXXXXXXX.",
+		          "XXXXXXXXX.", "XXXXXXXXX.", "XXXXXXXXX.", "XXXXXXXXX."],
+		"queue": "II",
+		"goal": {"type": "clear_all"}
+	}`))
+	if err != nil {
+		t.Fatalf("解析谜题失败: %v", err)
+	}
+
+	game := NewGameWithMode(DefaultGameConfig(), NewPuzzleMode(puzzle))
+	events := 0
+	game.AddEventHandler(func(event GameEvent) {
+		if event.Type == GameEventPerfectClear {
+			events++
+		}
+	})
+	game.SetState(types.GameStatePlaying)
+
+	// 竖直的 I 方块放入最右侧的空列，不计快速下落奖励
+	placeVerticalI := func() int {
+		game.MoveTetromino(0, 1)
+		game.RotateTetromino(types.DirectionRight)
+		for game.MoveTetromino(1, 0) {
+		}
+		for game.MoveTetromino(0, 1) {
+		}
+		before := game.GetScore()
+		game.DropTetromino()
+		return game.GetScore() - before
+	}
+
+	placeVerticalI()
+	if clear := game.GetLastClear(); clear.Lines != 4 || clear.PerfectClear || clear.BackToBack {
+		t.Errorf("第一次四消不应为全消或 Back-to-Back，实际为 %+v", clear)
+	}
+
+	gained := placeVerticalI()
+	clear := game.GetLastClear()
+	if !clear.PerfectClear || !clear.BackToBack {
+		t.Errorf("第二次四消应为 Back-to-Back 全消，实际为 %+v", clear)
+	}
+	expected := types.ScorePerLine*4*8 + types.PerfectClearB2BTetris
+	if gained != expected {
+		t.Errorf("Back-to-Back 四消全消应得 %d 分，实际为 %d", expected, gained)
+	}
+	if events != 1 {
+		t.Errorf("应触发 1 次全消事件，实际为 %d 次", events)
+	}
+}
+
+func TestBagRandomizer(t *testing.T) {
+	factory := NewTetrominoFactoryWithRandom(rand.New(rand.NewSource(1)))
+	factory.SetRandomizer(types.RandomizerBag)
+
+	for bag := 0; bag < 3; bag++ {
+		seen := make(map[types.TetrominoType]bool)
+		for i := 0; i < 7; i++ {
+			seen[factory.CreateNextTetromino().GetType()] = true
+		}
+		if len(seen) != 7 {
+			t.Errorf("第 %d 袋应包含全部七种方块，实际只有 %d 种", bag+1, len(seen))
+		}
+	}
+}
+
+func TestPerfectClearModeRestartsWhenStackTooHigh(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 1
+	game := NewGameWithMode(config, NewPerfectClearMode())
+	game.SetState(types.GameStatePlaying)
+
+	for i := 0; i < 20 && !strings.Contains(game.GetMode().GetStatus(game), "尝试: 2"); i++ {
+		game.DropTetromino()
+	}
+
+	if !strings.Contains(game.GetMode().GetStatus(game), "尝试: 2") {
+		t.Fatalf("堆叠超过 %d 行后应重新开始，状态为 %q", types.PerfectClearHeight, game.GetMode().GetStatus(game))
+	}
+	if game.GetState() != types.GameStatePlaying {
+		t.Errorf("重新开始后游戏应继续")
+	}
+	if stackHeight(game.GetBoard()) > types.PerfectClearHeight {
+		t.Errorf("重新开始后棋盘应被清空")
+	}
+}
+
+func TestLoadPieceSets(t *testing.T) {
+	sets, err := LoadPieceSets("../../" + types.PieceSetDir)
+	if err != nil {
+		t.Fatalf("加载方块集文件失败: %v", err)
+	}
+
+	var pentomino *PieceSet
+	for _, set := range sets {
+		if set.Name == "五连块" {
+			pentomino = set
+		}
+	}
+	if pentomino == nil {
+		t.Fatalf("应加载五连块方块集")
+	}
+
+	if len(pentomino.Types()) != 12 {
+		t.Errorf("五连块应有 12 种方块，实际为 %d 种", len(pentomino.Types()))
+	}
+	if pentomino.MaxSize() != 5 {
+		t.Errorf("五连块的最大边长应为 5，实际为 %d", pentomino.MaxSize())
+	}
+
+	// 对称的形状只保留不重复的旋转状态
+	states := map[string]int{"X": 1, "I": 2, "F": 4}
+	for _, tetrominoType := range pentomino.Types() {
+		def, _ := pentomino.Get(tetrominoType)
+		if len(def.Rotations[0]) != 5 {
+			t.Errorf("方块 %s 应由 5 个小块组成", def.Name)
+		}
+		if expected, ok := states[def.Name]; ok && len(def.Rotations) != expected {
+			t.Errorf("方块 %s 应有 %d 个旋转状态，实际为 %d 个", def.Name, expected, len(def.Rotations))
+		}
+		if _, ok := pentomino.CustomColor(def.Color); !ok {
+			t.Errorf("方块 %s 应使用自定义颜色", def.Name)
+		}
+	}
+}
+
+func TestGameWithCustomPieceSet(t *testing.T) {
+	set, err := ParsePieceSet([]byte(`{
+		"name": "test",
+		"pieces": [
+			{"name": "单格", "color": "#ffffff", "shape": ["X"]},
+			{"name": "长条", "color": "I", "shape": ["XXXXX"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("解析方块集失败: %v", err)
+	}
+
+	config := DefaultGameConfig()
+	config.Seed = 1
+	config.PieceSet = set
+	game := NewGameWithMode(config, NewClassicMode())
+	game.SetState(types.GameStatePlaying)
+
+	for i := 0; i < 10 && game.GetState() == types.GameStatePlaying; i++ {
+		current := game.GetCurrentTetromino()
+		if _, ok := set.Get(current.GetType()); !ok {
+			t.Fatalf("生成的方块 %v 不在方块集中", current.GetType())
+		}
+		game.RotateTetromino(types.DirectionRight)
+		game.DropTetromino()
+	}
+
+	if _, err := ParsePieceSet([]byte(`{"name": "bad", "pieces": [{"name": "a", "color": "red", "shape": ["X"]}]}`)); err == nil {
+		t.Errorf("无效的颜色应返回错误")
+	}
+}
+
+func TestFindPathTucksUnderOverhang(t *testing.T) {
+	puzzle, err := ParsePuzzle([]byte(`{
+		"name": "tuck",
+		"board": ["XXX.......", "..........", ".........."],
+		"queue": "O",
+		"goal": {"type": "clear_all"}
+	}`))
+	if err != nil {
+		t.Fatalf("解析谜题失败: %v", err)
+	}
+	game := NewGameWithMode(DefaultGameConfig(), NewPuzzleMode(puzzle))
+	game.SetState(types.GameStatePlaying)
+
+	target := NewTetromino(types.TetrominoO)
+	target.SetPosition(types.Position{X: 0, Y: types.BoardHeight - 2})
+	path, ok := FindPath(game.GetBoard(), game.GetCurrentTetromino(), target)
+	if !ok {
+		t.Fatalf("应能通过软降后平移到达屋檐下方")
+	}
+
+	for _, input := range path.Inputs {
+		game.ApplyInput(input)
+	}
+	for _, cell := range []types.Position{{X: 0, Y: 18}, {X: 1, Y: 18}, {X: 0, Y: 19}, {X: 1, Y: 19}} {
+		if game.GetBoard().GetCell(cell.X, cell.Y) == types.ColorEmpty {
+			t.Errorf("按搜索到的操作序列执行后 (%d, %d) 应被占据", cell.X, cell.Y)
+		}
+	}
+}
+
+func TestFindPathsIdentifiesTSpin(t *testing.T) {
+	puzzle, err := ParsePuzzle([]byte(`{
+		"name": "tsd",
+		"board": ["XXXX......", "XXX...XXXX", "XXXX.XXXXX"],
+		"queue": "T",
+		"goal": {"type": "tsd"}
+	}`))
+	if err != nil {
+		t.Fatalf("解析谜题失败: %v", err)
+	}
+	game := NewGameWithMode(DefaultGameConfig(), NewPuzzleMode(puzzle))
+	game.SetState(types.GameStatePlaying)
+
+	// 凸起向下的 T 方块中心位于缺口 (4, 18)
+	var spin *Path
+	paths := FindPaths(game.GetBoard(), game.GetCurrentTetromino())
+	for i := range paths {
+		position := paths[i].Piece.GetPosition()
+		if paths[i].TSpin == TSpinFull && position == (types.Position{X: 4, Y: 18}) && paths[i].Piece.GetRotation() == 0 {
+			spin = &paths[i]
+		}
+	}
+	if spin == nil {
+		t.Fatalf("应搜索到旋入缺口的 T-spin 放置")
+	}
+
+	for _, input := range spin.Inputs {
+		game.ApplyInput(input)
+	}
+	if clear := game.GetLastClear(); clear.Lines != 2 || clear.TSpin != TSpinFull {
+		t.Errorf("按搜索到的操作序列执行后应为 T-spin 双消，实际为 %+v", clear)
+	}
+}
+
+func TestLockEventReportsPlacedPiece(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 1
+	game := NewGame(config)
+	var locked []Tetromino
+	game.AddEventHandler(func(event GameEvent) {
+		if event.Type == GameEventLock {
+			locked = append(locked, event.Piece)
+		}
+	})
+	game.SetState(types.GameStatePlaying)
+
+	landing := DropPiece(game.GetBoard(), game.GetCurrentTetromino())
+	game.DropTetromino()
+
+	if len(locked) != 1 {
+		t.Fatalf("落地一个方块应触发 1 次落地事件，实际为 %d 次", len(locked))
+	}
+	if !SameCells(locked[0], landing) {
+		t.Errorf("落地事件中的方块应位于快速下降的落点")
+	}
+}
+
+// finesseTarget 返回在空棋盘上从出现状态旋转 rotations 次（顺时针）、移动到 x 后落到底部的方块
+func finesseTarget(board Board, pieceType types.TetrominoType, rotations, x int) Tetromino {
+	piece := NewTetromino(pieceType)
+	for i := 0; i < rotations; i++ {
+		piece = piece.Rotate(types.DirectionRight)
+	}
+	piece.SetPosition(types.Position{X: x, Y: 0})
+	return DropPiece(board, piece)
+}
+
+func TestCheckFinesseMatchesFinesseChart(t *testing.T) {
+	board := NewBoard(types.BoardWidth, types.BoardHeight)
+	spawnAt := types.Position{X: types.BoardWidth / 2, Y: 0}
+
+	// 按标准最简操作表推算的按键次数；本引擎的方块出现在 x=5，比标准位置靠右一列
+	tests := []struct {
+		name      string
+		piece     types.TetrominoType
+		rotations int
+		x         int
+		want      int
+	}{
+		{"O 原地", types.TetrominoO, 0, 5, 0},
+		{"O 靠左墙", types.TetrominoO, 0, 0, 1},
+		{"O 左墙右一列", types.TetrominoO, 0, 1, 2},
+		{"O 左移三列", types.TetrominoO, 0, 2, 3},
+		{"O 左移两列", types.TetrominoO, 0, 3, 2},
+		{"O 右墙左一列", types.TetrominoO, 0, 7, 2},
+		{"O 靠右墙", types.TetrominoO, 0, 8, 1},
+		{"横 I 靠左墙", types.TetrominoI, 0, 1, 1},
+		{"横 I 左墙右一列", types.TetrominoI, 0, 2, 2},
+		{"横 I 靠右墙", types.TetrominoI, 0, 7, 1},
+		{"竖 I 原地", types.TetrominoI, 1, 5, 1},
+		{"竖 I 第 0 列", types.TetrominoI, 1, 0, 2},
+		{"竖 I 第 1 列", types.TetrominoI, 1, 1, 2},
+		{"竖 I 第 2 列", types.TetrominoI, 1, 2, 3},
+		{"竖 I 第 8 列", types.TetrominoI, 1, 8, 3},
+		{"竖 I 第 9 列", types.TetrominoI, 1, 9, 2},
+		{"T 原地", types.TetrominoT, 0, 5, 0},
+		{"T 靠左墙", types.TetrominoT, 0, 1, 1},
+		{"T 左移三列", types.TetrominoT, 0, 2, 2},
+		{"T 靠右墙", types.TetrominoT, 0, 8, 1},
+		{"倒 T 原地", types.TetrominoT, 2, 5, 2},
+		{"倒 T 右移两列", types.TetrominoT, 2, 7, 4},
+		{"倒 T 靠右墙", types.TetrominoT, 2, 8, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spawn := NewTetromino(tt.piece)
+			spawn.SetPosition(spawnAt)
+			target := finesseTarget(board, tt.piece, tt.rotations, tt.x)
+
+			result := CheckFinesse(board, spawn, target, tt.want)
+			if result.Minimal != tt.want || result.Faults != 0 {
+				t.Errorf("最少按键次数应为 %d，实际为 %d（%s）", tt.want, result.Minimal, FormatInputs(result.Optimal))
+			}
+
+			// 最简操作序列应真的把方块放到目标位置
+			piece := spawn
+			for _, input := range result.Optimal[:len(result.Optimal)-1] {
+				next, ok := applyFinesseInput(board, piece, input)
+				if !ok {
+					t.Fatalf("最简操作 %s 中的 %s 无法执行", FormatInputs(result.Optimal), InputName(input))
+				}
+				piece = next
+			}
+			if !SameCells(DropPiece(board, piece), target) {
+				t.Errorf("最简操作 %s 没有到达目标位置", FormatInputs(result.Optimal))
+			}
+		})
+	}
+}
+
+func TestCheckFinesseTreatsWallShiftAsOneInput(t *testing.T) {
+	board := NewBoard(types.BoardWidth, types.BoardHeight)
+	spawn := NewTetromino(types.TetrominoO)
+	spawn.SetPosition(types.Position{X: types.BoardWidth / 2, Y: 0})
+	target := finesseTarget(board, types.TetrominoO, 0, 1)
+
+	result := CheckFinesse(board, spawn, target, 4)
+	want := []types.Input{types.InputShiftLeft, types.InputRight, types.InputHardDrop}
+	if FormatInputs(result.Optimal) != FormatInputs(want) {
+		t.Errorf("最简操作应为按住左移到墙边再右移一格，实际为 %s", FormatInputs(result.Optimal))
+	}
+	if result.Faults != 2 {
+		t.Errorf("点按 4 次应有 2 次多余按键，实际为 %d", result.Faults)
+	}
+}
+
+func TestGameReportsFinesseFaults(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 1
+	game := NewGame(config)
+	game.SetState(types.GameStatePlaying)
+
+	// 来回移动一次后回到原位再落下，多出 2 次按键
+	game.ApplyInput(types.InputLeft)
+	game.ApplyInput(types.InputRight)
+	game.ApplyInput(types.InputHardDrop)
+
+	result, ok := game.GetLastFinesse()
+	if !ok {
+		t.Fatalf("固定方块后应能得到最简操作检查结果")
+	}
+	if result.Inputs != 2 || result.Minimal != 0 || result.Faults != 2 {
+		t.Errorf("应检查出 2 次多余按键，实际为 %+v", result)
+	}
+	if len(result.Optimal) != 1 || result.Optimal[0] != types.InputHardDrop {
+		t.Errorf("原地落下的最简操作应只有快速下降，实际为 %s", FormatInputs(result.Optimal))
+	}
+}
+
+func TestFinesseModeChecksTarget(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 1
+	game := NewGameWithMode(config, NewFinesseMode())
+	game.SetState(types.GameStatePlaying)
+	mode := game.GetMode().(TargetMode)
+
+	// 按最简操作放到目标位置
+	path, ok := FindPath(game.GetBoard(), game.GetCurrentTetromino(), mode.GetTarget())
+	if !ok {
+		t.Fatalf("目标落点应可以到达")
+	}
+	for _, input := range path.Inputs {
+		game.ApplyInput(input)
+	}
+	if !strings.Contains(mode.GetStatus(game), "正确: 1") {
+		t.Errorf("按最简操作放到目标应计为正确，实际为 %q", mode.GetStatus(game))
+	}
+	if !game.GetBoard().IsEmpty() {
+		t.Errorf("每个方块固定后应清空棋盘")
+	}
+
+	// 放到与目标不同的位置
+	target := mode.GetTarget()
+	for _, path := range FindPaths(game.GetBoard(), game.GetCurrentTetromino()) {
+		if !SameCells(path.Piece, target) {
+			for _, input := range path.Inputs {
+				game.ApplyInput(input)
+			}
+			break
+		}
+	}
+	status := mode.GetStatus(game)
+	if !strings.Contains(status, "放错: 1") || !strings.Contains(status, "最简操作") {
+		t.Errorf("放错位置应计数并显示最简操作，实际为 %q", status)
+	}
+}
+
+func TestResetReseedsForReproducibleGames(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 9
+	first := NewGame(config)
+	second := NewGame(config)
+
+	// 玩过一段时间后重置，方块序列应与新游戏相同
+	first.SetState(types.GameStatePlaying)
+	for i := 0; i < 5; i++ {
+		first.DropTetromino()
+	}
+	first.Reset()
+
+	for i := 0; i < 10; i++ {
+		if first.GetCurrentTetromino().GetType() != second.GetCurrentTetromino().GetType() {
+			t.Fatalf("重置后第 %d 个方块与同一种子的新游戏不同", i+1)
+		}
+		first.SetState(types.GameStatePlaying)
+		second.SetState(types.GameStatePlaying)
+		first.DropTetromino()
+		second.DropTetromino()
+	}
+}
+
+func TestLoadStateKeepsConfigWhenModeFails(t *testing.T) {
+	saved, err := NewGameWithMode(BigGameConfig(DefaultGameConfig()), NewDigMode(DefaultDigConfig())).SaveState()
+	if err != nil {
+		t.Fatalf("保存状态失败: %v", err)
+	}
+	saved.Mode = json.RawMessage(`"损坏的模式状态"`)
+
+	g := NewGameWithMode(DefaultGameConfig(), NewDigMode(DefaultDigConfig()))
+	config := g.GetConfig()
+	if err := g.LoadState(saved); err == nil {
+		t.Fatalf("模式状态损坏时应恢复失败")
+	}
+	if g.GetConfig().BoardWidth != config.BoardWidth || g.GetConfig().PieceSet != config.PieceSet {
+		t.Errorf("恢复失败时不应修改游戏的配置，实际为 %+v", g.GetConfig())
+	}
+}
+
+func TestLoadStateContinuesIdentically(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 11
+	original := NewGameWithMode(config, NewDigMode(DefaultDigConfig()))
+	original.SetState(types.GameStatePlaying)
+
+	// 在所有可以到达的放置中随机选择，并不定长地推进时间
+	play := func(g Game, random *rand.Rand, pieces int) {
+		for placed := 0; placed < pieces && g.GetState() == types.GameStatePlaying; {
+			g.Update(random.Intn(200))
+			if g.GetCurrentTetromino() == nil {
+				continue
+			}
+			paths := FindPaths(g.GetBoard(), g.GetCurrentTetromino())
+			for _, input := range paths[random.Intn(len(paths))].Inputs {
+				g.ApplyInput(input)
+			}
+			placed++
+		}
+	}
+	play(original, rand.New(rand.NewSource(1)), 15)
+
+	saved, err := original.SaveState()
+	if err != nil {
+		t.Fatalf("保存状态失败: %v", err)
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatalf("序列化状态失败: %v", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("解析状态失败: %v", err)
+	}
+
+	// 恢复到使用不同种子创建的游戏，之后的进行应与原游戏完全相同
+	config.Seed = 99
+	restored := NewGameWithMode(config, NewDigMode(DefaultDigConfig()))
+	if err := restored.LoadState(&state); err != nil {
+		t.Fatalf("恢复状态失败: %v", err)
+	}
+	play(original, rand.New(rand.NewSource(2)), 15)
+	play(restored, rand.New(rand.NewSource(2)), 15)
+
+	expected, _ := original.SaveState()
+	actual, _ := restored.SaveState()
+	expectedData, _ := json.Marshal(expected)
+	actualData, _ := json.Marshal(actual)
+	if string(expectedData) != string(actualData) {
+		t.Errorf("恢复状态后继续进行的游戏应与原游戏相同")
+	}
+	if restored.GetMode().GetStatus(restored) != original.GetMode().GetStatus(original) {
+		t.Errorf("模式状态应一起恢复，期望 %q，实际 %q", original.GetMode().GetStatus(original), restored.GetMode().GetStatus(restored))
+	}
+}
+
+func TestHandlingOverridesModeAutoShift(t *testing.T) {
+	config := DefaultGameConfig()
+	config.Seed = 4
+	config.Handling = &Handling{DAS: 2, ARR: 0}
+	game := NewGame(config)
+	game.SetState(types.GameStatePlaying)
+
+	// DAS 为 2 帧、ARR 为 0 时，按住 2 帧后方块立即移动到左墙
+	game.StartShift(-1)
+	game.Update(2 * types.FrameDuration)
+	if _, ok := MovePiece(game.GetBoard(), game.GetCurrentTetromino(), -1, 0); ok {
+		t.Errorf("按住超过玩家设置的 DAS 后方块应已移动到左墙")
+	}
+}
+
+func TestBoardFumenRoundTrip(t *testing.T) {
+	board := NewBoard(types.BoardWidth, types.BoardHeight)
+	board.SetCell(0, types.BoardHeight-1, types.ColorI)
+	board.SetCell(9, types.BoardHeight-1, types.ColorGarbage)
+	board.SetCell(4, types.BoardHeight-3, types.ColorT)
+
+	text, err := board.Encode()
+	if err != nil {
+		t.Fatalf("编码棋盘失败: %v", err)
+	}
+	decoded := NewBoard(types.BoardWidth, types.BoardHeight)
+	decoded.SetCell(5, 5, types.ColorO)
+	if err := decoded.Decode(text); err != nil {
+		t.Fatalf("解码棋盘失败: %v", err)
+	}
+	for y := 0; y < types.BoardHeight; y++ {
+		for x := 0; x < types.BoardWidth; x++ {
+			if decoded.GetCell(x, y) != board.GetCell(x, y) {
+				t.Fatalf("(%d, %d) 应为 %v，实际为 %v", x, y, board.GetCell(x, y), decoded.GetCell(x, y))
+			}
+		}
+	}
+
+	if _, err := NewBoard(5, 10).Encode(); err == nil {
+		t.Errorf("宽度不是 10 的棋盘不能编码为 fumen")
+	}
+}
+
+func TestPuzzleFumenQuiz(t *testing.T) {
+	puzzle, err := ParsePuzzle([]byte(`{"name": "tsd", "description": "T-spin 双消",
+		"board": ["XXXX......", "XXX...XXXX", "XXXX.XXXXX"], "queue": "TIO", "hold": "L", "goal": {"type": "tsd"}}`))
+	if err != nil {
+		t.Fatalf("解析谜题失败: %v", err)
+	}
+	text, err := EncodePuzzle(puzzle)
+	if err != nil {
+		t.Fatalf("编码局面失败: %v", err)
+	}
+
+	decoded, err := DecodePuzzle(text)
+	if err != nil {
+		t.Fatalf("解码局面 %s 失败: %v", text, err)
+	}
+	if decoded.Goal.Type != PuzzleGoalPractice || decoded.Description != puzzle.Description {
+		t.Errorf("应解码为自由练习并保留说明: %+v", decoded)
+	}
+	if len(decoded.Board) != 3 || len(decoded.Queue) != 3 || decoded.Queue[0] != types.TetrominoT ||
+		len(decoded.Hold) != 1 || decoded.Hold[0] != types.TetrominoL {
+		t.Fatalf("局面解码错误: %+v", decoded)
+	}
+	for y, row := range puzzle.Board {
+		for x, color := range row {
+			if decoded.Board[y][x] != color {
+				t.Errorf("(%d, %d) 应为 %v，实际为 %v", x, y, color, decoded.Board[y][x])
+			}
+		}
+	}
+}
+
+func TestEditorBoardTools(t *testing.T) {
+	board := NewBoard(types.BoardWidth, types.BoardHeight)
+	bottom := types.BoardHeight - 1
+	board.SetCell(0, bottom, types.ColorJ)
+	board.SetCell(1, bottom, types.ColorGarbage)
+	board.SetCell(4, bottom-1, types.ColorT)
+
+	MirrorBoard(board)
+	if board.GetCell(9, bottom) != types.ColorL || board.GetCell(8, bottom) != types.ColorGarbage ||
+		board.GetCell(5, bottom-1) != types.ColorT || board.GetCell(0, bottom) != types.ColorEmpty {
+		t.Errorf("镜像后方块位置或颜色错误: %v", BoardRows(board))
+	}
+
+	ShiftBoard(board, -1)
+	if board.GetCell(9, bottom-1) != types.ColorL || board.GetCell(5, bottom-2) != types.ColorT || !isRowEmpty(board, bottom) {
+		t.Errorf("上移后方块位置错误: %v", BoardRows(board))
+	}
+	ShiftBoard(board, 2)
+	if board.GetCell(5, bottom) != types.ColorT || !isRowEmpty(board, bottom-1) {
+		t.Errorf("下移后移出底部的行应被丢弃: %v", BoardRows(board))
+	}
+
+	board.SetCell(0, bottom-3, types.ColorO)
+	ClearRow(board, bottom)
+	rows := BoardRows(board)
+	if len(rows) != 4 || rows[0][0] != types.ColorO || !isRowEmpty(board, bottom) {
+		t.Errorf("清空行后应剩下从最高方块到底部的 4 行，实际为 %v", rows)
+	}
+}
+
+func TestSavePuzzleRoundTrip(t *testing.T) {
+	puzzle := &Puzzle{
+		Name:  "自定义",
+		Board: [][]types.Color{{types.ColorEmpty, types.ColorS, types.ColorGarbage, types.ColorCustom}},
+		Queue: []types.TetrominoType{types.TetrominoT, types.TetrominoI},
+		Hold:  []types.TetrominoType{types.TetrominoO},
+		Goal:  PuzzleGoal{Type: PuzzleGoalLines, Lines: 2},
+	}
+	path := filepath.Join(t.TempDir(), "custom.json")
+	if err := SavePuzzle(path, puzzle); err != nil {
+		t.Fatalf("保存谜题失败: %v", err)
+	}
+	loaded, err := LoadPuzzle(path)
+	if err != nil {
+		t.Fatalf("加载保存的谜题失败: %v", err)
+	}
+	if loaded.Name != puzzle.Name || loaded.Goal != puzzle.Goal || len(loaded.Queue) != 2 ||
+		loaded.Queue[1] != types.TetrominoI || len(loaded.Hold) != 1 || loaded.Hold[0] != types.TetrominoO {
+		t.Errorf("保存后加载的谜题不一致: %+v", loaded)
+	}
+	want := []types.Color{types.ColorEmpty, types.ColorS, types.ColorGarbage, types.ColorGarbage}
+	for x, color := range want {
+		if loaded.Board[0][x] != color {
+			t.Errorf("第 %d 格应为 %v，实际为 %v", x, color, loaded.Board[0][x])
+		}
+	}
+
+	puzzle.Queue = nil
+	if err := SavePuzzle(filepath.Join(t.TempDir(), "empty.json"), puzzle); err == nil {
+		t.Errorf("没有方块序列的谜题不能保存")
+	}
+}
+
+// isRowEmpty 检查棋盘的一行是否为空
+func isRowEmpty(board Board, y int) bool {
+	for x := 0; x < board.GetWidth(); x++ {
+		if board.GetCell(x, y) != types.ColorEmpty {
+			return false
+		}
+	}
+	return true
+}
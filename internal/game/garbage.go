@@ -0,0 +1,59 @@
+// Package game 实现垃圾行生成器
+package game
+
+import (
+	"math/rand"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// GarbageGenerator 生成每行只有一个空洞的垃圾行，可供需要垃圾行的模式复用
+type GarbageGenerator struct {
+	random    *rand.Rand
+	width     int
+	messiness float64 // 空洞换列的概率：0 表示空洞始终在同一列，1 表示每行随机
+	hole      int     // 上一行空洞所在的列，-1 表示尚未生成
+}
+
+// NewGarbageGenerator 创建垃圾行生成器
+func NewGarbageGenerator(random *rand.Rand, width int, messiness float64) *GarbageGenerator {
+	return &GarbageGenerator{
+		random:    random,
+		width:     width,
+		messiness: messiness,
+		hole:      -1,
+	}
+}
+
+// Generate 生成指定数量的垃圾行（按从上到下的顺序），可直接传给 Board.InsertRows
+func (g *GarbageGenerator) Generate(count int) [][]types.Color {
+	rows := make([][]types.Color, count)
+
+	// 新行插入在原有垃圾行的下方，因此从上往下生成，保证空洞的变化是连贯的
+	for i := 0; i < count; i++ {
+		g.nextHole()
+
+		row := make([]types.Color, g.width)
+		for x := range row {
+			if x != g.hole {
+				row[x] = types.ColorGarbage
+			}
+		}
+		rows[i] = row
+	}
+
+	return rows
+}
+
+// nextHole 根据混乱度决定下一行空洞所在的列
+func (g *GarbageGenerator) nextHole() {
+	if g.hole < 0 || g.width < 2 {
+		g.hole = g.random.Intn(g.width)
+		return
+	}
+
+	if g.random.Float64() < g.messiness {
+		// 换到另一列，保证相邻两行的空洞不在同一列
+		g.hole = (g.hole + 1 + g.random.Intn(g.width-1)) % g.width
+	}
+}
@@ -0,0 +1,124 @@
+// Package game 实现方块放置历史，用于撤销和重做
+package game
+
+import (
+	"goeluosifangkuai/pkg/types"
+)
+
+// gameSnapshot 记录某个方块刚出现时的游戏状态，创建后不再修改
+type gameSnapshot struct {
+	cells        [][]types.Color
+	current      Tetromino // 出现位置上的当前方块
+	next         Tetromino
+	hold         Tetromino
+	score        int
+	level        int
+	linesCleared int
+	backToBack   bool
+}
+
+// takeSnapshot 记录当前的游戏状态
+func (g *gameImpl) takeSnapshot() *gameSnapshot {
+	cells := make([][]types.Color, g.board.GetHeight())
+	for y := range cells {
+		cells[y] = make([]types.Color, g.board.GetWidth())
+		for x := range cells[y] {
+			cells[y][x] = g.board.GetCell(x, y)
+		}
+	}
+
+	return &gameSnapshot{
+		cells:        cells,
+		current:      cloneTetromino(g.currentTetromino),
+		next:         cloneTetromino(g.nextTetromino),
+		hold:         cloneTetromino(g.holdTetromino),
+		score:        g.score,
+		level:        g.level,
+		linesCleared: g.linesCleared,
+		backToBack:   g.backToBack,
+	}
+}
+
+// restoreSnapshot 恢复到快照记录的状态
+func (g *gameImpl) restoreSnapshot(snapshot *gameSnapshot) {
+	for y, row := range snapshot.cells {
+		for x, color := range row {
+			g.board.SetCell(x, y, color)
+		}
+	}
+
+	g.currentTetromino = cloneTetromino(snapshot.current)
+	g.nextTetromino = cloneTetromino(snapshot.next)
+	g.holdTetromino = cloneTetromino(snapshot.hold)
+	g.holdUsed = false
+	g.score = snapshot.score
+	g.linesCleared = snapshot.linesCleared
+	g.level = snapshot.level
+	g.backToBack = snapshot.backToBack
+	g.updateDropInterval()
+	g.dropTimer = 0
+	g.spawnSnapshot = snapshot
+}
+
+// cloneTetromino 克隆方块，nil 时返回 nil
+func cloneTetromino(tetromino Tetromino) Tetromino {
+	if tetromino == nil {
+		return nil
+	}
+	return tetromino.Clone()
+}
+
+// recordSpawn 在新方块出现时记录状态，模式不允许撤销时不记录
+func (g *gameImpl) recordSpawn() {
+	g.spawnSnapshot = nil
+	if g.mode.GetUndoLimit() > 0 {
+		g.spawnSnapshot = g.takeSnapshot()
+	}
+}
+
+// recordPlacement 在方块固定前将其出现时的状态加入历史，超过上限时丢弃最早的记录
+func (g *gameImpl) recordPlacement() {
+	limit := g.mode.GetUndoLimit()
+	if limit <= 0 || g.spawnSnapshot == nil {
+		return
+	}
+
+	g.undoStack = append(g.undoStack, g.spawnSnapshot)
+	if len(g.undoStack) > limit {
+		g.undoStack = g.undoStack[len(g.undoStack)-limit:]
+	}
+	g.redoStack = nil
+}
+
+// clearHistory 清空撤销和重做记录
+func (g *gameImpl) clearHistory() {
+	g.spawnSnapshot = nil
+	g.undoStack = nil
+	g.redoStack = nil
+}
+
+// Undo 撤销上一次方块放置
+func (g *gameImpl) Undo() bool {
+	if g.state != types.GameStatePlaying || len(g.undoStack) == 0 || g.spawnSnapshot == nil {
+		return false
+	}
+
+	snapshot := g.undoStack[len(g.undoStack)-1]
+	g.undoStack = g.undoStack[:len(g.undoStack)-1]
+	g.redoStack = append(g.redoStack, g.spawnSnapshot)
+	g.restoreSnapshot(snapshot)
+	return true
+}
+
+// Redo 重做上一次被撤销的方块放置
+func (g *gameImpl) Redo() bool {
+	if g.state != types.GameStatePlaying || len(g.redoStack) == 0 || g.spawnSnapshot == nil {
+		return false
+	}
+
+	snapshot := g.redoStack[len(g.redoStack)-1]
+	g.redoStack = g.redoStack[:len(g.redoStack)-1]
+	g.undoStack = append(g.undoStack, g.spawnSnapshot)
+	g.restoreSnapshot(snapshot)
+	return true
+}
@@ -1,7 +1,12 @@
 // Package game 定义了俄罗斯方块游戏的核心接口和数据结构
 package game
 
-import "goeluosifangkuai/pkg/types"
+import (
+	"encoding/json"
+	"math/rand"
+
+	"goeluosifangkuai/pkg/types"
+)
 
 // Tetromino 表示一个俄罗斯方块
 type Tetromino interface {
@@ -20,6 +25,12 @@ type Tetromino interface {
 	// GetBlocks 返回方块的所有组成块的相对位置
 	GetBlocks() []types.Position
 
+	// GetRotation 返回当前旋转状态的序号
+	GetRotation() int
+
+	// GetKicks 返回直接旋转失败时依次尝试的踢墙偏移
+	GetKicks() []types.Position
+
 	// Rotate 旋转方块
 	Rotate(direction types.Direction) Tetromino
 
@@ -41,6 +52,12 @@ type Board interface {
 	// SetCell 设置指定位置的单元格颜色
 	SetCell(x, y int, color types.Color)
 
+	// SetClock 设置当前逻辑帧，之后放置的方块以此记录固定时间
+	SetClock(frame int)
+
+	// GetLockFrame 返回指定单元格被固定时的逻辑帧，空单元格返回 -1
+	GetLockFrame(x, y int) int
+
 	// IsValidPosition 检查方块是否可以放置在指定位置
 	IsValidPosition(tetromino Tetromino) bool
 
@@ -50,11 +67,24 @@ type Board interface {
 	// ClearLines 清除已满的行，返回清除的行数
 	ClearLines() int
 
+	// InsertRows 从底部插入行（按从上到下的顺序），原有方块整体上移
+	// 返回 false 表示有方块被挤出棋盘顶部
+	InsertRows(rows [][]types.Color) bool
+
 	// IsGameOver 检查游戏是否结束
 	IsGameOver() bool
 
+	// IsEmpty 检查棋盘上是否没有任何方块
+	IsEmpty() bool
+
 	// Clear 清空棋盘
 	Clear()
+
+	// Encode 将棋盘编码为 fumen（v115）文本，只支持宽度为 10 的棋盘
+	Encode() (string, error)
+
+	// Decode 用 fumen（v115）文本第一页的棋盘替换棋盘上的方块
+	Decode(text string) error
 }
 
 // Game 表示游戏主控制器
@@ -68,7 +98,7 @@ type Game interface {
 	// GetBoard 返回游戏棋盘
 	GetBoard() Board
 
-	// GetCurrentTetromino 返回当前正在下落的方块
+	// GetCurrentTetromino 返回当前正在下落的方块，出现延迟期间返回 nil
 	GetCurrentTetromino() Tetromino
 
 	// GetNextTetromino 返回下一个方块
@@ -80,9 +110,30 @@ type Game interface {
 	// GetLevel 返回当前等级
 	GetLevel() int
 
+	// SetLevel 设置当前等级并调整下落速度（供模式自行管理等级）
+	SetLevel(level int)
+
 	// GetLinesCleared 返回已消除的行数
 	GetLinesCleared() int
 
+	// GetMode 返回当前游戏模式
+	GetMode() Mode
+
+	// GetFrame 返回逻辑时钟当前的帧数
+	GetFrame() int
+
+	// GetElapsedTime 返回逻辑时钟经过的时间（毫秒）
+	GetElapsedTime() int
+
+	// GetPieceSet 返回游戏使用的方块集
+	GetPieceSet() *PieceSet
+
+	// GetConfig 返回游戏配置，其中的随机种子为实际使用的种子
+	GetConfig() GameConfig
+
+	// GetRandom 返回游戏的随机数生成器，模式应使用它以保证同一种子下结果可复现
+	GetRandom() *rand.Rand
+
 	// MoveTetromino 移动当前方块
 	MoveTetromino(dx, dy int) bool
 
@@ -92,11 +143,139 @@ type Game interface {
 	// DropTetromino 快速下落当前方块
 	DropTetromino()
 
+	// ApplyInput 执行一次操作输入，返回操作是否生效
+	ApplyInput(input types.Input) bool
+
+	// HoldTetromino 暂存当前方块（与已暂存的方块交换），每个方块只能暂存一次
+	HoldTetromino() bool
+
+	// GetHoldTetromino 返回暂存的方块，没有时返回 nil
+	GetHoldTetromino() Tetromino
+
+	// SetHoldTetromino 设置暂存的方块（供模式布置初始局面），nil 表示清空
+	SetHoldTetromino(tetromino Tetromino)
+
+	// SetPieceSequence 之后出现的方块按指定序列依次生成，序列用完后游戏结束（供模式使用）
+	SetPieceSequence(sequence []types.TetrominoType)
+
+	// GetLastClear 返回最近一次固定方块的消行信息
+	GetLastClear() ClearInfo
+
+	// GetLastFinesse 返回最近一次固定的方块的最简操作检查结果，还没有固定过方块时返回 false。
+	// 结果在第一次调用时同步计算，不需要立即得到结果时应使用 GetLastPlacement
+	GetLastFinesse() (FinesseResult, bool)
+
+	// GetLastPlacement 返回最近一次固定的方块的记录，用于在游戏循环之外计算最简操作，还没有固定过方块时返回 false
+	GetLastPlacement() (Placement, bool)
+
+	// AddEventHandler 注册游戏事件的处理函数，事件在游戏逻辑中同步触发
+	AddEventHandler(handler func(event GameEvent))
+
+	// StartShift 按下左右移动键（dx 为 -1 或 1），按住超过 DAS 后自动重复移动
+	StartShift(dx int)
+
+	// StopShift 松开左右移动键
+	StopShift(dx int)
+
 	// Update 更新游戏状态（用于游戏循环）
 	Update(deltaTime int) bool
 
+	// SetGravityEnabled 开启或关闭重力（供模式使用）
+	SetGravityEnabled(enabled bool)
+
+	// Undo 撤销上一次方块放置，模式不允许或没有历史时返回 false
+	Undo() bool
+
+	// Redo 重做上一次被撤销的方块放置
+	Redo() bool
+
 	// Reset 重置游戏
 	Reset()
+
+	// SaveState 保存游戏的完整状态，包括随机数和模式的状态
+	SaveState() (*State, error)
+
+	// LoadState 恢复 SaveState 保存的状态，游戏的模式必须与保存时相同
+	LoadState(state *State) error
+}
+
+// Mode 表示游戏模式，决定模式特有的规则和结束条件
+type Mode interface {
+	// GetName 返回模式名称
+	GetName() string
+
+	// GetStatus 返回用于界面显示的模式状态（如剩余时间）
+	GetStatus(game Game) string
+
+	// Start 在游戏创建或重置时调用，用于初始化模式状态
+	Start(game Game)
+
+	// Update 每个逻辑帧调用一次
+	Update(game Game)
+
+	// OnLock 在方块固定并完成消行后调用
+	OnLock(game Game, clearedLines int)
+
+	// OnSpawn 在新方块出现在棋盘上时调用（包括暂存换出的方块）
+	OnSpawn(game Game)
+
+	// IsFinished 检查是否达成模式目标，达成后游戏进入通关状态
+	IsFinished(game Game) bool
+
+	// OnTopOut 在方块堆到顶部时调用，返回 true 表示模式已处理（如清空棋盘），游戏继续
+	OnTopOut(game Game) bool
+
+	// GetUndoLimit 返回允许撤销的最大步数，0 表示不允许撤销
+	GetUndoLimit() int
+
+	// GetTiming 返回当前的时间参数（重力、出现延迟、锁定延迟等）
+	GetTiming(game Game) Timing
+
+	// GetFade 返回已固定方块的渐隐参数
+	GetFade() FadeConfig
+
+	// GetRandomizer 返回方块的随机生成方式
+	GetRandomizer() types.Randomizer
+
+	// SaveState 保存模式自身的状态，没有状态时返回 nil
+	SaveState() (json.RawMessage, error)
+
+	// LoadState 恢复 SaveState 保存的状态
+	LoadState(data json.RawMessage, game Game) error
+}
+
+// TargetMode 为当前方块给出目标落点的模式，界面会在棋盘上标出目标
+type TargetMode interface {
+	Mode
+
+	// GetTarget 返回当前方块的目标落点，没有目标时返回 nil
+	GetTarget() Tetromino
+}
+
+// ClearInfo 一次固定方块的消行信息
+type ClearInfo struct {
+	Lines        int       // 消除的行数
+	TSpin        TSpinType // T-spin 类型
+	PerfectClear bool      // 消行后棋盘是否被清空
+	BackToBack   bool      // 是否紧接上一次四消或 T-spin 消行（Back-to-Back）
+}
+
+// GameEventType 游戏事件类型
+type GameEventType int
+
+const (
+	GameEventPerfectClear GameEventType = iota // 全消
+	GameEventLock                              // 方块落地固定
+	GameEventInput                             // 玩家通过 ApplyInput 进行了一次操作
+)
+
+// GameEvent 游戏事件
+type GameEvent struct {
+	Type  GameEventType
+	Frame int         // 事件发生时的逻辑帧
+	Clear ClearInfo   // 触发事件的消行信息
+	Piece Tetromino   // 落地固定的方块（位置和旋转状态），仅用于落地事件
+	Input types.Input // 玩家的操作，仅用于操作事件
 }
 
 // GameStats 游戏统计信息
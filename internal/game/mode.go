@@ -0,0 +1,83 @@
+// Package game 实现游戏模式的公共部分
+package game
+
+import (
+	"encoding/json"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// BaseMode 提供 Mode 接口的默认空实现，具体模式可以嵌入它并只覆盖需要的方法
+type BaseMode struct{}
+
+// GetStatus 返回用于界面显示的模式状态
+func (BaseMode) GetStatus(game Game) string {
+	return ""
+}
+
+// Start 在游戏创建或重置时调用
+func (BaseMode) Start(game Game) {}
+
+// Update 每个逻辑帧调用一次
+func (BaseMode) Update(game Game) {}
+
+// OnLock 在方块固定并完成消行后调用
+func (BaseMode) OnLock(game Game, clearedLines int) {}
+
+// OnSpawn 在新方块出现时调用
+func (BaseMode) OnSpawn(game Game) {}
+
+// IsFinished 默认模式永远不会主动结束
+func (BaseMode) IsFinished(game Game) bool {
+	return false
+}
+
+// OnTopOut 默认在方块堆到顶部时结束游戏
+func (BaseMode) OnTopOut(game Game) bool {
+	return false
+}
+
+// GetUndoLimit 默认不允许撤销
+func (BaseMode) GetUndoLimit() int {
+	return 0
+}
+
+// GetTiming 默认使用经典规则的时间参数
+func (BaseMode) GetTiming(game Game) Timing {
+	return DefaultTiming()
+}
+
+// GetFade 默认已固定的方块始终可见
+func (BaseMode) GetFade() FadeConfig {
+	return FadeConfig{}
+}
+
+// GetRandomizer 默认每个方块独立随机
+func (BaseMode) GetRandomizer() types.Randomizer {
+	return types.RandomizerRandom
+}
+
+// SaveState 默认模式没有需要保存的状态
+func (BaseMode) SaveState() (json.RawMessage, error) {
+	return nil, nil
+}
+
+// LoadState 默认模式没有需要恢复的状态
+func (BaseMode) LoadState(data json.RawMessage, game Game) error {
+	return nil
+}
+
+// classicMode 经典模式：无限进行，直到方块堆到顶部
+type classicMode struct {
+	BaseMode
+}
+
+// NewClassicMode 创建经典模式
+func NewClassicMode() Mode {
+	return &classicMode{}
+}
+
+// GetName 返回模式名称
+func (m *classicMode) GetName() string {
+	return "经典"
+}
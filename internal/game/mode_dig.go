@@ -0,0 +1,137 @@
+// Package game 实现挖掘模式（Dig / Cheese Race）
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// DigConfig 挖掘模式配置
+type DigConfig struct {
+	GoalLines int     // 需要清除的垃圾行总数
+	Height    int     // 场上保持的垃圾行高度
+	Messiness float64 // 空洞换列的概率（0-1）
+}
+
+// DefaultDigConfig 返回默认挖掘模式配置
+func DefaultDigConfig() DigConfig {
+	return DigConfig{
+		GoalLines: types.DigGoalLines,
+		Height:    types.DigGarbageHeight,
+		Messiness: 1,
+	}
+}
+
+// digMode 挖掘模式：清除预先铺好的垃圾行，场上垃圾行不足时从底部补充
+type digMode struct {
+	BaseMode
+	config    DigConfig
+	generator *GarbageGenerator
+	inserted  int // 已经插入的垃圾行数
+	cleared   int // 已经清除的垃圾行数
+}
+
+// NewDigMode 创建挖掘模式
+func NewDigMode(config DigConfig) Mode {
+	defaults := DefaultDigConfig()
+	if config.GoalLines <= 0 {
+		config.GoalLines = defaults.GoalLines
+	}
+	if config.Height <= 0 {
+		config.Height = defaults.Height
+	}
+	return &digMode{config: config}
+}
+
+// GetName 返回模式名称
+func (m *digMode) GetName() string {
+	return "挖掘"
+}
+
+// GetStatus 返回垃圾行清除进度
+func (m *digMode) GetStatus(game Game) string {
+	return fmt.Sprintf("垃圾行: %d/%d", m.cleared, m.config.GoalLines)
+}
+
+// Start 铺设初始垃圾行，开局时棋盘为空，不会挤出方块
+func (m *digMode) Start(game Game) {
+	board := game.GetBoard()
+	m.generator = NewGarbageGenerator(game.GetRandom(), board.GetWidth(), m.config.Messiness)
+	m.inserted = 0
+	m.cleared = 0
+	m.refill(game)
+}
+
+// OnLock 统计被清除的垃圾行并补充新的垃圾行，补充的垃圾行把方块挤出棋盘顶部时游戏结束
+func (m *digMode) OnLock(game Game, clearedLines int) {
+	if clearedLines > 0 {
+		// 垃圾行只会因为消行而消失，差值即为本次清除的垃圾行数
+		m.cleared = m.inserted - countGarbageRows(game.GetBoard())
+	}
+	if !m.refill(game) {
+		game.SetState(types.GameStateGameOver)
+	}
+}
+
+// IsFinished 清除全部垃圾行后通关
+func (m *digMode) IsFinished(game Game) bool {
+	return m.cleared >= m.config.GoalLines
+}
+
+// digState 挖掘模式保存的状态
+type digState struct {
+	Inserted int `json:"inserted"`
+	Cleared  int `json:"cleared"`
+	Hole     int `json:"hole"`
+}
+
+// SaveState 保存垃圾行进度和上一行空洞的位置
+func (m *digMode) SaveState() (json.RawMessage, error) {
+	return json.Marshal(digState{Inserted: m.inserted, Cleared: m.cleared, Hole: m.generator.hole})
+}
+
+// LoadState 恢复垃圾行进度，垃圾行生成器使用游戏的随机数
+func (m *digMode) LoadState(data json.RawMessage, game Game) error {
+	var state digState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	m.generator = NewGarbageGenerator(game.GetRandom(), game.GetConfig().BoardWidth, m.config.Messiness)
+	m.generator.hole = state.Hole
+	m.inserted = state.Inserted
+	m.cleared = state.Cleared
+	return nil
+}
+
+// refill 将场上的垃圾行补充到设定高度，但不超过剩余的垃圾行总数。
+// 返回 false 表示有方块被挤出棋盘顶部
+func (m *digMode) refill(game Game) bool {
+	onBoard := m.inserted - m.cleared
+	count := m.config.Height - onBoard
+	if remaining := m.config.GoalLines - m.inserted; count > remaining {
+		count = remaining
+	}
+	if count <= 0 {
+		return true
+	}
+
+	fits := game.GetBoard().InsertRows(m.generator.Generate(count))
+	m.inserted += count
+	return fits
+}
+
+// countGarbageRows 统计棋盘上含有垃圾方块的行数
+func countGarbageRows(board Board) int {
+	count := 0
+	for y := 0; y < board.GetHeight(); y++ {
+		for x := 0; x < board.GetWidth(); x++ {
+			if board.GetCell(x, y) == types.ColorGarbage {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
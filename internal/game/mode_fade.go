@@ -0,0 +1,84 @@
+// Package game 实现隐形和渐隐挑战模式
+package game
+
+import (
+	"goeluosifangkuai/pkg/types"
+)
+
+// FadeConfig 已固定方块的渐隐参数（帧）
+type FadeConfig struct {
+	Enabled  bool // 是否启用渐隐
+	Delay    int  // 方块固定后保持可见的帧数，0 表示立即开始渐隐
+	Duration int  // 从可见到隐形的帧数，0 表示立即隐形
+}
+
+// fadingMode 在任意模式的基础上让已固定的方块隐形或渐隐，游戏结束后显示整个棋盘
+type fadingMode struct {
+	Mode
+	suffix string
+	fade   FadeConfig
+}
+
+// NewInvisibleMode 创建隐形模式：方块固定后立即隐形
+func NewInvisibleMode(base Mode) Mode {
+	return &fadingMode{
+		Mode:   base,
+		suffix: "（隐形）",
+		fade:   FadeConfig{Enabled: true},
+	}
+}
+
+// NewFadingMode 创建渐隐模式：方块固定 delay 帧后用 duration 帧逐渐隐形。
+// 与 FadeConfig 一样 0 表示立即开始渐隐或立即隐形，负数时使用默认值
+func NewFadingMode(base Mode, delay, duration int) Mode {
+	if delay < 0 {
+		delay = types.FadeDelay
+	}
+	if duration < 0 {
+		duration = types.FadeDuration
+	}
+	return &fadingMode{
+		Mode:   base,
+		suffix: "（渐隐）",
+		fade:   FadeConfig{Enabled: true, Delay: delay, Duration: duration},
+	}
+}
+
+// GetName 返回模式名称
+func (m *fadingMode) GetName() string {
+	return m.Mode.GetName() + m.suffix
+}
+
+// GetFade 返回渐隐参数
+func (m *fadingMode) GetFade() FadeConfig {
+	return m.fade
+}
+
+// CellOpacity 计算棋盘单元格的不透明度（0-1），供渲染器使用；游戏结束后整个棋盘重新显示
+func CellOpacity(game Game, x, y int) float64 {
+	fade := game.GetMode().GetFade()
+	state := game.GetState()
+	if !fade.Enabled || state == types.GameStateGameOver || state == types.GameStateGameClear {
+		return 1
+	}
+
+	board := game.GetBoard()
+	lockFrame := board.GetLockFrame(x, y)
+	if board.GetCell(x, y) == types.ColorEmpty || lockFrame < 0 {
+		return 1
+	}
+
+	age := game.GetFrame() - lockFrame
+	if age < fade.Delay {
+		return 1
+	}
+	if fade.Duration <= 0 {
+		return 0
+	}
+
+	opacity := 1 - float64(age-fade.Delay)/float64(fade.Duration)
+	if opacity < 0 {
+		return 0
+	}
+	return opacity
+}
@@ -0,0 +1,153 @@
+// Package game 实现最简操作练习模式
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// finesseMode 最简操作练习：在空棋盘上为每个方块随机给出一个目标落点，
+// 玩家需要用最少的按键把方块放到目标位置。放错位置或按键多余时显示最简操作序列。
+// 每个方块固定后清空棋盘，重力关闭
+type finesseMode struct {
+	BaseMode
+	target  Tetromino // 当前方块的目标落点
+	correct int       // 位置正确且按键最少的方块数
+	faults  int       // 位置正确但有多余按键的方块数
+	misses  int       // 放错位置的方块数
+	streak  int       // 连续正确的方块数
+	message string    // 上一个方块的检查结果
+}
+
+// NewFinesseMode 创建最简操作练习模式
+func NewFinesseMode() Mode {
+	return &finesseMode{}
+}
+
+// GetName 返回模式名称
+func (m *finesseMode) GetName() string {
+	return "操作练习"
+}
+
+// GetStatus 返回练习统计和上一个方块的检查结果
+func (m *finesseMode) GetStatus(game Game) string {
+	status := fmt.Sprintf("正确: %d 多余按键: %d 放错: %d 连续: %d", m.correct, m.faults, m.misses, m.streak)
+	if m.message != "" {
+		status += "\n" + m.message
+	}
+	return status
+}
+
+// Start 关闭重力，清空统计
+func (m *finesseMode) Start(game Game) {
+	m.target = nil
+	m.correct = 0
+	m.faults = 0
+	m.misses = 0
+	m.streak = 0
+	m.message = ""
+	game.SetGravityEnabled(false)
+}
+
+// OnSpawn 从新方块在空棋盘上可以直接落下的放置中随机选择目标
+func (m *finesseMode) OnSpawn(game Game) {
+	m.target = nil
+	board := NewBoard(game.GetBoard().GetWidth(), game.GetBoard().GetHeight())
+
+	var candidates []Path
+	for _, path := range FindPaths(board, game.GetCurrentTetromino()) {
+		if !hasSoftDrop(path.Inputs) {
+			candidates = append(candidates, path)
+		}
+	}
+	if len(candidates) > 0 {
+		m.target = candidates[game.GetRandom().Intn(len(candidates))].Piece
+	}
+}
+
+// OnLock 检查位置和按键次数，然后清空棋盘
+func (m *finesseMode) OnLock(game Game, clearedLines int) {
+	defer game.GetBoard().Clear()
+
+	result, ok := game.GetLastFinesse()
+	if !ok || m.target == nil {
+		return
+	}
+
+	switch {
+	case !SameCells(result.Piece, m.target):
+		m.misses++
+		m.streak = 0
+		board := NewBoard(game.GetBoard().GetWidth(), game.GetBoard().GetHeight())
+		optimal := CheckFinesse(board, result.Spawn, m.target, 0)
+		m.message = "放错位置，最简操作: " + FormatInputs(optimal.Optimal)
+	case result.Faults > 0:
+		m.faults++
+		m.streak = 0
+		m.message = fmt.Sprintf("多按了 %d 次，最简操作: %s", result.Faults, FormatInputs(result.Optimal))
+	default:
+		m.correct++
+		m.streak++
+		m.message = "完美！"
+	}
+}
+
+// OnTopOut 清空棋盘，游戏继续
+func (m *finesseMode) OnTopOut(game Game) bool {
+	game.GetBoard().Clear()
+	return true
+}
+
+// GetTarget 返回当前方块的目标落点
+func (m *finesseMode) GetTarget() Tetromino {
+	return m.target
+}
+
+// finesseState 最简操作练习模式保存的状态
+type finesseState struct {
+	Target  *PieceState `json:"target"`
+	Correct int         `json:"correct"`
+	Faults  int         `json:"faults"`
+	Misses  int         `json:"misses"`
+	Streak  int         `json:"streak"`
+	Message string      `json:"message"`
+}
+
+// SaveState 保存目标落点和练习统计
+func (m *finesseMode) SaveState() (json.RawMessage, error) {
+	return json.Marshal(finesseState{
+		Target:  savePiece(m.target),
+		Correct: m.correct,
+		Faults:  m.faults,
+		Misses:  m.misses,
+		Streak:  m.streak,
+		Message: m.message,
+	})
+}
+
+// LoadState 恢复目标落点和练习统计
+func (m *finesseMode) LoadState(data json.RawMessage, game Game) error {
+	var state finesseState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	m.target = restorePiece(game.GetConfig().PieceSet, state.Target)
+	m.correct = state.Correct
+	m.faults = state.Faults
+	m.misses = state.Misses
+	m.streak = state.Streak
+	m.message = state.Message
+	return nil
+}
+
+// hasSoftDrop 判断操作序列中是否包含软降
+func hasSoftDrop(inputs []types.Input) bool {
+	for _, input := range inputs {
+		if input == types.InputSoftDrop {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+// Package game 实现马拉松模式（Marathon）
+package game
+
+import (
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// LineGoalType 表示升级所需行数的计算方式
+type LineGoalType int
+
+const (
+	LineGoalFixed    LineGoalType = iota // 固定目标制：每级所需行数固定
+	LineGoalVariable                     // 变动目标制：每级所需行数为 5×等级
+)
+
+// MarathonConfig 马拉松模式配置
+type MarathonConfig struct {
+	Endless       bool         // 无尽模式：不设通关目标，等级不封顶
+	GoalType      LineGoalType // 升级行数的计算方式
+	LinesPerLevel int          // 固定目标制下每级所需行数
+	MaxLevel      int          // 最高等级，完成该等级即通关
+}
+
+// DefaultMarathonConfig 返回默认马拉松配置：固定目标制，150 行 / 15 级通关
+func DefaultMarathonConfig() MarathonConfig {
+	return MarathonConfig{
+		Endless:       false,
+		GoalType:      LineGoalFixed,
+		LinesPerLevel: types.MarathonGoalLines / types.MarathonMaxLevel,
+		MaxLevel:      types.MarathonMaxLevel,
+	}
+}
+
+// marathonMode 马拉松模式：按消除行数升级，完成最高等级后通关
+type marathonMode struct {
+	BaseMode
+	config MarathonConfig
+}
+
+// NewMarathonMode 创建马拉松模式
+func NewMarathonMode(config MarathonConfig) Mode {
+	if config.LinesPerLevel <= 0 {
+		config.LinesPerLevel = DefaultMarathonConfig().LinesPerLevel
+	}
+	if config.MaxLevel <= 0 {
+		config.MaxLevel = types.MarathonMaxLevel
+	}
+	return &marathonMode{config: config}
+}
+
+// GetName 返回模式名称
+func (m *marathonMode) GetName() string {
+	if m.config.Endless {
+		return "马拉松（无尽）"
+	}
+	return "马拉松"
+}
+
+// GetStatus 返回升级和通关进度
+func (m *marathonMode) GetStatus(game Game) string {
+	lines := game.GetLinesCleared()
+	if m.config.Endless {
+		return fmt.Sprintf("距下一级: %d 行", m.linesForLevel(game.GetLevel())-lines)
+	}
+	return fmt.Sprintf("目标: %d/%d 行", lines, m.goalLines())
+}
+
+// Start 从第一级开始
+func (m *marathonMode) Start(game Game) {
+	game.SetLevel(1)
+}
+
+// OnLock 根据已消除行数重新计算等级
+func (m *marathonMode) OnLock(game Game, clearedLines int) {
+	level := m.levelForLines(game.GetLinesCleared())
+	if !m.config.Endless && level > m.config.MaxLevel {
+		level = m.config.MaxLevel
+	}
+	if level != game.GetLevel() {
+		game.SetLevel(level)
+	}
+}
+
+// IsFinished 完成最高等级时通关，无尽模式永不通关
+func (m *marathonMode) IsFinished(game Game) bool {
+	return !m.config.Endless && game.GetLinesCleared() >= m.goalLines()
+}
+
+// goalLines 返回通关所需的总行数
+func (m *marathonMode) goalLines() int {
+	return m.linesForLevel(m.config.MaxLevel)
+}
+
+// linesForLevel 返回完成指定等级所需的累计行数
+func (m *marathonMode) linesForLevel(level int) int {
+	if m.config.GoalType == LineGoalVariable {
+		// 5×1 + 5×2 + ... + 5×level
+		return types.VariableGoalFactor * level * (level + 1) / 2
+	}
+	return m.config.LinesPerLevel * level
+}
+
+// levelForLines 返回消除指定行数后所处的等级
+func (m *marathonMode) levelForLines(lines int) int {
+	level := 1
+	for lines >= m.linesForLevel(level) {
+		level++
+	}
+	return level
+}
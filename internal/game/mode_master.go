@@ -0,0 +1,230 @@
+// Package game 实现大师模式（Master / 20G）
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// gravityLevel 重力表中的一项：从 level 开始使用的重力
+type gravityLevel struct {
+	level   int
+	gravity int
+}
+
+// masterGravityTable 大师模式的重力表，500 级起为 20G
+var masterGravityTable = []gravityLevel{
+	{0, 4}, {30, 6}, {35, 8}, {40, 10}, {50, 12}, {60, 16}, {70, 32}, {80, 48},
+	{90, 64}, {100, 80}, {120, 96}, {140, 112}, {160, 128}, {170, 144}, {200, 4},
+	{220, 32}, {230, 64}, {233, 96}, {236, 128}, {239, 160}, {243, 192}, {247, 224},
+	{251, 256}, {300, 512}, {330, 768}, {360, 1024}, {400, 1280}, {420, 1024},
+	{450, 768}, {500, types.Gravity20G},
+}
+
+// delayLevel 延迟表中的一项：从 level 开始使用的各项延迟（帧）
+type delayLevel struct {
+	level          int
+	are            int
+	das            int
+	lockDelay      int
+	lineClearDelay int
+}
+
+// masterDelayTable 大师模式的延迟表
+var masterDelayTable = []delayLevel{
+	{0, 25, 14, 30, 40},
+	{500, 25, 8, 30, 25},
+	{600, 25, 8, 30, 16},
+	{700, 16, 8, 30, 12},
+	{800, 12, 8, 30, 6},
+	{900, 12, 6, 17, 6},
+}
+
+// gradeThreshold 段位及达到该段位所需的分数
+type gradeThreshold struct {
+	name   string
+	points int
+}
+
+// masterGrades 段位表，从低到高排列
+var masterGrades = []gradeThreshold{
+	{"9", 0}, {"8", 400}, {"7", 800}, {"6", 1400}, {"5", 2000}, {"4", 3500},
+	{"3", 5500}, {"2", 8000}, {"1", 12000}, {"S1", 16000}, {"S2", 22000},
+	{"S3", 30000}, {"S4", 40000}, {"S5", 52000}, {"S6", 66000}, {"S7", 82000},
+	{"S8", 100000}, {"S9", 120000},
+}
+
+// gradeMaster 最高段位，需要在规定时间内以足够的分数通过各个检查点
+const gradeMaster = "GM"
+
+// gmCheckpoint 获得 GM 段位的检查点
+type gmCheckpoint struct {
+	level  int
+	points int
+	time   int // 毫秒
+}
+
+// masterGMCheckpoints 获得 GM 段位需要满足的检查点
+var masterGMCheckpoints = []gmCheckpoint{
+	{300, 12000, (4*60 + 15) * 1000},
+	{500, 40000, (7*60 + 30) * 1000},
+	{999, 126000, (13*60 + 30) * 1000},
+}
+
+// masterMode 大师模式：按方块数和消除行数升级，高等级为 20G，按分数评定段位
+type masterMode struct {
+	BaseMode
+	level      int  // 0-999
+	points     int  // 段位分数
+	combo      int  // 连击系数
+	gmEligible bool // 是否仍有资格获得 GM 段位
+}
+
+// NewMasterMode 创建大师模式
+func NewMasterMode() Mode {
+	return &masterMode{}
+}
+
+// GetName 返回模式名称
+func (m *masterMode) GetName() string {
+	return "大师"
+}
+
+// GetStatus 返回等级进度和当前段位
+func (m *masterMode) GetStatus(game Game) string {
+	return fmt.Sprintf("等级: %03d/%d 段位: %s", m.level, m.sectionGoal(), m.GetGrade())
+}
+
+// Start 从 0 级开始
+func (m *masterMode) Start(game Game) {
+	m.level = 0
+	m.points = 0
+	m.combo = 1
+	m.gmEligible = true
+	game.SetLevel(1)
+}
+
+// OnLock 消行时等级增加消除的行数，每出现一个新方块等级加 1（在 xx99 级停止直到消行）
+func (m *masterMode) OnLock(game Game, clearedLines int) {
+	before := m.level
+
+	if clearedLines > 0 {
+		m.addPoints(game, clearedLines)
+		m.level += clearedLines
+	} else {
+		m.combo = 1
+	}
+
+	if m.level%100 != 99 && m.level != types.MasterMaxLevel-1 {
+		m.level++
+	}
+	if m.level > types.MasterMaxLevel {
+		m.level = types.MasterMaxLevel
+	}
+
+	m.checkGM(game, before)
+
+	// 界面上的等级显示当前所在的段落
+	game.SetLevel(m.level/100 + 1)
+}
+
+// IsFinished 达到 999 级时通关
+func (m *masterMode) IsFinished(game Game) bool {
+	return m.level >= types.MasterMaxLevel
+}
+
+// GetTiming 按当前等级查询重力和各项延迟
+func (m *masterMode) GetTiming(game Game) Timing {
+	timing := DefaultTiming()
+
+	for _, entry := range masterGravityTable {
+		if m.level >= entry.level {
+			timing.Gravity = entry.gravity
+		}
+	}
+
+	for _, entry := range masterDelayTable {
+		if m.level >= entry.level {
+			timing.ARE = entry.are
+			timing.DAS = entry.das
+			timing.LockDelay = entry.lockDelay
+			timing.LineClearDelay = entry.lineClearDelay
+		}
+	}
+	timing.ARR = 1
+
+	return timing
+}
+
+// GetGrade 返回当前段位
+func (m *masterMode) GetGrade() string {
+	if m.gmEligible && m.level >= types.MasterMaxLevel {
+		return gradeMaster
+	}
+
+	grade := masterGrades[0].name
+	for _, threshold := range masterGrades {
+		if m.points >= threshold.points {
+			grade = threshold.name
+		}
+	}
+	return grade
+}
+
+// addPoints 按消行前的等级、消除行数和连击计算段位分数，清空棋盘时分数翻四倍
+func (m *masterMode) addPoints(game Game, clearedLines int) {
+	m.combo += 2*clearedLines - 2
+	points := (m.level + clearedLines + 3) / 4 * clearedLines * m.combo
+	if game.GetLastClear().PerfectClear {
+		points *= 4
+	}
+	m.points += points
+}
+
+// checkGM 越过检查点时检查分数和用时是否满足 GM 条件
+func (m *masterMode) checkGM(game Game, before int) {
+	for _, checkpoint := range masterGMCheckpoints {
+		if before < checkpoint.level && m.level >= checkpoint.level {
+			if m.points < checkpoint.points || game.GetElapsedTime() > checkpoint.time {
+				m.gmEligible = false
+			}
+		}
+	}
+}
+
+// masterState 大师模式保存的状态
+type masterState struct {
+	Level      int  `json:"level"`
+	Points     int  `json:"points"`
+	Combo      int  `json:"combo"`
+	GMEligible bool `json:"gm_eligible"`
+}
+
+// SaveState 保存等级和段位分数
+func (m *masterMode) SaveState() (json.RawMessage, error) {
+	return json.Marshal(masterState{Level: m.level, Points: m.points, Combo: m.combo, GMEligible: m.gmEligible})
+}
+
+// LoadState 恢复等级和段位分数
+func (m *masterMode) LoadState(data json.RawMessage, game Game) error {
+	var state masterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	m.level = state.Level
+	m.points = state.Points
+	m.combo = state.Combo
+	m.gmEligible = state.GMEligible
+	return nil
+}
+
+// sectionGoal 返回当前段落的终点等级
+func (m *masterMode) sectionGoal() int {
+	goal := (m.level/100 + 1) * 100
+	if goal > types.MasterMaxLevel {
+		goal = types.MasterMaxLevel
+	}
+	return goal
+}
@@ -0,0 +1,110 @@
+// Package game 实现全消练习模式
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// perfectClearMode 全消练习：从空棋盘开始，使用 7-bag 方块序列练习全消。
+// 方块堆叠超过规定高度时本次尝试失败，清空棋盘重新开始，连续全消次数归零
+type perfectClearMode struct {
+	BaseMode
+	streak   int // 当前连续全消次数
+	best     int // 最高连续全消次数
+	attempts int // 尝试次数（每次从空棋盘开始计一次）
+}
+
+// NewPerfectClearMode 创建全消练习模式
+func NewPerfectClearMode() Mode {
+	return &perfectClearMode{}
+}
+
+// GetName 返回模式名称
+func (m *perfectClearMode) GetName() string {
+	return "全消练习"
+}
+
+// GetStatus 返回连续全消次数和尝试次数
+func (m *perfectClearMode) GetStatus(game Game) string {
+	return fmt.Sprintf("连续全消: %d 最佳: %d 尝试: %d", m.streak, m.best, m.attempts)
+}
+
+// Start 关闭重力，从空棋盘开始第一次尝试
+func (m *perfectClearMode) Start(game Game) {
+	m.streak = 0
+	m.best = 0
+	m.attempts = 1
+	game.SetGravityEnabled(false)
+}
+
+// OnLock 统计全消次数，堆叠超过规定高度时重新开始
+func (m *perfectClearMode) OnLock(game Game, clearedLines int) {
+	if game.GetLastClear().PerfectClear {
+		m.streak++
+		if m.streak > m.best {
+			m.best = m.streak
+		}
+		return
+	}
+
+	if stackHeight(game.GetBoard()) > types.PerfectClearHeight {
+		m.restart(game)
+	}
+}
+
+// OnTopOut 清空棋盘重新开始，游戏继续
+func (m *perfectClearMode) OnTopOut(game Game) bool {
+	m.restart(game)
+	return true
+}
+
+// GetRandomizer 使用 7-bag 方块序列
+func (m *perfectClearMode) GetRandomizer() types.Randomizer {
+	return types.RandomizerBag
+}
+
+// perfectClearState 全消练习模式保存的状态
+type perfectClearState struct {
+	Streak   int `json:"streak"`
+	Best     int `json:"best"`
+	Attempts int `json:"attempts"`
+}
+
+// SaveState 保存全消统计
+func (m *perfectClearMode) SaveState() (json.RawMessage, error) {
+	return json.Marshal(perfectClearState{Streak: m.streak, Best: m.best, Attempts: m.attempts})
+}
+
+// LoadState 恢复全消统计
+func (m *perfectClearMode) LoadState(data json.RawMessage, game Game) error {
+	var state perfectClearState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	m.streak = state.Streak
+	m.best = state.Best
+	m.attempts = state.Attempts
+	return nil
+}
+
+// restart 本次尝试失败，清空棋盘开始新的尝试
+func (m *perfectClearMode) restart(game Game) {
+	game.GetBoard().Clear()
+	m.streak = 0
+	m.attempts++
+}
+
+// stackHeight 返回棋盘上方块堆叠的高度（最高的非空行到底部的行数）
+func stackHeight(board Board) int {
+	for y := 0; y < board.GetHeight(); y++ {
+		for x := 0; x < board.GetWidth(); x++ {
+			if board.GetCell(x, y) != types.ColorEmpty {
+				return board.GetHeight() - y
+			}
+		}
+	}
+	return 0
+}
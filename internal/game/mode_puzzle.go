@@ -0,0 +1,116 @@
+// Package game 实现谜题模式
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// puzzleMode 谜题模式：从预设的局面出发，用固定的方块序列完成目标
+type puzzleMode struct {
+	BaseMode
+	puzzle *Puzzle
+	placed int  // 已放置的方块数
+	solved bool // 是否已完成目标
+}
+
+// NewPuzzleMode 创建谜题模式
+func NewPuzzleMode(puzzle *Puzzle) Mode {
+	return &puzzleMode{puzzle: puzzle}
+}
+
+// GetName 返回模式名称
+func (m *puzzleMode) GetName() string {
+	return "谜题: " + m.puzzle.Name
+}
+
+// GetStatus 返回谜题目标和已放置的方块数
+func (m *puzzleMode) GetStatus(game Game) string {
+	goal := m.puzzle.Goal
+	var text string
+	switch goal.Type {
+	case PuzzleGoalClearAll:
+		text = "目标: 清空棋盘"
+	case PuzzleGoalTSpinDouble:
+		text = "目标: T-spin 双消"
+	case PuzzleGoalLines:
+		text = fmt.Sprintf("目标: 消除 %d 行 (%d/%d)", goal.Lines, game.GetLinesCleared(), goal.Lines)
+	case PuzzleGoalPractice:
+		text = fmt.Sprintf("自由练习 消除: %d 行", game.GetLinesCleared())
+	}
+
+	if goal.Pieces > 0 {
+		return fmt.Sprintf("%s 方块: %d/%d", text, m.placed, goal.Pieces)
+	}
+	return fmt.Sprintf("%s 方块: %d", text, m.placed)
+}
+
+// Start 布置初始局面
+func (m *puzzleMode) Start(game Game) {
+	m.placed = 0
+	m.solved = false
+
+	board := game.GetBoard()
+	board.Clear()
+	offset := board.GetHeight() - len(m.puzzle.Board)
+	for i, row := range m.puzzle.Board {
+		for x, color := range row {
+			board.SetCell(x, offset+i, color)
+		}
+	}
+
+	game.SetPieceSequence(m.puzzle.Queue)
+	game.SetHoldTetromino(nil)
+	if len(m.puzzle.Hold) > 0 {
+		game.SetHoldTetromino(NewTetromino(m.puzzle.Hold[0]))
+	}
+	game.SetGravityEnabled(m.puzzle.Gravity)
+}
+
+// OnLock 检查是否完成目标，超过方块数限制时判定失败
+func (m *puzzleMode) OnLock(game Game, clearedLines int) {
+	m.placed++
+
+	switch m.puzzle.Goal.Type {
+	case PuzzleGoalClearAll:
+		m.solved = game.GetLastClear().PerfectClear
+	case PuzzleGoalTSpinDouble:
+		clear := game.GetLastClear()
+		m.solved = clear.Lines == 2 && clear.TSpin == TSpinFull
+	case PuzzleGoalLines:
+		m.solved = game.GetLinesCleared() >= m.puzzle.Goal.Lines
+	}
+
+	if !m.solved && m.puzzle.Goal.Pieces > 0 && m.placed >= m.puzzle.Goal.Pieces {
+		game.SetState(types.GameStateGameOver)
+	}
+}
+
+// IsFinished 完成目标时通关
+func (m *puzzleMode) IsFinished(game Game) bool {
+	return m.solved
+}
+
+// puzzleState 谜题模式保存的状态
+type puzzleState struct {
+	Placed int  `json:"placed"`
+	Solved bool `json:"solved"`
+}
+
+// SaveState 保存已放置的方块数和是否完成目标
+func (m *puzzleMode) SaveState() (json.RawMessage, error) {
+	return json.Marshal(puzzleState{Placed: m.placed, Solved: m.solved})
+}
+
+// LoadState 恢复已放置的方块数和是否完成目标
+func (m *puzzleMode) LoadState(data json.RawMessage, game Game) error {
+	var state puzzleState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	m.placed = state.Placed
+	m.solved = state.Solved
+	return nil
+}
@@ -0,0 +1,47 @@
+// Package game 实现竞速模式（Sprint）
+package game
+
+import (
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// sprintMode 竞速模式：用尽可能短的时间消除规定的行数
+type sprintMode struct {
+	BaseMode
+	goalLines int // 需要消除的行数
+}
+
+// NewSprintMode 创建竞速模式，goalLines 为需要消除的行数，非正数时使用默认值
+func NewSprintMode(goalLines int) Mode {
+	if goalLines <= 0 {
+		goalLines = types.SprintGoalLines
+	}
+	return &sprintMode{goalLines: goalLines}
+}
+
+// GetName 返回模式名称
+func (m *sprintMode) GetName() string {
+	return "竞速"
+}
+
+// GetStatus 返回剩余行数和已用时间
+func (m *sprintMode) GetStatus(game Game) string {
+	remaining := m.goalLines - game.GetLinesCleared()
+	if remaining < 0 {
+		remaining = 0
+	}
+	elapsed := game.GetElapsedTime()
+	return fmt.Sprintf("剩余行数: %d 用时: %d:%02d.%02d", remaining, elapsed/60000, elapsed/1000%60, elapsed/10%100)
+}
+
+// IsFinished 消除规定的行数后通关
+func (m *sprintMode) IsFinished(game Game) bool {
+	return game.GetLinesCleared() >= m.goalLines
+}
+
+// GetRandomizer 使用 7-bag 方块序列
+func (m *sprintMode) GetRandomizer() types.Randomizer {
+	return types.RandomizerBag
+}
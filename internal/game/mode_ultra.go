@@ -0,0 +1,48 @@
+// Package game 实现限时模式（Ultra）
+package game
+
+import (
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// ultraMode 限时模式：在限定时间内尽可能获得高分
+type ultraMode struct {
+	BaseMode
+	timeLimit int // 时间限制（毫秒）
+}
+
+// NewUltraMode 创建限时模式，timeLimit 为时间限制（毫秒），非正数时使用默认值
+func NewUltraMode(timeLimit int) Mode {
+	if timeLimit <= 0 {
+		timeLimit = types.UltraTimeLimit
+	}
+	return &ultraMode{timeLimit: timeLimit}
+}
+
+// GetName 返回模式名称
+func (m *ultraMode) GetName() string {
+	return "限时"
+}
+
+// GetStatus 返回剩余时间
+func (m *ultraMode) GetStatus(game Game) string {
+	remaining := m.GetRemainingTime(game)
+	seconds := (remaining + 999) / 1000 // 向上取整，避免提前显示 0:00
+	return fmt.Sprintf("剩余时间: %d:%02d", seconds/60, seconds%60)
+}
+
+// IsFinished 时间用完时结束游戏
+func (m *ultraMode) IsFinished(game Game) bool {
+	return game.GetElapsedTime() >= m.timeLimit
+}
+
+// GetRemainingTime 返回剩余时间（毫秒）
+func (m *ultraMode) GetRemainingTime(game Game) int {
+	remaining := m.timeLimit - game.GetElapsedTime()
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
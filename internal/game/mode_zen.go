@@ -0,0 +1,89 @@
+// Package game 实现禅模式（Zen / 练习）
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// ZenConfig 禅模式配置
+type ZenConfig struct {
+	Gravity   bool // 是否开启重力
+	UndoLimit int  // 可撤销的最大步数
+}
+
+// DefaultZenConfig 返回默认禅模式配置
+func DefaultZenConfig() ZenConfig {
+	return ZenConfig{
+		Gravity:   true,
+		UndoLimit: types.ZenUndoLimit,
+	}
+}
+
+// zenMode 禅模式：堆到顶部时清空棋盘而不结束游戏，可以关闭重力并撤销放置
+type zenMode struct {
+	BaseMode
+	config  ZenConfig
+	topOuts int // 堆到顶部的次数
+}
+
+// NewZenMode 创建禅模式
+func NewZenMode(config ZenConfig) Mode {
+	if config.UndoLimit < 0 {
+		config.UndoLimit = 0
+	}
+	return &zenMode{config: config}
+}
+
+// GetName 返回模式名称
+func (m *zenMode) GetName() string {
+	if !m.config.Gravity {
+		return "禅（无重力）"
+	}
+	return "禅"
+}
+
+// GetStatus 返回堆到顶部的次数
+func (m *zenMode) GetStatus(game Game) string {
+	return fmt.Sprintf("清空次数: %d", m.topOuts)
+}
+
+// Start 按配置设置重力
+func (m *zenMode) Start(game Game) {
+	m.topOuts = 0
+	game.SetGravityEnabled(m.config.Gravity)
+}
+
+// OnTopOut 清空棋盘，游戏继续
+func (m *zenMode) OnTopOut(game Game) bool {
+	m.topOuts++
+	game.GetBoard().Clear()
+	return true
+}
+
+// zenState 禅模式保存的状态
+type zenState struct {
+	TopOuts int `json:"top_outs"`
+}
+
+// SaveState 保存堆到顶部的次数
+func (m *zenMode) SaveState() (json.RawMessage, error) {
+	return json.Marshal(zenState{TopOuts: m.topOuts})
+}
+
+// LoadState 恢复堆到顶部的次数
+func (m *zenMode) LoadState(data json.RawMessage, game Game) error {
+	var state zenState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	m.topOuts = state.TopOuts
+	return nil
+}
+
+// GetUndoLimit 返回允许撤销的最大步数
+func (m *zenMode) GetUndoLimit() int {
+	return m.config.UndoLimit
+}
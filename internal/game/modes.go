@@ -0,0 +1,61 @@
+// Package game 提供按标识创建游戏模式的注册表
+package game
+
+import (
+	"goeluosifangkuai/pkg/types"
+)
+
+// ModeInfo 描述一个可以按标识创建的游戏模式
+type ModeInfo struct {
+	ID         string      // 稳定的英文标识，用于命令行参数和录像文件
+	Name       string      // 显示名称
+	Create     func() Mode // 创建模式的新实例
+	RankByTime bool        // 成绩按通关用时而不是分数排名
+	NeverEnds  bool        // 堆到顶部时游戏继续，也没有通关目标，游戏不会自行结束
+}
+
+// Modes 返回所有可以按标识创建的游戏模式（谜题模式需要谜题文件，不在其中）
+func Modes() []ModeInfo {
+	return []ModeInfo{
+		{ID: "classic", Name: "经典", Create: NewClassicMode},
+		{ID: "marathon", Name: "马拉松", Create: func() Mode { return NewMarathonMode(DefaultMarathonConfig()) }},
+		{ID: "marathon-variable", Name: "马拉松（变动目标）", Create: func() Mode {
+			config := DefaultMarathonConfig()
+			config.GoalType = LineGoalVariable
+			return NewMarathonMode(config)
+		}},
+		{ID: "marathon-endless", Name: "马拉松（无尽）", Create: func() Mode {
+			config := DefaultMarathonConfig()
+			config.Endless = true
+			return NewMarathonMode(config)
+		}},
+		{ID: "ultra", Name: "限时", Create: func() Mode { return NewUltraMode(0) }},
+		{ID: "sprint", Name: "竞速", Create: func() Mode { return NewSprintMode(0) }, RankByTime: true},
+		{ID: "master", Name: "大师", Create: NewMasterMode},
+		{ID: "invisible", Name: "隐形", Create: func() Mode {
+			return NewInvisibleMode(NewMarathonMode(DefaultMarathonConfig()))
+		}},
+		{ID: "fading", Name: "渐隐", Create: func() Mode {
+			return NewFadingMode(NewMarathonMode(DefaultMarathonConfig()), types.FadeDelay, types.FadeDuration)
+		}},
+		{ID: "dig", Name: "挖掘", Create: func() Mode { return NewDigMode(DefaultDigConfig()) }},
+		{ID: "perfect-clear", Name: "全消练习", Create: NewPerfectClearMode, NeverEnds: true},
+		{ID: "finesse", Name: "操作练习", Create: NewFinesseMode, NeverEnds: true},
+		{ID: "zen", Name: "禅", Create: func() Mode { return NewZenMode(DefaultZenConfig()) }, NeverEnds: true},
+		{ID: "zen-no-gravity", Name: "禅（无重力）", Create: func() Mode {
+			config := DefaultZenConfig()
+			config.Gravity = false
+			return NewZenMode(config)
+		}, NeverEnds: true},
+	}
+}
+
+// FindMode 按标识查找游戏模式
+func FindMode(id string) (ModeInfo, bool) {
+	for _, info := range Modes() {
+		if info.ID == id {
+			return info, true
+		}
+	}
+	return ModeInfo{}, false
+}
@@ -0,0 +1,52 @@
+// Package game 实现方块的移动和旋转规则，游戏引擎和路径搜索共用
+package game
+
+import (
+	"goeluosifangkuai/pkg/types"
+)
+
+// MovePiece 返回平移后的方块副本，目标位置无效时返回 false
+func MovePiece(board Board, piece Tetromino, dx, dy int) (Tetromino, bool) {
+	moved := piece.Clone()
+	position := moved.GetPosition()
+	moved.SetPosition(types.Position{X: position.X + dx, Y: position.Y + dy})
+	if !board.IsValidPosition(moved) {
+		return nil, false
+	}
+	return moved, true
+}
+
+// RotatePiece 返回旋转后的方块副本：先尝试原地旋转，失败时按方块的踢墙表依次尝试偏移，
+// 全部失败时返回 false
+func RotatePiece(board Board, piece Tetromino, direction types.Direction) (Tetromino, bool) {
+	rotated := piece.Rotate(direction)
+	if board.IsValidPosition(rotated) {
+		return rotated, true
+	}
+
+	originalPos := rotated.GetPosition()
+	for _, kick := range rotated.GetKicks() {
+		rotated.SetPosition(types.Position{
+			X: originalPos.X + kick.X,
+			Y: originalPos.Y + kick.Y,
+		})
+		if board.IsValidPosition(rotated) {
+			return rotated, true
+		}
+	}
+
+	return nil, false
+}
+
+// DropPiece 返回方块直接落到底部后的副本
+func DropPiece(board Board, piece Tetromino) Tetromino {
+	dropped := piece.Clone()
+	for {
+		position := dropped.GetPosition()
+		dropped.SetPosition(types.Position{X: position.X, Y: position.Y + 1})
+		if !board.IsValidPosition(dropped) {
+			dropped.SetPosition(position)
+			return dropped
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package game 实现方块的路径搜索
+package game
+
+import (
+	"sort"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// Path 到达一个最终放置的最短操作序列
+type Path struct {
+	Piece  Tetromino     // 落地时的方块（位置和旋转状态）
+	Inputs []types.Input // 从出现位置出发的最短操作序列，以快速下降结束
+	TSpin  TSpinType     // 按该序列放置时的 T-spin 类型
+}
+
+// pathMargin 方块中心可能超出棋盘边界的最大格数，用于确定状态表的大小
+const pathMargin = 8
+
+// pathStates 以 (x, y, 旋转状态, 附加标志) 为下标的状态表，比 map 更快
+type pathStates struct {
+	seen                     []bool
+	width, height, rotations int
+	flags                    int
+}
+
+// newPathStates 为棋盘和方块创建状态表，flags 为每个位置附加标志的取值个数
+func newPathStates(board Board, piece Tetromino, flags int) *pathStates {
+	s := &pathStates{
+		width:     board.GetWidth() + 2*pathMargin,
+		height:    board.GetHeight() + 2*pathMargin,
+		rotations: rotationCount(piece),
+		flags:     flags,
+	}
+	s.seen = make([]bool, s.width*s.height*s.rotations*s.flags)
+	return s
+}
+
+// mark 标记状态，返回该状态之前是否未被标记；超出状态表范围的状态视为已标记
+func (s *pathStates) mark(piece Tetromino, flag int) bool {
+	position := piece.GetPosition()
+	x, y := position.X+pathMargin, position.Y+pathMargin
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return false
+	}
+	index := ((piece.GetRotation()*s.height+y)*s.width+x)*s.flags + flag
+	if s.seen[index] {
+		return false
+	}
+	s.seen[index] = true
+	return true
+}
+
+// pathNode 搜索树中的节点
+type pathNode struct {
+	piece  Tetromino
+	parent *pathNode
+	input  types.Input
+	depth  int
+}
+
+// pathInputs 搜索时尝试的操作，顺序决定了步数相同时优先选择的操作
+var pathInputs = []types.Input{
+	types.InputLeft,
+	types.InputRight,
+	types.InputRotateRight,
+	types.InputRotateLeft,
+	types.InputSoftDrop,
+}
+
+// FindPaths 从方块当前的位置出发，按引擎的移动、旋转和踢墙规则对 (x, y, 旋转状态) 做广度优先搜索，
+// 返回所有可以到达的最终放置及到达它们的最短操作序列。占据相同格子且 T-spin 类型相同的放置只保留一个
+func FindPaths(board Board, piece Tetromino) []Path {
+	if piece == nil || !board.IsValidPosition(piece) {
+		return nil
+	}
+
+	// 搜索状态需要区分最后一次操作是否为旋转，因为它影响 T-spin 判定
+	root := &pathNode{piece: piece.Clone()}
+	visited := newPathStates(board, piece, 2)
+	visited.mark(root.piece, 0)
+	queue := []*pathNode{root}
+
+	// 广度优先搜索按步数递增的顺序访问状态，每个放置第一次出现时的操作序列即为最短
+	found := newPathStates(board, piece, 3)
+	var paths []Path
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		// 从当前状态快速下降得到的放置，没有下落且最后一次操作为旋转时才可能是 T-spin
+		landing := DropPiece(board, node.piece)
+		position := landing.GetPosition()
+		lastRotated := node.depth > 0 && isRotation(node.input) && position == node.piece.GetPosition()
+		tSpin := DetectTSpin(board, landing, lastRotated)
+		if found.mark(landing, int(tSpin)) {
+			paths = append(paths, Path{
+				Piece:  landing,
+				Inputs: append(node.inputs(), types.InputHardDrop),
+				TSpin:  tSpin,
+			})
+		}
+
+		for _, input := range pathInputs {
+			next, ok := applyPathInput(board, node.piece, input)
+			if !ok {
+				continue
+			}
+			flag := 0
+			if isRotation(input) {
+				flag = 1
+			}
+			if !visited.mark(next, flag) {
+				continue
+			}
+			queue = append(queue, &pathNode{piece: next, parent: node, input: input, depth: node.depth + 1})
+		}
+	}
+
+	return uniquePaths(paths)
+}
+
+// uniquePaths 合并占据相同格子且 T-spin 类型相同的放置（如 I、S、Z 方块对称的旋转状态），保留较短的操作序列
+func uniquePaths(paths []Path) []Path {
+	index := make(map[string]int, len(paths))
+	result := paths[:0]
+	for _, path := range paths {
+		key := cellsKey(path.Piece) + string(rune('0'+path.TSpin))
+		if i, ok := index[key]; ok {
+			if len(path.Inputs) < len(result[i].Inputs) {
+				result[i] = path
+			}
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, path)
+	}
+	return result
+}
+
+// FindPath 返回到达指定放置（占据相同的格子）的最短操作序列，无法到达时返回 false
+func FindPath(board Board, piece Tetromino, target Tetromino) (Path, bool) {
+	key := cellsKey(target)
+	var result Path
+	found := false
+	for _, path := range FindPaths(board, piece) {
+		if cellsKey(path.Piece) == key && (!found || len(path.Inputs) < len(result.Inputs)) {
+			result = path
+			found = true
+		}
+	}
+	return result, found
+}
+
+// SameCells 判断两个方块是否占据相同的格子，与旋转状态无关
+func SameCells(a, b Tetromino) bool {
+	return a != nil && b != nil && cellsKey(a) == cellsKey(b)
+}
+
+// inputs 返回从根节点到该节点的操作序列
+func (n *pathNode) inputs() []types.Input {
+	inputs := make([]types.Input, n.depth, n.depth+1)
+	for node := n; node.parent != nil; node = node.parent {
+		inputs[node.depth-1] = node.input
+	}
+	return inputs
+}
+
+// applyPathInput 按引擎规则对方块执行一次操作
+func applyPathInput(board Board, piece Tetromino, input types.Input) (Tetromino, bool) {
+	switch input {
+	case types.InputLeft:
+		return MovePiece(board, piece, -1, 0)
+	case types.InputRight:
+		return MovePiece(board, piece, 1, 0)
+	case types.InputSoftDrop:
+		return MovePiece(board, piece, 0, 1)
+	case types.InputRotateRight:
+		return RotatePiece(board, piece, types.DirectionRight)
+	case types.InputRotateLeft:
+		return RotatePiece(board, piece, types.DirectionLeft)
+	}
+	return nil, false
+}
+
+// isRotation 判断操作是否为旋转
+func isRotation(input types.Input) bool {
+	return input == types.InputRotateRight || input == types.InputRotateLeft
+}
+
+// rotationCount 返回方块的旋转状态数
+func rotationCount(piece Tetromino) int {
+	start := piece.GetRotation()
+	rotated := piece.Rotate(types.DirectionRight)
+	count := 1
+	for rotated.GetRotation() != start {
+		rotated = rotated.Rotate(types.DirectionRight)
+		count++
+	}
+	return count
+}
+
+// cellsKey 以占据的格子标识方块的位置，与旋转状态无关
+func cellsKey(piece Tetromino) string {
+	position := piece.GetPosition()
+	cells := make([]types.Position, 0, len(piece.GetBlocks()))
+	for _, block := range piece.GetBlocks() {
+		cells = append(cells, types.Position{X: position.X + block.X, Y: position.Y + block.Y})
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Y != cells[j].Y {
+			return cells[i].Y < cells[j].Y
+		}
+		return cells[i].X < cells[j].X
+	})
+
+	key := make([]byte, 0, len(cells)*2)
+	for _, cell := range cells {
+		// 棋盘上方的格子 y 为负数，偏移后仍能用一个字节表示
+		key = append(key, byte(cell.X+64), byte(cell.Y+64))
+	}
+	return string(key)
+}
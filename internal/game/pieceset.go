@@ -0,0 +1,365 @@
+// Package game 实现数据驱动的方块集
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// PieceDef 方块定义：旋转状态、颜色和踢墙表
+type PieceDef struct {
+	Type      types.TetrominoType
+	Name      string
+	Color     types.Color
+	Rotations [][]types.Position // 每个旋转状态中各个小块相对旋转中心的位置
+	Kicks     []types.Position   // 直接旋转失败时依次尝试的偏移
+}
+
+// PieceSet 方块集：一局游戏中可能出现的所有方块，创建后不再修改
+type PieceSet struct {
+	Name    string
+	pieces  []*PieceDef
+	byType  map[types.TetrominoType]*PieceDef
+	palette []color.NRGBA // 自定义颜色，依次对应 types.ColorCustom 起的颜色值
+}
+
+// standardPieceSet 标准的七种四连方块
+var standardPieceSet = newStandardPieceSet()
+
+// StandardPieceSet 返回标准的七种四连方块
+func StandardPieceSet() *PieceSet {
+	return standardPieceSet
+}
+
+// newStandardPieceSet 根据内置的形状表创建标准方块集
+func newStandardPieceSet() *PieceSet {
+	set := &PieceSet{Name: "标准", byType: make(map[types.TetrominoType]*PieceDef)}
+	for tetrominoType := types.TetrominoI; tetrominoType <= types.TetrominoL; tetrominoType++ {
+		set.add(&PieceDef{
+			Type:      tetrominoType,
+			Name:      string(tetrominoLetters[tetrominoType]),
+			Color:     tetrominoColors[tetrominoType],
+			Rotations: tetrominoShapes[tetrominoType],
+			Kicks:     defaultKicks,
+		})
+	}
+	return set
+}
+
+// add 向方块集中加入方块定义
+func (s *PieceSet) add(def *PieceDef) {
+	s.pieces = append(s.pieces, def)
+	s.byType[def.Type] = def
+}
+
+// Types 返回方块集中所有的方块类型
+func (s *PieceSet) Types() []types.TetrominoType {
+	result := make([]types.TetrominoType, len(s.pieces))
+	for i, def := range s.pieces {
+		result[i] = def.Type
+	}
+	return result
+}
+
+// Get 返回指定类型的方块定义
+func (s *PieceSet) Get(tetrominoType types.TetrominoType) (*PieceDef, bool) {
+	def, ok := s.byType[tetrominoType]
+	return def, ok
+}
+
+// NewPiece 创建指定类型的方块，方块集中没有该类型时按标准方块创建
+func (s *PieceSet) NewPiece(tetrominoType types.TetrominoType) Tetromino {
+	def, ok := s.byType[tetrominoType]
+	if !ok {
+		return NewTetromino(tetrominoType)
+	}
+	return newPiece(def)
+}
+
+// MaxSize 返回方块集中所有方块在任意旋转状态下外接矩形的最大边长
+func (s *PieceSet) MaxSize() int {
+	size := 0
+	for _, def := range s.pieces {
+		for _, blocks := range def.Rotations {
+			minX, minY, maxX, maxY := blockBounds(blocks)
+			if maxX-minX+1 > size {
+				size = maxX - minX + 1
+			}
+			if maxY-minY+1 > size {
+				size = maxY - minY + 1
+			}
+		}
+	}
+	return size
+}
+
+// CustomColor 返回自定义颜色值对应的颜色
+func (s *PieceSet) CustomColor(c types.Color) (color.NRGBA, bool) {
+	index := int(c - types.ColorCustom)
+	if index < 0 || index >= len(s.palette) {
+		return color.NRGBA{}, false
+	}
+	return s.palette[index], true
+}
+
+// blockBounds 返回一组小块的外接矩形
+func blockBounds(blocks []types.Position) (minX, minY, maxX, maxY int) {
+	minX, minY = blocks[0].X, blocks[0].Y
+	maxX, maxY = minX, minY
+	for _, block := range blocks[1:] {
+		if block.X < minX {
+			minX = block.X
+		}
+		if block.X > maxX {
+			maxX = block.X
+		}
+		if block.Y < minY {
+			minY = block.Y
+		}
+		if block.Y > maxY {
+			maxY = block.Y
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+// pieceSetFile 方块集文件的 JSON 格式，详见 pieces/README.md
+type pieceSetFile struct {
+	Name   string `json:"name"`
+	Pieces []struct {
+		Name      string     `json:"name"`
+		Color     string     `json:"color"`
+		Shape     []string   `json:"shape"`
+		Rotations [][]string `json:"rotations"`
+		Kicks     [][2]int   `json:"kicks"`
+	} `json:"pieces"`
+}
+
+// ParsePieceSet 解析 JSON 格式的方块集
+func ParsePieceSet(data []byte) (*PieceSet, error) {
+	var file pieceSetFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析方块集失败: %w", err)
+	}
+	if len(file.Pieces) == 0 {
+		return nil, fmt.Errorf("方块集 %q 没有方块", file.Name)
+	}
+
+	set := &PieceSet{Name: file.Name, byType: make(map[types.TetrominoType]*PieceDef)}
+	names := make(map[string]bool)
+	for i, piece := range file.Pieces {
+		if piece.Name == "" || names[piece.Name] {
+			return nil, fmt.Errorf("方块集 %q 第 %d 个方块的名称为空或重复", file.Name, i+1)
+		}
+		names[piece.Name] = true
+
+		def := &PieceDef{
+			Type:  types.TetrominoCustom + types.TetrominoType(i),
+			Name:  piece.Name,
+			Kicks: defaultKicks,
+		}
+
+		var err error
+		if def.Color, err = set.parseColor(piece.Color); err != nil {
+			return nil, fmt.Errorf("方块 %q: %w", piece.Name, err)
+		}
+
+		if len(piece.Rotations) > 0 {
+			for _, grid := range piece.Rotations {
+				blocks, err := parseShape(grid)
+				if err != nil {
+					return nil, fmt.Errorf("方块 %q: %w", piece.Name, err)
+				}
+				def.Rotations = append(def.Rotations, blocks)
+			}
+		} else {
+			blocks, err := parseShape(piece.Shape)
+			if err != nil {
+				return nil, fmt.Errorf("方块 %q: %w", piece.Name, err)
+			}
+			def.Rotations = rotationStates(blocks)
+		}
+
+		if piece.Kicks != nil {
+			def.Kicks = make([]types.Position, len(piece.Kicks))
+			for j, kick := range piece.Kicks {
+				def.Kicks[j] = types.Position{X: kick[0], Y: kick[1]}
+			}
+		}
+
+		set.add(def)
+	}
+
+	return set, nil
+}
+
+// LoadPieceSet 从文件加载方块集
+func LoadPieceSet(path string) (*PieceSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取方块集文件失败: %w", err)
+	}
+	return ParsePieceSet(data)
+}
+
+// LoadPieceSets 加载目录中所有的方块集文件（*.json），按文件名排序
+func LoadPieceSets(dir string) ([]*PieceSet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	sets := make([]*PieceSet, 0, len(paths))
+	for _, path := range paths {
+		set, err := LoadPieceSet(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// pieceSetData 方块集完整定义的 JSON 格式，用于录像等需要原样保存方块集的场合
+type pieceSetData struct {
+	Name    string      `json:"name"`
+	Pieces  []*PieceDef `json:"pieces"`
+	Palette []string    `json:"palette,omitempty"` // 自定义颜色，格式为 "#rrggbb"
+}
+
+// MarshalJSON 保存方块集的完整定义
+func (s *PieceSet) MarshalJSON() ([]byte, error) {
+	data := pieceSetData{Name: s.Name, Pieces: s.pieces}
+	for _, c := range s.palette {
+		data.Palette = append(data.Palette, fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON 从完整定义恢复方块集
+func (s *PieceSet) UnmarshalJSON(bytes []byte) error {
+	var data pieceSetData
+	if err := json.Unmarshal(bytes, &data); err != nil {
+		return err
+	}
+	if len(data.Pieces) == 0 {
+		return fmt.Errorf("方块集 %q 没有方块", data.Name)
+	}
+
+	*s = PieceSet{Name: data.Name, byType: make(map[types.TetrominoType]*PieceDef)}
+	for _, text := range data.Palette {
+		if _, err := s.parseColor(text); err != nil {
+			return err
+		}
+	}
+	for _, def := range data.Pieces {
+		if def == nil || len(def.Rotations) == 0 {
+			return fmt.Errorf("方块集 %q 中的方块没有形状", data.Name)
+		}
+		for _, blocks := range def.Rotations {
+			if len(blocks) == 0 {
+				return fmt.Errorf("方块 %q 的形状为空", def.Name)
+			}
+		}
+		s.add(def)
+	}
+	return nil
+}
+
+// parseColor 解析方块颜色：标准方块的字母（如 "T"）使用对应的内置颜色，"#rrggbb" 为自定义颜色
+func (s *PieceSet) parseColor(text string) (types.Color, error) {
+	if strings.HasPrefix(text, "#") {
+		var c color.NRGBA
+		if _, err := fmt.Sscanf(text, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil || len(text) != 7 {
+			return 0, fmt.Errorf("无效的颜色 %q", text)
+		}
+		c.A = 255
+		for i, existing := range s.palette {
+			if existing == c {
+				return types.ColorCustom + types.Color(i), nil
+			}
+		}
+		s.palette = append(s.palette, c)
+		return types.ColorCustom + types.Color(len(s.palette)-1), nil
+	}
+
+	runes := []rune(text)
+	if len(runes) == 1 {
+		if tetrominoType, ok := ParseTetrominoLetter(runes[0]); ok {
+			return ColorForTetrominoType(tetrominoType), nil
+		}
+	}
+	return 0, fmt.Errorf("无效的颜色 %q", text)
+}
+
+// parseShape 解析方块形状：每行一个字符串，'X' 或 '#' 为小块，'.' 为空；
+// 旋转中心为网格的中心格（偶数边长时偏向左上）
+func parseShape(rows []string) ([]types.Position, error) {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	pivotX, pivotY := (width-1)/2, (len(rows)-1)/2
+
+	var blocks []types.Position
+	for y, row := range rows {
+		for x, r := range row {
+			switch r {
+			case 'X', 'x', '#':
+				blocks = append(blocks, types.Position{X: x - pivotX, Y: y - pivotY})
+			case '.', ' ':
+			default:
+				return nil, fmt.Errorf("无法识别的形状字符 %q", r)
+			}
+		}
+	}
+
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("形状为空")
+	}
+	return blocks, nil
+}
+
+// rotationStates 绕旋转中心顺时针旋转生成各个旋转状态，对称的形状只保留不重复的状态
+func rotationStates(blocks []types.Position) [][]types.Position {
+	states := [][]types.Position{blocks}
+	for i := 1; i < 4; i++ {
+		previous := states[i-1]
+		rotated := make([]types.Position, len(previous))
+		for j, block := range previous {
+			rotated[j] = types.Position{X: -block.Y, Y: block.X}
+		}
+		if sameBlocks(rotated, blocks) {
+			break
+		}
+		states = append(states, rotated)
+	}
+	return states
+}
+
+// sameBlocks 判断两组小块是否占据相同的位置
+func sameBlocks(a, b []types.Position) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	positions := make(map[types.Position]bool, len(a))
+	for _, block := range a {
+		positions[block] = true
+	}
+	for _, block := range b {
+		if !positions[block] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,231 @@
+// Package game 实现谜题的定义和加载
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// PuzzleGoalType 谜题的目标类型
+type PuzzleGoalType string
+
+const (
+	PuzzleGoalClearAll    PuzzleGoalType = "clear_all" // 清空整个棋盘
+	PuzzleGoalTSpinDouble PuzzleGoalType = "tsd"       // 完成一次 T-spin 双消
+	PuzzleGoalLines       PuzzleGoalType = "lines"     // 在限定的方块数内消除指定行数
+	PuzzleGoalPractice    PuzzleGoalType = "practice"  // 自由练习：没有目标，方块序列用完时结束，没有序列时随机生成
+)
+
+// PuzzleGoal 谜题目标
+type PuzzleGoal struct {
+	Type   PuzzleGoalType
+	Lines  int // 需要消除的行数（lines 目标）
+	Pieces int // 最多可以放置的方块数，0 表示不限制（序列用完即失败）
+}
+
+// Puzzle 谜题：初始棋盘、固定的方块序列、暂存方块和目标
+type Puzzle struct {
+	Name        string
+	Description string
+	Board       [][]types.Color // 初始棋盘，按从上到下的顺序排列，与棋盘底部对齐
+	Queue       []types.TetrominoType
+	Hold        []types.TetrominoType // 初始暂存的方块，最多一个
+	Goal        PuzzleGoal
+	Gravity     bool // 是否开启重力
+}
+
+// puzzleFile 谜题文件的 JSON 格式，详见 puzzles/README.md
+type puzzleFile struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Board       []string `json:"board"`
+	Queue       string   `json:"queue"`
+	Hold        string   `json:"hold"`
+	Gravity     bool     `json:"gravity"`
+	Goal        struct {
+		Type   string `json:"type"`
+		Lines  int    `json:"lines"`
+		Pieces int    `json:"pieces"`
+	} `json:"goal"`
+}
+
+// ParsePuzzle 解析 JSON 格式的谜题
+func ParsePuzzle(data []byte) (*Puzzle, error) {
+	var file puzzleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析谜题失败: %w", err)
+	}
+
+	puzzle := &Puzzle{
+		Name:        file.Name,
+		Description: file.Description,
+		Gravity:     file.Gravity,
+		Goal: PuzzleGoal{
+			Type:   PuzzleGoalType(file.Goal.Type),
+			Lines:  file.Goal.Lines,
+			Pieces: file.Goal.Pieces,
+		},
+	}
+
+	for i, row := range file.Board {
+		cells, err := parseBoardRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("谜题 %q 第 %d 行: %w", file.Name, i+1, err)
+		}
+		puzzle.Board = append(puzzle.Board, cells)
+	}
+
+	queue, err := parsePieceList(file.Queue)
+	if err != nil {
+		return nil, fmt.Errorf("谜题 %q 的方块序列: %w", file.Name, err)
+	}
+	puzzle.Queue = queue
+
+	hold, err := parsePieceList(file.Hold)
+	if err != nil || len(hold) > 1 {
+		return nil, fmt.Errorf("谜题 %q 的暂存方块无效: %q", file.Name, file.Hold)
+	}
+	puzzle.Hold = hold
+
+	if len(puzzle.Queue) == 0 {
+		return nil, fmt.Errorf("谜题 %q 没有方块序列", file.Name)
+	}
+
+	switch puzzle.Goal.Type {
+	case PuzzleGoalClearAll, PuzzleGoalTSpinDouble, PuzzleGoalPractice:
+	case PuzzleGoalLines:
+		if puzzle.Goal.Lines <= 0 {
+			return nil, fmt.Errorf("谜题 %q 的目标行数必须大于 0", file.Name)
+		}
+	default:
+		return nil, fmt.Errorf("谜题 %q 的目标类型未知: %q", file.Name, file.Goal.Type)
+	}
+
+	return puzzle, nil
+}
+
+// MarshalPuzzle 将谜题转换为 JSON 格式的谜题文件，自定义颜色的格子写为垃圾方块
+func MarshalPuzzle(puzzle *Puzzle) ([]byte, error) {
+	file := puzzleFile{
+		Name:        puzzle.Name,
+		Description: puzzle.Description,
+		Board:       []string{},
+		Queue:       pieceListString(puzzle.Queue),
+		Hold:        pieceListString(puzzle.Hold),
+		Gravity:     puzzle.Gravity,
+	}
+	file.Goal.Type = string(puzzle.Goal.Type)
+	file.Goal.Lines = puzzle.Goal.Lines
+	file.Goal.Pieces = puzzle.Goal.Pieces
+
+	for _, row := range puzzle.Board {
+		var b strings.Builder
+		for _, color := range row {
+			if color == types.ColorEmpty {
+				b.WriteByte('.')
+			} else if tetrominoType, ok := tetrominoTypeForColor(color); ok {
+				b.WriteRune(TetrominoLetter(tetrominoType))
+			} else {
+				b.WriteByte('X')
+			}
+		}
+		file.Board = append(file.Board, b.String())
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// SavePuzzle 将谜题保存为 JSON 格式的谜题文件，保存前按加载时的规则检查谜题
+func SavePuzzle(path string, puzzle *Puzzle) error {
+	data, err := MarshalPuzzle(puzzle)
+	if err != nil {
+		return err
+	}
+	if _, err := ParsePuzzle(data); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadPuzzle 从文件加载谜题
+func LoadPuzzle(path string) (*Puzzle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取谜题文件失败: %w", err)
+	}
+	return ParsePuzzle(data)
+}
+
+// LoadPuzzles 加载目录中所有的谜题文件（*.json），按文件名排序
+func LoadPuzzles(dir string) ([]*Puzzle, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	puzzles := make([]*Puzzle, 0, len(paths))
+	for _, path := range paths {
+		puzzle, err := LoadPuzzle(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		puzzles = append(puzzles, puzzle)
+	}
+	return puzzles, nil
+}
+
+// parseBoardRow 解析一行棋盘：'.' 为空，'X' 或 'G' 为垃圾方块，I/O/T/S/Z/J/L 为对应颜色的方块
+func parseBoardRow(row string) ([]types.Color, error) {
+	cells := make([]types.Color, 0, len(row))
+	for _, r := range row {
+		switch r {
+		case '.', '_', ' ':
+			cells = append(cells, types.ColorEmpty)
+		case 'X', 'x', 'G', 'g', '#':
+			cells = append(cells, types.ColorGarbage)
+		default:
+			tetrominoType, ok := ParseTetrominoLetter(r)
+			if !ok {
+				return nil, fmt.Errorf("无法识别的格子 %q", r)
+			}
+			cells = append(cells, ColorForTetrominoType(tetrominoType))
+		}
+	}
+	return cells, nil
+}
+
+// parsePieceList 解析由方块字母组成的序列，如 "TIOL"
+func parsePieceList(letters string) ([]types.TetrominoType, error) {
+	var pieces []types.TetrominoType
+	for _, r := range strings.TrimSpace(letters) {
+		tetrominoType, ok := ParseTetrominoLetter(r)
+		if !ok {
+			return nil, fmt.Errorf("无法识别的方块 %q", r)
+		}
+		pieces = append(pieces, tetrominoType)
+	}
+	return pieces, nil
+}
+
+// pieceListString 返回方块序列对应的字母，如 "TIOL"
+func pieceListString(pieces []types.TetrominoType) string {
+	var b strings.Builder
+	for _, tetrominoType := range pieces {
+		b.WriteRune(TetrominoLetter(tetrominoType))
+	}
+	return b.String()
+}
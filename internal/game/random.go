@@ -0,0 +1,48 @@
+// Package game 实现可以保存和恢复状态的随机数源
+package game
+
+import (
+	"math/rand"
+)
+
+// countingSource 记录种子和已经产生的随机数个数的随机数源。
+// math/rand 的随机数源无法直接保存状态，按种子重新设置后跳过相同个数的随机数即可恢复到同一状态
+type countingSource struct {
+	source rand.Source64
+	seed   int64
+	draws  uint64
+}
+
+// newCountingSource 创建指定种子的随机数源
+func newCountingSource(seed int64) *countingSource {
+	s := &countingSource{source: rand.NewSource(seed).(rand.Source64)}
+	s.Seed(seed)
+	return s
+}
+
+// Seed 重新设置种子
+func (s *countingSource) Seed(seed int64) {
+	s.source.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
+
+// Int63 返回一个非负的 63 位随机数
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.source.Int63()
+}
+
+// Uint64 返回一个 64 位随机数
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.source.Uint64()
+}
+
+// restore 恢复到按 seed 设置种子后产生了 draws 个随机数的状态
+func (s *countingSource) restore(seed int64, draws uint64) {
+	s.Seed(seed)
+	for s.draws < draws {
+		s.Int63()
+	}
+}
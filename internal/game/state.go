@@ -0,0 +1,325 @@
+// Package game 实现完整游戏状态的保存和恢复
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// PieceState 方块的类型、位置和旋转状态
+type PieceState struct {
+	Type     types.TetrominoType `json:"type"`
+	X        int                 `json:"x"`
+	Y        int                 `json:"y"`
+	Rotation int                 `json:"rotation"`
+}
+
+// SnapshotState 撤销历史中的一条记录
+type SnapshotState struct {
+	Cells      [][]types.Color `json:"cells"`
+	Current    *PieceState     `json:"current"`
+	Next       *PieceState     `json:"next"`
+	Hold       *PieceState     `json:"hold"`
+	Score      int             `json:"score"`
+	Level      int             `json:"level"`
+	Lines      int             `json:"lines"`
+	BackToBack bool            `json:"back_to_back"`
+}
+
+// State 游戏的完整状态，恢复后游戏的后续进行与保存时完全一致。
+// 不包括事件处理函数和最近一次放置的最简操作检查结果
+type State struct {
+	EngineVersion int             `json:"engine_version"`
+	Config        GameConfig      `json:"config"`
+	Status        types.GameState `json:"state"`
+
+	// 棋盘和方块
+	Cells      [][]types.Color `json:"cells"`
+	LockFrames [][]int         `json:"lock_frames"`
+	Current    *PieceState     `json:"current"`
+	Next       *PieceState     `json:"next"`
+	Hold       *PieceState     `json:"hold"`
+	HoldUsed   bool            `json:"hold_used"`
+
+	// 随机数和方块生成
+	Seed        int64                 `json:"seed"`
+	Draws       uint64                `json:"draws"`
+	Randomizer  types.Randomizer      `json:"randomizer"`
+	Bag         []types.TetrominoType `json:"bag"`
+	Sequence    []types.TetrominoType `json:"sequence"`
+	UseSequence bool                  `json:"use_sequence"`
+
+	// 统计和消行
+	Score           int       `json:"score"`
+	Level           int       `json:"level"`
+	Lines           int       `json:"lines"`
+	LastClear       ClearInfo `json:"last_clear"`
+	LastMoveRotated bool      `json:"last_move_rotated"`
+	BackToBack      bool      `json:"back_to_back"`
+
+	// 计时器
+	DropTimer      int  `json:"drop_timer"`
+	DropInterval   int  `json:"drop_interval"`
+	Frame          int  `json:"frame"`
+	FrameTimer     int  `json:"frame_timer"`
+	GravityEnabled bool `json:"gravity_enabled"`
+	GravityCounter int  `json:"gravity_counter"`
+	LockTimer      int  `json:"lock_timer"`
+	SpawnDelay     int  `json:"spawn_delay"`
+	ShiftDirection int  `json:"shift_direction"`
+	ShiftTimer     int  `json:"shift_timer"`
+
+	// 最简操作检查
+	Spawn       *PieceState `json:"spawn"`
+	PieceInputs int         `json:"piece_inputs"`
+
+	// 撤销历史
+	SpawnSnapshot *SnapshotState  `json:"spawn_snapshot"`
+	UndoStack     []SnapshotState `json:"undo_stack"`
+	RedoStack     []SnapshotState `json:"redo_stack"`
+
+	// 模式自身的状态，格式由模式决定
+	Mode json.RawMessage `json:"mode,omitempty"`
+}
+
+// SaveState 保存游戏的完整状态
+func (g *gameImpl) SaveState() (*State, error) {
+	modeState, err := g.mode.SaveState()
+	if err != nil {
+		return nil, fmt.Errorf("保存模式状态失败: %w", err)
+	}
+
+	// 标准方块集不需要保存完整定义
+	config := g.config
+	if config.PieceSet == StandardPieceSet() {
+		config.PieceSet = nil
+	}
+
+	state := &State{
+		EngineVersion: EngineVersion,
+		Config:        config,
+		Status:        g.state,
+
+		Cells:      make([][]types.Color, g.board.GetHeight()),
+		LockFrames: make([][]int, g.board.GetHeight()),
+		Current:    savePiece(g.currentTetromino),
+		Next:       savePiece(g.nextTetromino),
+		Hold:       savePiece(g.holdTetromino),
+		HoldUsed:   g.holdUsed,
+
+		Seed:        g.source.seed,
+		Draws:       g.source.draws,
+		Randomizer:  g.factory.randomizer,
+		Bag:         append([]types.TetrominoType(nil), g.factory.bag...),
+		Sequence:    append([]types.TetrominoType(nil), g.factory.sequence...),
+		UseSequence: g.factory.useSequence,
+
+		Score:           g.score,
+		Level:           g.level,
+		Lines:           g.linesCleared,
+		LastClear:       g.lastClear,
+		LastMoveRotated: g.lastMoveRotated,
+		BackToBack:      g.backToBack,
+
+		DropTimer:      g.dropTimer,
+		DropInterval:   g.dropInterval,
+		Frame:          g.frame,
+		FrameTimer:     g.frameTimer,
+		GravityEnabled: g.gravityEnabled,
+		GravityCounter: g.gravityCounter,
+		LockTimer:      g.lockTimer,
+		SpawnDelay:     g.spawnDelay,
+		ShiftDirection: g.shiftDirection,
+		ShiftTimer:     g.shiftTimer,
+
+		Spawn:       savePiece(g.spawnPiece),
+		PieceInputs: g.pieceInputs,
+
+		SpawnSnapshot: saveSnapshot(g.spawnSnapshot),
+		UndoStack:     saveSnapshots(g.undoStack),
+		RedoStack:     saveSnapshots(g.redoStack),
+
+		Mode: modeState,
+	}
+
+	for y := range state.Cells {
+		state.Cells[y] = make([]types.Color, g.board.GetWidth())
+		state.LockFrames[y] = make([]int, g.board.GetWidth())
+		for x := range state.Cells[y] {
+			state.Cells[y][x] = g.board.GetCell(x, y)
+			state.LockFrames[y][x] = g.board.GetLockFrame(x, y)
+		}
+	}
+
+	return state, nil
+}
+
+// LoadState 恢复保存的完整状态。游戏必须使用与保存时相同的模式创建，
+// 方块集等配置以保存的状态为准
+func (g *gameImpl) LoadState(state *State) error {
+	if state.EngineVersion != EngineVersion {
+		return fmt.Errorf("状态由第 %d 版游戏逻辑保存，当前为第 %d 版", state.EngineVersion, EngineVersion)
+	}
+	if len(state.Cells) != state.Config.BoardHeight || len(state.LockFrames) != state.Config.BoardHeight {
+		return fmt.Errorf("棋盘高度与配置不一致")
+	}
+	for y := range state.Cells {
+		if len(state.Cells[y]) != state.Config.BoardWidth || len(state.LockFrames[y]) != state.Config.BoardWidth {
+			return fmt.Errorf("棋盘宽度与配置不一致")
+		}
+	}
+
+	config := state.Config
+	if config.PieceSet == nil {
+		config.PieceSet = StandardPieceSet()
+	}
+	// 模式按恢复后的配置（棋盘宽度、方块集）恢复状态，失败时还原配置，不修改游戏的其他部分
+	previousConfig, previousSeedFixed := g.config, g.seedFixed
+	previousPieceSet, previousBag := g.factory.pieceSet, g.factory.bag
+	g.config = config
+	g.seedFixed = true
+	g.factory.SetPieceSet(config.PieceSet)
+	if err := g.mode.LoadState(state.Mode, g); err != nil {
+		g.config = previousConfig
+		g.seedFixed = previousSeedFixed
+		g.factory.pieceSet = previousPieceSet
+		g.factory.bag = previousBag
+		return fmt.Errorf("恢复模式状态失败: %w", err)
+	}
+
+	board := NewBoard(config.BoardWidth, config.BoardHeight).(*board)
+	for y := range state.Cells {
+		copy(board.cells[y], state.Cells[y])
+		copy(board.lockFrames[y], state.LockFrames[y])
+	}
+	board.SetClock(state.Frame)
+	g.board = board
+
+	g.state = state.Status
+	g.currentTetromino = g.loadPiece(state.Current)
+	g.nextTetromino = g.loadPiece(state.Next)
+	g.holdTetromino = g.loadPiece(state.Hold)
+	g.holdUsed = state.HoldUsed
+
+	g.source.restore(state.Seed, state.Draws)
+	g.factory.randomizer = state.Randomizer
+	g.factory.bag = append([]types.TetrominoType(nil), state.Bag...)
+	g.factory.sequence = append([]types.TetrominoType(nil), state.Sequence...)
+	g.factory.useSequence = state.UseSequence
+
+	g.score = state.Score
+	g.level = state.Level
+	g.linesCleared = state.Lines
+	g.lastClear = state.LastClear
+	g.lastMoveRotated = state.LastMoveRotated
+	g.backToBack = state.BackToBack
+
+	g.dropTimer = state.DropTimer
+	g.dropInterval = state.DropInterval
+	g.frame = state.Frame
+	g.frameTimer = state.FrameTimer
+	g.gravityEnabled = state.GravityEnabled
+	g.gravityCounter = state.GravityCounter
+	g.lockTimer = state.LockTimer
+	g.spawnDelay = state.SpawnDelay
+	g.shiftDirection = state.ShiftDirection
+	g.shiftTimer = state.ShiftTimer
+
+	g.spawnPiece = g.loadPiece(state.Spawn)
+	g.pieceInputs = state.PieceInputs
+	g.lastPlacement = nil
+	g.lastFinesse = nil
+
+	g.spawnSnapshot = g.loadSnapshot(state.SpawnSnapshot)
+	g.undoStack = g.loadSnapshots(state.UndoStack)
+	g.redoStack = g.loadSnapshots(state.RedoStack)
+	return nil
+}
+
+// savePiece 保存方块的状态，nil 时返回 nil
+func savePiece(piece Tetromino) *PieceState {
+	if piece == nil {
+		return nil
+	}
+	position := piece.GetPosition()
+	return &PieceState{Type: piece.GetType(), X: position.X, Y: position.Y, Rotation: piece.GetRotation()}
+}
+
+// loadPiece 按保存的状态用游戏的方块集创建方块，nil 时返回 nil
+func (g *gameImpl) loadPiece(state *PieceState) Tetromino {
+	return restorePiece(g.config.PieceSet, state)
+}
+
+// restorePiece 按保存的状态用指定的方块集创建方块，nil 时返回 nil
+func restorePiece(pieceSet *PieceSet, state *PieceState) Tetromino {
+	if state == nil {
+		return nil
+	}
+	piece := pieceSet.NewPiece(state.Type)
+	for i := 0; i < 4 && piece.GetRotation() != state.Rotation; i++ {
+		piece = piece.Rotate(types.DirectionRight)
+	}
+	piece.SetPosition(types.Position{X: state.X, Y: state.Y})
+	return piece
+}
+
+// saveSnapshot 保存撤销历史中的一条记录，nil 时返回 nil
+func saveSnapshot(snapshot *gameSnapshot) *SnapshotState {
+	if snapshot == nil {
+		return nil
+	}
+	return &SnapshotState{
+		Cells:      snapshot.cells,
+		Current:    savePiece(snapshot.current),
+		Next:       savePiece(snapshot.next),
+		Hold:       savePiece(snapshot.hold),
+		Score:      snapshot.score,
+		Level:      snapshot.level,
+		Lines:      snapshot.linesCleared,
+		BackToBack: snapshot.backToBack,
+	}
+}
+
+// saveSnapshots 保存撤销或重做记录
+func saveSnapshots(snapshots []*gameSnapshot) []SnapshotState {
+	result := make([]SnapshotState, len(snapshots))
+	for i, snapshot := range snapshots {
+		result[i] = *saveSnapshot(snapshot)
+	}
+	return result
+}
+
+// loadSnapshot 恢复撤销历史中的一条记录，nil 时返回 nil
+func (g *gameImpl) loadSnapshot(state *SnapshotState) *gameSnapshot {
+	if state == nil {
+		return nil
+	}
+	cells := make([][]types.Color, len(state.Cells))
+	for y, row := range state.Cells {
+		cells[y] = append([]types.Color(nil), row...)
+	}
+	return &gameSnapshot{
+		cells:        cells,
+		current:      g.loadPiece(state.Current),
+		next:         g.loadPiece(state.Next),
+		hold:         g.loadPiece(state.Hold),
+		score:        state.Score,
+		level:        state.Level,
+		linesCleared: state.Lines,
+		backToBack:   state.BackToBack,
+	}
+}
+
+// loadSnapshots 恢复撤销或重做记录
+func (g *gameImpl) loadSnapshots(states []SnapshotState) []*gameSnapshot {
+	if len(states) == 0 {
+		return nil
+	}
+	result := make([]*gameSnapshot, len(states))
+	for i := range states {
+		result[i] = g.loadSnapshot(&states[i])
+	}
+	return result
+}
@@ -10,8 +10,9 @@ type tetromino struct {
 	tetrominoType types.TetrominoType
 	color         types.Color
 	position      types.Position
-	rotation      int                // 当前旋转状态 (0-3)
-	blocks        [][]types.Position // 四个旋转状态的方块位置
+	rotation      int                // 当前旋转状态
+	blocks        [][]types.Position // 每个旋转状态的方块位置
+	kicks         []types.Position   // 直接旋转失败时依次尝试的偏移，所有同类方块共享
 }
 
 // GetType 返回方块类型
@@ -42,34 +43,41 @@ func (t *tetromino) GetBlocks() []types.Position {
 	return t.blocks[0]
 }
 
+// GetRotation 返回当前旋转状态
+func (t *tetromino) GetRotation() int {
+	return t.rotation
+}
+
+// GetKicks 返回旋转时的踢墙偏移
+func (t *tetromino) GetKicks() []types.Position {
+	return t.kicks
+}
+
 // Rotate 旋转方块
 func (t *tetromino) Rotate(direction types.Direction) Tetromino {
 	newTetromino := t.Clone().(*tetromino)
+	states := len(newTetromino.blocks)
 
 	switch direction {
 	case types.DirectionLeft:
-		newTetromino.rotation = (newTetromino.rotation + 3) % 4
+		newTetromino.rotation = (newTetromino.rotation + states - 1) % states
 	case types.DirectionRight:
-		newTetromino.rotation = (newTetromino.rotation + 1) % 4
+		newTetromino.rotation = (newTetromino.rotation + 1) % states
 	}
 
 	return newTetromino
 }
 
 // Clone 克隆方块
+// 形状数据在创建方块时已经深拷贝且之后不再修改，副本之间直接共享，使路径搜索中频繁的克隆足够廉价
 func (t *tetromino) Clone() Tetromino {
-	newBlocks := make([][]types.Position, len(t.blocks))
-	for i, rotationBlocks := range t.blocks {
-		newBlocks[i] = make([]types.Position, len(rotationBlocks))
-		copy(newBlocks[i], rotationBlocks)
-	}
-
 	return &tetromino{
 		tetrominoType: t.tetrominoType,
 		color:         t.color,
 		position:      t.position,
 		rotation:      t.rotation,
-		blocks:        newBlocks,
+		blocks:        t.blocks,
+		kicks:         t.kicks,
 	}
 }
 
@@ -143,32 +151,71 @@ var tetrominoColors = map[types.TetrominoType]types.Color{
 	types.TetrominoL: types.ColorL,
 }
 
-// NewTetromino 创建新的俄罗斯方块
+// defaultKicks 标准方块的踢墙偏移：左右各一格，再向上一格
+var defaultKicks = []types.Position{
+	{X: -1, Y: 0}, // 向左踢
+	{X: 1, Y: 0},  // 向右踢
+	{X: 0, Y: -1}, // 向上踢
+}
+
+// NewTetromino 创建标准方块集中的俄罗斯方块
 func NewTetromino(tetrominoType types.TetrominoType) Tetromino {
-	shapes, exists := tetrominoShapes[tetrominoType]
-	if !exists {
+	if _, exists := tetrominoShapes[tetrominoType]; !exists {
 		// 默认创建 I 形方块
-		shapes = tetrominoShapes[types.TetrominoI]
 		tetrominoType = types.TetrominoI
 	}
+	return standardPieceSet.NewPiece(tetrominoType)
+}
 
-	color, exists := tetrominoColors[tetrominoType]
-	if !exists {
-		color = types.ColorI
-	}
-
+// newPiece 根据方块定义创建方块
+func newPiece(def *PieceDef) Tetromino {
 	// 深拷贝形状数据
-	blocks := make([][]types.Position, len(shapes))
-	for i, shape := range shapes {
+	blocks := make([][]types.Position, len(def.Rotations))
+	for i, shape := range def.Rotations {
 		blocks[i] = make([]types.Position, len(shape))
 		copy(blocks[i], shape)
 	}
 
 	return &tetromino{
-		tetrominoType: tetrominoType,
-		color:         color,
+		tetrominoType: def.Type,
+		color:         def.Color,
 		position:      types.Position{X: types.BoardWidth / 2, Y: 0},
 		rotation:      0,
 		blocks:        blocks,
+		kicks:         def.Kicks,
 	}
 }
+
+// 方块类型与字母的对应关系，用于文本格式的谜题和局面
+var tetrominoLetters = map[types.TetrominoType]rune{
+	types.TetrominoI: 'I',
+	types.TetrominoO: 'O',
+	types.TetrominoT: 'T',
+	types.TetrominoS: 'S',
+	types.TetrominoZ: 'Z',
+	types.TetrominoJ: 'J',
+	types.TetrominoL: 'L',
+}
+
+// TetrominoLetter 返回方块类型对应的字母
+func TetrominoLetter(tetrominoType types.TetrominoType) rune {
+	return tetrominoLetters[tetrominoType]
+}
+
+// ParseTetrominoLetter 将字母（不区分大小写）解析为方块类型
+func ParseTetrominoLetter(letter rune) (types.TetrominoType, bool) {
+	if letter >= 'a' && letter <= 'z' {
+		letter -= 'a' - 'A'
+	}
+	for tetrominoType, l := range tetrominoLetters {
+		if l == letter {
+			return tetrominoType, true
+		}
+	}
+	return 0, false
+}
+
+// ColorForTetrominoType 返回方块类型对应的颜色
+func ColorForTetrominoType(tetrominoType types.TetrominoType) types.Color {
+	return tetrominoColors[tetrominoType]
+}
@@ -0,0 +1,164 @@
+// Package game 实现重力、锁定延迟、出现延迟和自动重复移动等时间相关的逻辑
+package game
+
+import (
+	"goeluosifangkuai/pkg/types"
+)
+
+// Timing 当前速度等级下的时间参数，除特别说明外单位均为逻辑帧
+type Timing struct {
+	Gravity        int // 重力，单位为 1/GravityUnit 格每帧；0 表示按等级计算的下落间隔下落
+	ARE            int // 方块固定后到下一个方块出现的延迟
+	LineClearDelay int // 消行时在出现延迟之外额外等待的时间
+	DAS            int // 按住左右键后开始自动重复移动的延迟
+	ARR            int // 自动重复移动的间隔，0 表示立即移动到底
+	LockDelay      int // 方块着地后到固定的延迟，0 表示着地后随下一次下落立即固定
+}
+
+// Handling 玩家的操作手感设置，覆盖模式给出的自动重复移动参数（帧）
+type Handling struct {
+	DAS int `json:"das"`
+	ARR int `json:"arr"`
+}
+
+// timing 返回当前的时间参数：模式的设置加上玩家的操作手感
+func (g *gameImpl) timing() Timing {
+	timing := g.mode.GetTiming(g)
+	if g.config.Handling != nil {
+		timing.DAS = g.config.Handling.DAS
+		timing.ARR = g.config.Handling.ARR
+	}
+	return timing
+}
+
+// DefaultTiming 返回经典规则的时间参数：按下落间隔下落，无出现延迟和锁定延迟
+func DefaultTiming() Timing {
+	return Timing{
+		DAS: types.DefaultDAS,
+		ARR: types.DefaultARR,
+	}
+}
+
+// applyGravity 处理自动下落和锁定延迟
+func (g *gameImpl) applyGravity(timing Timing) {
+	if !g.gravityEnabled || g.currentTetromino == nil {
+		return
+	}
+
+	if timing.Gravity > 0 {
+		// 按重力累积下落距离，20G 时方块会立即落到底部
+		g.gravityCounter += timing.Gravity
+		for g.gravityCounter >= types.GravityUnit {
+			g.gravityCounter -= types.GravityUnit
+			if !g.fall() {
+				g.gravityCounter = 0
+				break
+			}
+		}
+	} else {
+		// 更新下落计时器
+		g.dropTimer += types.FrameDuration
+
+		// 检查是否需要自动下落
+		if g.dropTimer >= g.dropInterval {
+			g.dropTimer = 0
+
+			if !g.fall() && timing.LockDelay == 0 {
+				// 无法向下移动，固定当前方块
+				g.lockCurrentTetromino()
+				return
+			}
+		}
+	}
+
+	if timing.Gravity > 0 || timing.LockDelay > 0 {
+		g.updateLockDelay(timing)
+	}
+}
+
+// fall 使当前方块下落一格，成功时重置锁定延迟
+func (g *gameImpl) fall() bool {
+	if g.MoveTetromino(0, 1) {
+		g.lockTimer = 0
+		return true
+	}
+	return false
+}
+
+// updateLockDelay 方块着地后累计锁定延迟，到时固定方块
+func (g *gameImpl) updateLockDelay(timing Timing) {
+	if !g.isGrounded() {
+		return
+	}
+
+	g.lockTimer++
+	if g.lockTimer >= timing.LockDelay {
+		g.lockCurrentTetromino()
+	}
+}
+
+// isGrounded 检查当前方块是否已经着地
+func (g *gameImpl) isGrounded() bool {
+	if g.currentTetromino == nil {
+		return false
+	}
+
+	below := g.currentTetromino.Clone()
+	position := below.GetPosition()
+	below.SetPosition(types.Position{X: position.X, Y: position.Y + 1})
+	return !g.board.IsValidPosition(below)
+}
+
+// updateSpawnDelay 出现延迟结束后生成新方块
+func (g *gameImpl) updateSpawnDelay() {
+	if g.currentTetromino != nil || g.spawnDelay <= 0 {
+		return
+	}
+
+	g.spawnDelay--
+	if g.spawnDelay == 0 {
+		g.spawnNewTetromino()
+	}
+}
+
+// StartShift 开始按住左右移动：立即移动一格，按住超过 DAS 后按 ARR 自动重复
+func (g *gameImpl) StartShift(dx int) {
+	g.shiftDirection = dx
+	g.shiftTimer = 0
+	if dx < 0 {
+		g.countInput(types.InputLeft)
+	} else {
+		g.countInput(types.InputRight)
+	}
+	g.MoveTetromino(dx, 0)
+}
+
+// StopShift 松开左右移动，只有方向与当前按住的方向一致时才生效
+func (g *gameImpl) StopShift(dx int) {
+	if g.shiftDirection == dx {
+		g.shiftDirection = 0
+		g.shiftTimer = 0
+	}
+}
+
+// updateShift 处理自动重复移动，出现延迟期间也会继续蓄力
+func (g *gameImpl) updateShift(timing Timing) {
+	if g.shiftDirection == 0 {
+		return
+	}
+
+	g.shiftTimer++
+	if g.shiftTimer < timing.DAS || g.currentTetromino == nil {
+		return
+	}
+
+	if timing.ARR <= 0 {
+		for g.MoveTetromino(g.shiftDirection, 0) {
+		}
+		return
+	}
+
+	if (g.shiftTimer-timing.DAS)%timing.ARR == 0 {
+		g.MoveTetromino(g.shiftDirection, 0)
+	}
+}
@@ -0,0 +1,56 @@
+// Package game 实现 T-spin 判定
+package game
+
+import (
+	"goeluosifangkuai/pkg/types"
+)
+
+// TSpinType 表示 T-spin 的类型
+type TSpinType int
+
+const (
+	TSpinNone TSpinType = iota // 不是 T-spin
+	TSpinMini                  // T-spin Mini
+	TSpinFull                  // T-spin
+)
+
+// tSpinFrontCorners T 方块每个旋转状态下朝向凸起一侧的两个角（相对中心）
+var tSpinFrontCorners = [4][2]types.Position{
+	{{X: -1, Y: 1}, {X: 1, Y: 1}},   // 凸起向下
+	{{X: -1, Y: -1}, {X: -1, Y: 1}}, // 凸起向左
+	{{X: -1, Y: -1}, {X: 1, Y: -1}}, // 凸起向上
+	{{X: 1, Y: -1}, {X: 1, Y: 1}},   // 凸起向右
+}
+
+// DetectTSpin 按三角规则判定 T-spin：T 方块最后一次操作为旋转，且中心四个角中至少三个被占据；
+// 凸起一侧的两个角都被占据时为 T-spin，否则为 T-spin Mini。墙壁和地板视为被占据
+func DetectTSpin(board Board, tetromino Tetromino, lastMoveRotated bool) TSpinType {
+	if tetromino == nil || tetromino.GetType() != types.TetrominoT || !lastMoveRotated {
+		return TSpinNone
+	}
+
+	center := tetromino.GetPosition()
+	occupied := func(dx, dy int) bool {
+		x, y := center.X+dx, center.Y+dy
+		if x < 0 || x >= board.GetWidth() || y >= board.GetHeight() {
+			return true
+		}
+		return y >= 0 && board.GetCell(x, y) != types.ColorEmpty
+	}
+
+	corners := 0
+	for _, corner := range []types.Position{{X: -1, Y: -1}, {X: 1, Y: -1}, {X: -1, Y: 1}, {X: 1, Y: 1}} {
+		if occupied(corner.X, corner.Y) {
+			corners++
+		}
+	}
+	if corners < 3 {
+		return TSpinNone
+	}
+
+	front := tSpinFrontCorners[tetromino.GetRotation()%4]
+	if occupied(front[0].X, front[0].Y) && occupied(front[1].X, front[1].Y) {
+		return TSpinFull
+	}
+	return TSpinMini
+}
@@ -0,0 +1,210 @@
+// Package highscore 实现按模式和规则分别保存的本地排行榜
+package highscore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/userdata"
+	"goeluosifangkuai/pkg/types"
+)
+
+// FormatVersion 排行榜文件格式的版本，格式改变时递增
+const FormatVersion = 1
+
+// RulesStandard 标准规则：标准方块集、标准尺寸的棋盘
+const RulesStandard = "standard"
+
+// Entry 排行榜中的一条成绩
+type Entry struct {
+	Name   string    `json:"name"`
+	Date   time.Time `json:"date"`
+	Score  int       `json:"score"`
+	Lines  int       `json:"lines"`
+	Time   int       `json:"time_ms"`          // 游戏时间（毫秒）
+	Replay string    `json:"replay,omitempty"` // 录像文件的路径
+}
+
+// better 判断成绩 a 是否排在 b 之前：分数高的在前，同分时用时短的在前，再按先后顺序。
+// byTime 为 true 时（如竞速模式）用时短的在前，同用时再比较分数
+func better(a, b Entry, byTime bool) bool {
+	if byTime && a.Time != b.Time {
+		return a.Time < b.Time
+	}
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Time != b.Time {
+		return a.Time < b.Time
+	}
+	return a.Date.Before(b.Date)
+}
+
+// Category 排行榜的分类：游戏模式和规则（大方块变体、方块集）不同的成绩分别排名
+type Category struct {
+	Mode  string `json:"mode"`  // 游戏模式标识，见 game.Modes
+	Rules string `json:"rules"` // 规则，标准规则为 RulesStandard
+}
+
+// CategoryOf 返回使用指定模式和配置的游戏所属的分类
+func CategoryOf(mode string, config game.GameConfig) Category {
+	var rules []string
+	if config.BoardWidth < types.BoardWidth {
+		rules = append(rules, "big")
+	}
+	if config.PieceSet != nil && config.PieceSet != game.StandardPieceSet() {
+		rules = append(rules, config.PieceSet.Name)
+	}
+	if len(rules) == 0 {
+		return Category{Mode: mode, Rules: RulesStandard}
+	}
+	return Category{Mode: mode, Rules: strings.Join(rules, "+")}
+}
+
+// RankByTime 判断分类的成绩是否按用时排名
+func (c Category) RankByTime() bool {
+	info, ok := game.FindMode(c.Mode)
+	return ok && info.RankByTime
+}
+
+// String 返回分类的显示名称
+func (c Category) String() string {
+	name := c.Mode
+	if info, ok := game.FindMode(c.Mode); ok {
+		name = info.Name
+	}
+	switch c.Rules {
+	case RulesStandard:
+		return name
+	case "big":
+		return name + "（大方块）"
+	}
+	return fmt.Sprintf("%s（%s）", name, strings.Replace(c.Rules, "big+", "大方块+", 1))
+}
+
+// table 一个分类的排行榜
+type table struct {
+	Category
+	Entries []Entry `json:"entries"`
+}
+
+// storeFile 排行榜文件的 JSON 格式
+type storeFile struct {
+	Version  int     `json:"version"`
+	LastName string  `json:"last_name"` // 上一次输入的名字
+	Tables   []table `json:"tables"`
+}
+
+// Store 保存在文件中的所有排行榜，每个分类保留最好的 limit 个成绩
+type Store struct {
+	path      string
+	limit     int
+	data      storeFile
+	recovered bool
+}
+
+// Open 打开排行榜文件，文件不存在时创建空的排行榜；文件损坏时从备份恢复
+func Open(path string, limit int) (*Store, error) {
+	store := &Store{path: path, limit: limit, data: storeFile{Version: FormatVersion}}
+	recovered, err := userdata.ReadJSON(path, &store.data)
+	if err != nil {
+		return nil, fmt.Errorf("读取排行榜失败: %w", err)
+	}
+	if store.data.Version > FormatVersion {
+		return nil, fmt.Errorf("不支持的排行榜文件版本: %d", store.data.Version)
+	}
+	store.recovered = recovered
+	return store, nil
+}
+
+// OpenDefault 打开用户数据目录中的排行榜
+func OpenDefault() (*Store, error) {
+	path, err := userdata.Path(types.HighScoreFile)
+	if err != nil {
+		return nil, err
+	}
+	return Open(path, types.HighScoreLimit)
+}
+
+// Recovered 返回打开时文件是否损坏，损坏的文件已被保留为 .corrupt 并从备份恢复
+func (s *Store) Recovered() bool {
+	return s.recovered
+}
+
+// LastName 返回上一次记录成绩时输入的名字
+func (s *Store) LastName() string {
+	return s.data.LastName
+}
+
+// Categories 返回所有有成绩的分类
+func (s *Store) Categories() []Category {
+	categories := make([]Category, len(s.data.Tables))
+	for i, t := range s.data.Tables {
+		categories[i] = t.Category
+	}
+	return categories
+}
+
+// Top 返回分类中按名次排列的成绩
+func (s *Store) Top(category Category) []Entry {
+	if t := s.find(category); t != nil {
+		return append([]Entry(nil), t.Entries...)
+	}
+	return nil
+}
+
+// Rank 返回成绩加入排行榜后的名次（从 1 开始），进不了排行榜时返回 0
+func (s *Store) Rank(category Category, entry Entry) int {
+	var entries []Entry
+	if t := s.find(category); t != nil {
+		entries = t.Entries
+	}
+	byTime := category.RankByTime()
+	rank := sort.Search(len(entries), func(i int) bool {
+		return better(entry, entries[i], byTime)
+	}) + 1
+	if rank > s.limit {
+		return 0
+	}
+	return rank
+}
+
+// Add 将成绩加入排行榜并写入文件，返回名次，进不了排行榜时返回 0 且不修改文件
+func (s *Store) Add(category Category, entry Entry) (int, error) {
+	rank := s.Rank(category, entry)
+	if rank == 0 {
+		return 0, nil
+	}
+
+	t := s.find(category)
+	if t == nil {
+		s.data.Tables = append(s.data.Tables, table{Category: category})
+		t = &s.data.Tables[len(s.data.Tables)-1]
+	}
+	t.Entries = append(t.Entries, Entry{})
+	copy(t.Entries[rank:], t.Entries[rank-1:])
+	t.Entries[rank-1] = entry
+	if len(t.Entries) > s.limit {
+		t.Entries = t.Entries[:s.limit]
+	}
+	s.data.LastName = entry.Name
+	s.data.Version = FormatVersion
+
+	if err := userdata.WriteJSON(s.path, s.data); err != nil {
+		return 0, fmt.Errorf("保存排行榜失败: %w", err)
+	}
+	return rank, nil
+}
+
+// find 返回分类的排行榜，没有时返回 nil
+func (s *Store) find(category Category) *table {
+	for i := range s.data.Tables {
+		if s.data.Tables[i].Category == category {
+			return &s.data.Tables[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package highscore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+)
+
+func TestStoreKeepsTopEntriesPerCategory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "highscores.json")
+	store, err := Open(path, 3)
+	if err != nil {
+		t.Fatalf("打开排行榜失败: %v", err)
+	}
+
+	standard := CategoryOf("marathon", game.DefaultGameConfig())
+	big := CategoryOf("marathon", game.BigGameConfig(game.DefaultGameConfig()))
+	if standard == big {
+		t.Fatalf("大方块变体应单独排名")
+	}
+
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, score := range []int{300, 100, 500, 200} {
+		store.Add(standard, Entry{Name: "甲", Date: date.Add(time.Duration(i) * time.Hour), Score: score, Time: 60000})
+	}
+	// 同分时用时短的在前
+	if rank, _ := store.Add(standard, Entry{Name: "乙", Date: date, Score: 300, Time: 30000}); rank != 2 {
+		t.Errorf("同分的新成绩用时更短，应排第 2 名，实际为 %d", rank)
+	}
+	if rank, _ := store.Add(standard, Entry{Name: "乙", Score: 50}); rank != 0 {
+		t.Errorf("低于最后一名的成绩不应进入排行榜，实际名次为 %d", rank)
+	}
+	store.Add(big, Entry{Name: "丙", Score: 10})
+
+	reopened, err := Open(path, 3)
+	if err != nil {
+		t.Fatalf("重新打开排行榜失败: %v", err)
+	}
+	top := reopened.Top(standard)
+	if len(top) != 3 || top[0].Score != 500 || top[1].Name != "乙" || top[2].Score != 300 {
+		t.Errorf("排行榜应保留最好的 3 个成绩，实际为 %+v", top)
+	}
+	if len(reopened.Top(big)) != 1 || len(reopened.Categories()) != 2 {
+		t.Errorf("每个分类应分别保存")
+	}
+	if reopened.LastName() != "丙" {
+		t.Errorf("应记住上一次输入的名字，实际为 %q", reopened.LastName())
+	}
+}
+
+func TestStoreRecoversFromCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "highscores.json")
+	store, _ := Open(path, 10)
+	category := CategoryOf("ultra", game.DefaultGameConfig())
+	store.Add(category, Entry{Name: "甲", Score: 100})
+	store.Add(category, Entry{Name: "甲", Score: 200})
+
+	// 模拟写入中断导致文件被截断
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := Open(path, 10)
+	if err != nil {
+		t.Fatalf("文件损坏时应从备份恢复而不是报错: %v", err)
+	}
+	if !recovered.Recovered() {
+		t.Errorf("应报告文件已损坏")
+	}
+	if top := recovered.Top(category); len(top) != 1 || top[0].Score != 100 {
+		t.Errorf("应从备份恢复上一次保存的排行榜，实际为 %+v", top)
+	}
+	if _, err := os.Stat(path + ".corrupt"); err != nil {
+		t.Errorf("损坏的文件应被保留: %v", err)
+	}
+}
+
+func TestSprintRanksByTime(t *testing.T) {
+	store, _ := Open(filepath.Join(t.TempDir(), "highscores.json"), 10)
+	category := CategoryOf("sprint", game.DefaultGameConfig())
+	store.Add(category, Entry{Name: "甲", Score: 5000, Time: 90000})
+	if rank, _ := store.Add(category, Entry{Name: "乙", Score: 3000, Time: 60000}); rank != 1 {
+		t.Errorf("竞速模式用时更短的成绩应排第 1 名，实际为 %d", rank)
+	}
+}
@@ -0,0 +1,77 @@
+// Package history 提供游戏历史的 JSON 和 CSV 导出
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// SchemaVersion 导出格式的版本。导出的字段只增加不改名，改变已有字段的含义时递增
+const SchemaVersion = 1
+
+// Export 导出的 JSON 文件
+type Export struct {
+	SchemaVersion int      `json:"schema_version"`
+	Profile       string   `json:"profile,omitempty"` // 档案名，不使用档案时为空
+	Games         []Record `json:"games"`
+}
+
+// WriteJSON 以 JSON 格式导出游戏历史
+func WriteJSON(w io.Writer, profile string, records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Export{SchemaVersion: SchemaVersion, Profile: profile, Games: records})
+}
+
+// CSVHeader CSV 导出的表头，与 Record 的 JSON 字段名一致，嵌套的字段用下划线连接
+var CSVHeader = []string{
+	"date", "mode", "rules", "result", "score", "lines", "level", "time_ms", "pieces", "pps",
+	"clears_singles", "clears_doubles", "clears_triples", "clears_tetrises",
+	"clears_tspin_minis", "clears_tspins", "clears_perfect_clears", "clears_back_to_backs",
+	"finesse_pieces", "finesse_faults", "finesse_extra", "replay",
+}
+
+// WriteCSV 以 CSV 格式导出游戏历史，每局一行
+func WriteCSV(w io.Writer, records []Record) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			r.Date.Format(time.RFC3339),
+			r.Mode,
+			r.Rules,
+			r.Result,
+			strconv.Itoa(r.Score),
+			strconv.Itoa(r.Lines),
+			strconv.Itoa(r.Level),
+			strconv.Itoa(r.Time),
+			strconv.Itoa(r.Pieces),
+			strconv.FormatFloat(r.PPS, 'f', 3, 64),
+			strconv.Itoa(r.Clears.Singles),
+			strconv.Itoa(r.Clears.Doubles),
+			strconv.Itoa(r.Clears.Triples),
+			strconv.Itoa(r.Clears.Tetrises),
+			strconv.Itoa(r.Clears.TSpinMinis),
+			strconv.Itoa(r.Clears.TSpins),
+			strconv.Itoa(r.Clears.PerfectClears),
+			strconv.Itoa(r.Clears.BackToBacks),
+			strconv.Itoa(r.Finesse.Pieces),
+			strconv.Itoa(r.Finesse.Faults),
+			strconv.Itoa(r.Finesse.Extra),
+			r.Replay,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
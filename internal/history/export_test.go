@@ -0,0 +1,69 @@
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/highscore"
+)
+
+func TestExportSchemas(t *testing.T) {
+	record := Record{
+		Date:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Category: highscore.CategoryOf("sprint", game.DefaultGameConfig()),
+		Result:   ResultClear,
+		Lines:    40,
+		Time:     95000,
+		Pieces:   100,
+		PPS:      1.0526,
+		Clears:   Clears{Singles: 2, Tetrises: 9, TSpins: 1},
+		Finesse:  Finesse{Pieces: 100, Faults: 3, Extra: 4},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []Record{record}); err != nil {
+		t.Fatalf("导出 CSV 失败: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("CSV 应有表头和 1 行数据: %v %v", rows, err)
+	}
+	row := make(map[string]string)
+	for i, name := range rows[0] {
+		row[name] = rows[1][i]
+	}
+	expected := map[string]string{
+		"date": "2024-01-01T12:00:00Z", "mode": "sprint", "rules": "standard", "result": "clear",
+		"time_ms": "95000", "pps": "1.053", "clears_tetrises": "9", "clears_tspins": "1", "finesse_faults": "3",
+	}
+	for name, value := range expected {
+		if row[name] != value {
+			t.Errorf("CSV 字段 %s 应为 %q，实际为 %q", name, value, row[name])
+		}
+	}
+
+	// JSON 的字段名与 CSV 的表头一致
+	buf.Reset()
+	if err := WriteJSON(&buf, "小明", []Record{record}); err != nil {
+		t.Fatalf("导出 JSON 失败: %v", err)
+	}
+	var exported struct {
+		SchemaVersion int                      `json:"schema_version"`
+		Profile       string                   `json:"profile"`
+		Games         []map[string]interface{} `json:"games"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatalf("解析导出的 JSON 失败: %v", err)
+	}
+	if exported.SchemaVersion != SchemaVersion || exported.Profile != "小明" || len(exported.Games) != 1 {
+		t.Fatalf("导出的 JSON 错误: %s", buf.String())
+	}
+	first := exported.Games[0]
+	if first["mode"] != "sprint" || first["time_ms"] != 95000.0 || first["clears"].(map[string]interface{})["tetrises"] != 9.0 {
+		t.Errorf("导出的 JSON 字段错误: %v", first)
+	}
+}
@@ -0,0 +1,360 @@
+// Package history 实现只追加的本地游戏历史：每局结束后记录一行摘要，用于统计图表和个人最佳的变化
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/highscore"
+	"goeluosifangkuai/internal/userdata"
+	"goeluosifangkuai/pkg/types"
+)
+
+// 一局游戏的结果
+const (
+	ResultClear = "clear" // 通关
+	ResultOver  = "over"  // 堆到顶部
+)
+
+// Record 历史中的一局游戏，每局在文件中占一行
+type Record struct {
+	Date time.Time `json:"date"`
+	highscore.Category
+	Result  string  `json:"result"` // ResultClear 或 ResultOver
+	Score   int     `json:"score"`
+	Lines   int     `json:"lines"`
+	Level   int     `json:"level"`
+	Time    int     `json:"time_ms"` // 游戏时间（毫秒）
+	Pieces  int     `json:"pieces"`  // 放置的方块数
+	PPS     float64 `json:"pps"`     // 每秒放置的方块数
+	Clears  Clears  `json:"clears"`
+	Finesse Finesse `json:"finesse"`
+	Replay  string  `json:"replay,omitempty"`
+}
+
+// Clears 一局游戏中各种消行的次数
+type Clears struct {
+	Singles       int `json:"singles"`
+	Doubles       int `json:"doubles"`
+	Triples       int `json:"triples"`
+	Tetrises      int `json:"tetrises"`
+	TSpinMinis    int `json:"tspin_minis"` // T-spin Mini 的次数，包括不消行的
+	TSpins        int `json:"tspins"`      // T-spin 的次数，包括不消行的
+	PerfectClears int `json:"perfect_clears"`
+	BackToBacks   int `json:"back_to_backs"`
+}
+
+// add 计入一次固定方块的消行
+func (c *Clears) add(clear game.ClearInfo) {
+	switch clear.Lines {
+	case 1:
+		c.Singles++
+	case 2:
+		c.Doubles++
+	case 3:
+		c.Triples++
+	case 4:
+		c.Tetrises++
+	}
+	switch clear.TSpin {
+	case game.TSpinMini:
+		c.TSpinMinis++
+	case game.TSpinFull:
+		c.TSpins++
+	}
+	if clear.PerfectClear {
+		c.PerfectClears++
+	}
+	if clear.BackToBack {
+		c.BackToBacks++
+	}
+}
+
+// Finesse 一局游戏的最简操作统计
+type Finesse struct {
+	Pieces int `json:"pieces"` // 检查过的方块数
+	Faults int `json:"faults"` // 有多余按键的方块数
+	Extra  int `json:"extra"`  // 多余按键的总次数
+}
+
+// Cleared 返回这局游戏是否通关
+func (r Record) Cleared() bool {
+	return r.Result == ResultClear
+}
+
+// Tracker 统计一局游戏中无法从游戏结束时的状态得到的数据：放置的方块数、各种消行和最简操作。
+// 最简操作需要路径搜索，在后台 goroutine 中按落地顺序检查，不占用游戏循环
+type Tracker struct {
+	game      game.Game
+	pieces    int
+	clears    Clears
+	finesse   *finesseCounter
+	onFinesse func(Finesse)
+	startTime int // 开始统计时的游戏时间，继续保存的游戏时之前的方块不计入
+}
+
+// NewTracker 创建统计并订阅游戏的落地事件
+func NewTracker(g game.Game) *Tracker {
+	t := &Tracker{game: g}
+	t.finesse = newFinesseCounter(nil)
+	g.AddEventHandler(t.handleEvent)
+	return t
+}
+
+// SetFinesseHandler 设置最简操作统计更新时的回调，在后台 goroutine 中调用，参数为本局到目前为止的统计
+func (t *Tracker) SetFinesseHandler(handler func(Finesse)) {
+	t.onFinesse = handler
+	t.finesse.setHandler(handler)
+}
+
+// handleEvent 统计落地的方块
+func (t *Tracker) handleEvent(event game.GameEvent) {
+	if event.Type != game.GameEventLock {
+		return
+	}
+	t.pieces++
+	t.clears.add(event.Clear)
+	if placement, ok := t.game.GetLastPlacement(); ok {
+		t.finesse.add(placement)
+	}
+}
+
+// Start 从游戏的当前时间开始重新统计，在开局或继续保存的游戏时调用
+func (t *Tracker) Start() {
+	t.pieces = 0
+	t.clears = Clears{}
+	// 上一局还没检查完的方块不再计入，也不再回调
+	t.finesse.setHandler(nil)
+	t.finesse = newFinesseCounter(t.onFinesse)
+	t.startTime = t.game.GetElapsedTime()
+}
+
+// Record 返回已经结束的游戏的记录，会等待还没检查完的方块
+func (t *Tracker) Record(mode string) Record {
+	record := Record{
+		Date:     time.Now(),
+		Category: highscore.CategoryOf(mode, t.game.GetConfig()),
+		Result:   ResultOver,
+		Score:    t.game.GetScore(),
+		Lines:    t.game.GetLinesCleared(),
+		Level:    t.game.GetLevel(),
+		Time:     t.game.GetElapsedTime(),
+		Pieces:   t.pieces,
+		Clears:   t.clears,
+		Finesse:  t.finesse.wait(),
+	}
+	if t.game.GetState() == types.GameStateGameClear {
+		record.Result = ResultClear
+	}
+	if elapsed := record.Time - t.startTime; elapsed > 0 {
+		record.PPS = float64(t.pieces) * 1000 / float64(elapsed)
+	}
+	return record
+}
+
+// finesseCounter 在后台 goroutine 中按落地顺序检查方块的最简操作并累计统计
+type finesseCounter struct {
+	mu       sync.Mutex
+	idle     *sync.Cond
+	queue    []game.Placement
+	running  bool
+	total    Finesse
+	onUpdate func(Finesse)
+}
+
+// newFinesseCounter 创建最简操作统计，每检查完一个方块调用一次 onUpdate（可以为 nil）
+func newFinesseCounter(onUpdate func(Finesse)) *finesseCounter {
+	c := &finesseCounter{onUpdate: onUpdate}
+	c.idle = sync.NewCond(&c.mu)
+	return c
+}
+
+// setHandler 替换统计更新时的回调
+func (c *finesseCounter) setHandler(onUpdate func(Finesse)) {
+	c.mu.Lock()
+	c.onUpdate = onUpdate
+	c.mu.Unlock()
+}
+
+// add 将放置加入检查队列，没有正在运行的检查时启动一个
+func (c *finesseCounter) add(placement game.Placement) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queue = append(c.queue, placement)
+	if !c.running {
+		c.running = true
+		go c.run()
+	}
+}
+
+// run 依次检查队列中的放置，队列为空时退出
+func (c *finesseCounter) run() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.queue) > 0 {
+		placement := c.queue[0]
+		c.queue = c.queue[1:]
+
+		c.mu.Unlock()
+		result := placement.Finesse()
+		c.mu.Lock()
+
+		c.total.Pieces++
+		if result.Faults > 0 {
+			c.total.Faults++
+			c.total.Extra += result.Faults
+		}
+		if onUpdate := c.onUpdate; onUpdate != nil {
+			total := c.total
+			c.mu.Unlock()
+			onUpdate(total)
+			c.mu.Lock()
+		}
+	}
+	c.running = false
+	c.idle.Broadcast()
+}
+
+// wait 等待队列中的放置全部检查完，返回统计
+func (c *finesseCounter) wait() Finesse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.running {
+		c.idle.Wait()
+	}
+	return c.total
+}
+
+// DefaultPath 返回用户数据目录中的游戏历史文件（不使用玩家档案时）
+func DefaultPath() (string, error) {
+	return userdata.Path(types.HistoryFile)
+}
+
+// Append 将一局游戏追加到历史文件末尾，已有的记录不会被改写
+func Append(path string, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("打开游戏历史失败: %w", err)
+	}
+	// 上次写入时中断留下的不完整的行没有换行符，先补上，避免新记录接在它后面一起被跳过
+	line, err := terminateLine(file)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取游戏历史失败: %w", err)
+	}
+	if _, err := file.Write(append(append(line, data...), '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("写入游戏历史失败: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// terminateLine 文件不为空且最后一个字节不是换行符时返回需要先写入的换行符
+func terminateLine(file *os.File) ([]byte, error) {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return nil, err
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return nil, err
+	}
+	if last[0] == '\n' {
+		return nil, nil
+	}
+	return []byte{'\n'}, nil
+}
+
+// Load 按时间顺序读取历史文件中的所有记录，文件不存在时返回空历史。
+// 无法解析的行（如写入时中断留下的不完整的行）被跳过，skipped 为跳过的行数
+func Load(path string) (records []Record, skipped int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("读取游戏历史失败: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil || record.Mode == "" {
+			skipped++
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("读取游戏历史失败: %w", err)
+	}
+	return records, skipped, nil
+}
+
+// Categories 返回历史中出现过的分类，按第一次出现的顺序排列
+func Categories(records []Record) []highscore.Category {
+	var categories []highscore.Category
+	seen := make(map[highscore.Category]bool)
+	for _, r := range records {
+		if !seen[r.Category] {
+			seen[r.Category] = true
+			categories = append(categories, r.Category)
+		}
+	}
+	return categories
+}
+
+// Filter 返回分类中的记录，保持原来的顺序
+func Filter(records []Record, category highscore.Category) []Record {
+	var filtered []Record
+	for _, r := range records {
+		if r.Category == category {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// PersonalBests 返回分类中每次刷新个人最佳的记录，按时间顺序排列。
+// 按用时排名的分类（竞速）只比较通关的记录，用时短的更好；其他分类分数高的更好
+func PersonalBests(records []Record, category highscore.Category) []Record {
+	byTime := category.RankByTime()
+	var bests []Record
+	for _, r := range Filter(records, category) {
+		if byTime && !r.Cleared() {
+			continue
+		}
+		if len(bests) > 0 {
+			best := bests[len(bests)-1]
+			if byTime && r.Time >= best.Time || !byTime && r.Score <= best.Score {
+				continue
+			}
+		}
+		bests = append(bests, r)
+	}
+	return bests
+}
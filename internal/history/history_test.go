@@ -0,0 +1,114 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/highscore"
+	"goeluosifangkuai/pkg/types"
+)
+
+func TestAppendAndLoadSkipsIncompleteLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	sprint := highscore.CategoryOf("sprint", game.DefaultGameConfig())
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, r := range []Record{
+		{Result: ResultClear, Time: 90000},
+		{Result: ResultOver, Time: 30000},
+		{Result: ResultClear, Time: 95000},
+		{Result: ResultClear, Time: 80000},
+	} {
+		r.Date = date.Add(time.Duration(i) * time.Hour)
+		r.Category = sprint
+		if err := Append(path, r); err != nil {
+			t.Fatalf("追加记录失败: %v", err)
+		}
+	}
+
+	// 模拟写入时中断留下的不完整的行
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"date":"2024-01-02T00:00:00Z","mo`)
+	file.Close()
+
+	records, skipped, err := Load(path)
+	if err != nil {
+		t.Fatalf("读取历史失败: %v", err)
+	}
+	if len(records) != 4 || skipped != 1 {
+		t.Fatalf("应读取 4 条记录并跳过 1 行，实际为 %d 条、跳过 %d 行", len(records), skipped)
+	}
+	if records[0].Category != sprint || !records[0].Date.Equal(date) {
+		t.Errorf("记录读取错误: %+v", records[0])
+	}
+
+	// 竞速只比较通关的记录，用时短的更好
+	bests := PersonalBests(records, sprint)
+	if len(bests) != 2 || bests[0].Time != 90000 || bests[1].Time != 80000 {
+		t.Errorf("个人最佳的变化错误: %+v", bests)
+	}
+}
+
+func TestAppendAfterIncompleteLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte(`{"date":"2024-01-02T00:00:00Z","mo`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新记录应从新的一行开始，不能和不完整的行一起被跳过
+	record := Record{Category: highscore.CategoryOf("marathon", game.DefaultGameConfig()), Result: ResultClear, Score: 100}
+	if err := Append(path, record); err != nil {
+		t.Fatalf("追加记录失败: %v", err)
+	}
+	records, skipped, err := Load(path)
+	if err != nil {
+		t.Fatalf("读取历史失败: %v", err)
+	}
+	if len(records) != 1 || skipped != 1 || records[0].Score != 100 {
+		t.Errorf("应读取 1 条记录并跳过 1 行，实际为 %+v、跳过 %d 行", records, skipped)
+	}
+}
+
+func TestPersonalBestsByScore(t *testing.T) {
+	marathon := highscore.CategoryOf("marathon", game.DefaultGameConfig())
+	big := highscore.CategoryOf("marathon", game.BigGameConfig(game.DefaultGameConfig()))
+	var records []Record
+	for _, score := range []int{300, 100, 500, 500, 800} {
+		records = append(records, Record{Category: marathon, Score: score})
+	}
+	records = append(records, Record{Category: big, Score: 9999})
+
+	bests := PersonalBests(records, marathon)
+	if len(bests) != 3 || bests[0].Score != 300 || bests[1].Score != 500 || bests[2].Score != 800 {
+		t.Errorf("个人最佳的变化错误: %+v", bests)
+	}
+	if categories := Categories(records); len(categories) != 2 {
+		t.Errorf("应有 2 个分类，实际为 %v", categories)
+	}
+}
+
+func TestTrackerCountsPieces(t *testing.T) {
+	config := game.DefaultGameConfig()
+	config.Seed = 1
+	g := game.NewGameWithMode(config, game.NewSprintMode(0))
+	tracker := NewTracker(g)
+	g.SetState(types.GameStatePlaying)
+	tracker.Start()
+
+	for i := 0; i < 5; i++ {
+		g.Update(500)
+		g.ApplyInput(types.InputHardDrop)
+	}
+	record := tracker.Record("sprint")
+	if record.Pieces != 5 || record.Finesse.Pieces != 5 {
+		t.Errorf("应统计 5 个方块，实际为 %d（最简操作检查了 %d 个）", record.Pieces, record.Finesse.Pieces)
+	}
+	if record.Mode != "sprint" || record.Result != ResultOver || record.PPS <= 0 {
+		t.Errorf("记录错误: %+v", record)
+	}
+}
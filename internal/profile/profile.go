@@ -0,0 +1,280 @@
+// Package profile 实现玩家档案：每个玩家各自的按键、操作手感、主题、排行榜和累计统计
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/userdata"
+	"goeluosifangkuai/pkg/types"
+)
+
+// FormatVersion 档案文件格式的版本，格式改变时递增
+const FormatVersion = 1
+
+// 可以绑定按键的操作
+const (
+	ActionLeft        = "left"         // 向左移动
+	ActionRight       = "right"        // 向右移动
+	ActionSoftDrop    = "soft_drop"    // 向下移动
+	ActionRotateRight = "rotate_right" // 顺时针旋转
+	ActionRotateLeft  = "rotate_left"  // 逆时针旋转
+	ActionHold        = "hold"         // 暂存
+	ActionHardDrop    = "hard_drop"    // 快速下降
+	ActionUndo        = "undo"         // 撤销
+	ActionRedo        = "redo"         // 重做
+	ActionPause       = "pause"        // 暂停/继续
+	ActionHint        = "hint"         // 显示/隐藏提示
+)
+
+// Action 可以绑定按键的操作
+type Action struct {
+	ID   string
+	Name string // 显示名称
+}
+
+// Actions 返回所有可以绑定按键的操作，按界面中的显示顺序排列
+func Actions() []Action {
+	return []Action{
+		{ActionLeft, "向左移动"},
+		{ActionRight, "向右移动"},
+		{ActionSoftDrop, "向下移动"},
+		{ActionRotateRight, "顺时针旋转"},
+		{ActionRotateLeft, "逆时针旋转"},
+		{ActionHold, "暂存"},
+		{ActionHardDrop, "快速下降"},
+		{ActionUndo, "撤销"},
+		{ActionRedo, "重做"},
+		{ActionPause, "暂停/继续"},
+		{ActionHint, "提示"},
+	}
+}
+
+// KeyBindings 操作到按键名称的映射，按键名称与 Fyne 的 KeyName 相同（如 "A"、"Space"、"Left"）
+type KeyBindings map[string]string
+
+// DefaultKeyBindings 返回默认按键
+func DefaultKeyBindings() KeyBindings {
+	return KeyBindings{
+		ActionLeft:        "A",
+		ActionRight:       "D",
+		ActionSoftDrop:    "S",
+		ActionRotateRight: "W",
+		ActionRotateLeft:  "Q",
+		ActionHold:        "C",
+		ActionHardDrop:    "Space",
+		ActionUndo:        "Z",
+		ActionRedo:        "Y",
+		ActionPause:       "P",
+		ActionHint:        "H",
+	}
+}
+
+// Validate 检查每个操作都有按键且按键不重复
+func (k KeyBindings) Validate() error {
+	used := make(map[string]string)
+	for _, action := range Actions() {
+		key := k[action.ID]
+		if key == "" {
+			return fmt.Errorf("%s没有设置按键", action.Name)
+		}
+		if other, ok := used[key]; ok {
+			return fmt.Errorf("%s和%s使用了同一个按键 %s", other, action.Name, key)
+		}
+		used[key] = action.Name
+	}
+	return nil
+}
+
+// 可选的主题
+const (
+	ThemeSystem = "system" // 跟随系统
+	ThemeDark   = "dark"   // 深色
+	ThemeLight  = "light"  // 浅色
+)
+
+// Settings 档案的设置
+type Settings struct {
+	Keys     KeyBindings    `json:"keys"`
+	Handling *game.Handling `json:"handling,omitempty"` // 为 nil 时使用模式的 DAS 和 ARR
+	Theme    string         `json:"theme"`
+}
+
+// DefaultSettings 返回新档案的默认设置
+func DefaultSettings() Settings {
+	return Settings{Keys: DefaultKeyBindings(), Theme: ThemeSystem}
+}
+
+// Stats 档案的累计统计
+type Stats struct {
+	GamesPlayed int `json:"games_played"`
+	TotalLines  int `json:"total_lines"`
+	TimePlayed  int `json:"time_played_ms"`
+	BestSprint  int `json:"best_sprint_ms"` // 竞速模式通关的最短用时，0 表示还没有通关过
+}
+
+// Record 将一局结束的游戏计入统计
+func (s *Stats) Record(mode string, state types.GameState, lines, elapsed int) {
+	s.GamesPlayed++
+	s.TotalLines += lines
+	s.TimePlayed += elapsed
+	if mode == "sprint" && state == types.GameStateGameClear && (s.BestSprint == 0 || elapsed < s.BestSprint) {
+		s.BestSprint = elapsed
+	}
+}
+
+// Profile 一个玩家档案，保存在档案目录下以档案名命名的子目录中
+type Profile struct {
+	Version  int       `json:"version"`
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"last_used"`
+	Settings Settings  `json:"settings"`
+	Stats    Stats     `json:"stats"`
+
+	dir       string
+	recovered bool
+}
+
+// profileFile 档案目录中保存设置和统计的文件
+const profileFile = "profile.json"
+
+// Root 返回用户数据目录中的档案目录
+func Root() (string, error) {
+	return userdata.Path(types.ProfileDir)
+}
+
+// dirName 将档案名转换为可以用作目录名的字符串
+func dirName(name string) string {
+	replacer := strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+	return strings.TrimLeft(replacer.Replace(name), ".")
+}
+
+// Create 在档案目录中创建新档案，同名档案已经存在时返回错误
+func Create(root, name string) (*Profile, error) {
+	name = strings.TrimSpace(name)
+	dir := dirName(name)
+	if dir == "" {
+		return nil, fmt.Errorf("档案名不能为空")
+	}
+	dir = filepath.Join(root, dir)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("档案 %s 已经存在", name)
+	}
+
+	now := time.Now()
+	profile := &Profile{
+		Version:  FormatVersion,
+		Name:     name,
+		Created:  now,
+		LastUsed: now,
+		Settings: DefaultSettings(),
+		dir:      dir,
+	}
+	if err := profile.Save(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Load 加载档案目录中的一个档案，文件损坏时从备份恢复
+func Load(dir string) (*Profile, error) {
+	profile := &Profile{dir: dir}
+	recovered, err := userdata.ReadJSON(filepath.Join(dir, profileFile), profile)
+	if err != nil {
+		return nil, fmt.Errorf("读取档案失败: %w", err)
+	}
+	if profile.Name == "" {
+		return nil, fmt.Errorf("%s 不是有效的档案", dir)
+	}
+	if profile.Version > FormatVersion {
+		return nil, fmt.Errorf("不支持的档案版本: %d", profile.Version)
+	}
+
+	// 补全旧档案中没有的设置
+	defaults := DefaultSettings()
+	if profile.Settings.Keys == nil {
+		profile.Settings.Keys = defaults.Keys
+	}
+	for action, key := range defaults.Keys {
+		if profile.Settings.Keys[action] == "" {
+			profile.Settings.Keys[action] = key
+		}
+	}
+	if profile.Settings.Theme == "" {
+		profile.Settings.Theme = defaults.Theme
+	}
+	profile.recovered = recovered
+	return profile, nil
+}
+
+// List 加载档案目录中的所有档案，最近使用的在前，无法读取的档案被跳过
+func List(root string) ([]*Profile, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var profiles []*Profile
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if profile, err := Load(filepath.Join(root, entry.Name())); err == nil {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profiles[i].LastUsed.After(profiles[j].LastUsed)
+	})
+	return profiles, nil
+}
+
+// Save 将档案写入档案目录
+func (p *Profile) Save() error {
+	p.Version = FormatVersion
+	if err := userdata.WriteJSON(filepath.Join(p.dir, profileFile), p); err != nil {
+		return fmt.Errorf("保存档案失败: %w", err)
+	}
+	return nil
+}
+
+// Use 记录档案被选用的时间并保存，档案列表中最近使用的排在最前
+func (p *Profile) Use() error {
+	p.LastUsed = time.Now()
+	return p.Save()
+}
+
+// Dir 返回档案目录
+func (p *Profile) Dir() string {
+	return p.dir
+}
+
+// Recovered 返回加载时档案文件是否损坏并从备份恢复
+func (p *Profile) Recovered() bool {
+	return p.recovered
+}
+
+// HighScorePath 返回档案的排行榜文件
+func (p *Profile) HighScorePath() string {
+	return filepath.Join(p.dir, types.HighScoreFile)
+}
+
+// SaveGamePath 返回档案的未完成游戏存档
+func (p *Profile) SaveGamePath() string {
+	return filepath.Join(p.dir, types.SaveGameFile)
+}
+
+// HistoryPath 返回档案的游戏历史文件
+func (p *Profile) HistoryPath() string {
+	return filepath.Join(p.dir, types.HistoryFile)
+}
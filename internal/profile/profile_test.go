@@ -0,0 +1,56 @@
+package profile
+
+import (
+	"testing"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+func TestCreateAndListProfiles(t *testing.T) {
+	root := t.TempDir()
+	first, err := Create(root, "小明")
+	if err != nil {
+		t.Fatalf("创建档案失败: %v", err)
+	}
+	if _, err := Create(root, "小明"); err == nil {
+		t.Errorf("同名档案不应重复创建")
+	}
+	if _, err := Create(root, "a/b"); err != nil {
+		t.Errorf("档案名中的路径分隔符应被替换: %v", err)
+	}
+
+	first.Stats.Record("sprint", types.GameStateGameClear, 40, 95000)
+	first.Stats.Record("sprint", types.GameStateGameOver, 12, 30000)
+	first.Stats.Record("marathon", types.GameStateGameOver, 30, 120000)
+	first.Settings.Keys[ActionHardDrop] = "Up"
+	if err := first.Use(); err != nil {
+		t.Fatalf("保存档案失败: %v", err)
+	}
+
+	profiles, err := List(root)
+	if err != nil || len(profiles) != 2 {
+		t.Fatalf("应列出 2 个档案，实际为 %d 个（%v）", len(profiles), err)
+	}
+	loaded := profiles[0]
+	if loaded.Name != "小明" {
+		t.Errorf("最近使用的档案应排在最前，实际为 %q", loaded.Name)
+	}
+	stats := loaded.Stats
+	if stats.GamesPlayed != 3 || stats.TotalLines != 82 || stats.TimePlayed != 245000 || stats.BestSprint != 95000 {
+		t.Errorf("累计统计错误: %+v", stats)
+	}
+	if loaded.Settings.Keys[ActionHardDrop] != "Up" {
+		t.Errorf("应保存档案自己的按键")
+	}
+}
+
+func TestKeyBindingsRejectDuplicateKeys(t *testing.T) {
+	keys := DefaultKeyBindings()
+	if err := keys.Validate(); err != nil {
+		t.Errorf("默认按键应有效: %v", err)
+	}
+	keys[ActionHold] = keys[ActionLeft]
+	if err := keys.Validate(); err == nil {
+		t.Errorf("两个操作使用同一个按键时应报错")
+	}
+}
@@ -0,0 +1,136 @@
+// Package replay 实现录像文件的读写
+package replay
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/userdata"
+	"goeluosifangkuai/pkg/types"
+)
+
+// replayFile 录像文件的 JSON 格式，整个文件经过 gzip 压缩。
+// 操作记录为 [与上一个操作相隔的帧数, 操作] 的数组，相邻操作的帧数差通常很小
+type replayFile struct {
+	Version       int             `json:"version"`
+	EngineVersion int             `json:"engine_version"`
+	Mode          string          `json:"mode"`
+	Config        game.GameConfig `json:"config"`
+	Date          time.Time       `json:"date"`
+	Inputs        [][2]int        `json:"inputs"`
+	Result        Result          `json:"result"`
+}
+
+// Write 将录像写入 w
+func Write(w io.Writer, replay *Replay) error {
+	file := replayFile{
+		Version:       replay.Version,
+		EngineVersion: replay.EngineVersion,
+		Mode:          replay.Mode,
+		Config:        replay.Config,
+		Date:          replay.Date,
+		Inputs:        make([][2]int, len(replay.Inputs)),
+		Result:        replay.Result,
+	}
+	frame := 0
+	for i, input := range replay.Inputs {
+		file.Inputs[i] = [2]int{input.Frame - frame, int(input.Input)}
+		frame = input.Frame
+	}
+
+	compressed := gzip.NewWriter(w)
+	if err := json.NewEncoder(compressed).Encode(file); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// Read 从 r 读取录像
+func Read(r io.Reader) (*Replay, error) {
+	compressed, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("录像文件格式错误: %w", err)
+	}
+	defer compressed.Close()
+
+	var file replayFile
+	if err := json.NewDecoder(compressed).Decode(&file); err != nil {
+		return nil, fmt.Errorf("解析录像失败: %w", err)
+	}
+	if file.Version < 1 || file.Version > FormatVersion {
+		return nil, fmt.Errorf("不支持的录像文件版本: %d", file.Version)
+	}
+
+	replay := &Replay{
+		Version:       file.Version,
+		EngineVersion: file.EngineVersion,
+		Mode:          file.Mode,
+		Config:        file.Config,
+		Date:          file.Date,
+		Inputs:        make([]Input, len(file.Inputs)),
+		Result:        file.Result,
+	}
+	frame := 0
+	for i, input := range file.Inputs {
+		if input[0] < 0 {
+			return nil, fmt.Errorf("录像中第 %d 个操作的帧数错误", i+1)
+		}
+		frame += input[0]
+		replay.Inputs[i] = Input{Frame: frame, Input: types.Input(input[1])}
+	}
+	return replay, nil
+}
+
+// Save 将录像保存到文件，目录不存在时自动创建
+func Save(path string, replay *Replay) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, replay); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Load 从文件加载录像
+func Load(path string) (*Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取录像文件失败: %w", err)
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// DefaultDir 返回用户数据目录中的录像目录。返回绝对路径，保存在排行榜和游戏历史中的录像路径与工作目录无关
+func DefaultDir() (string, error) {
+	return userdata.Path(types.ReplayDir)
+}
+
+// FileName 返回录像的默认文件名：录制时间和模式
+func FileName(replay *Replay) string {
+	return replay.Date.Format("20060102-150405") + "_" + replay.Mode + types.ReplayExt
+}
+
+// List 返回目录中的所有录像文件，最近录制的在前
+func List(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+types.ReplayExt))
+	if err != nil {
+		return nil, err
+	}
+	// 文件名以录制时间开头，按名称倒序即按时间倒序
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
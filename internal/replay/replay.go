@@ -0,0 +1,281 @@
+// Package replay 实现游戏录像的录制、保存和重现
+package replay
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// FormatVersion 录像文件格式的版本，格式改变时递增
+const FormatVersion = 1
+
+// Input 一次操作及其发生时的逻辑帧
+type Input struct {
+	Frame int
+	Input types.Input
+}
+
+// Result 录像结束时的游戏结果，用于校验重现是否一致
+type Result struct {
+	Frames    int             `json:"frames"`     // 结束时的逻辑帧
+	State     types.GameState `json:"state"`      // 结束时的游戏状态
+	Score     int             `json:"score"`      // 最终分数
+	Lines     int             `json:"lines"`      // 消除的行数
+	BoardHash string          `json:"board_hash"` // 最终棋盘的哈希
+}
+
+// Replay 一局游戏的录像：重现所需的配置、模式和按帧记录的全部操作
+type Replay struct {
+	Version       int             // 文件格式版本
+	EngineVersion int             // 录制时的游戏逻辑版本
+	Mode          string          // 游戏模式标识，见 game.Modes
+	Config        game.GameConfig // 完整的游戏配置，包括种子
+	Date          time.Time       // 录制开始的时间
+	Inputs        []Input         // 按帧排列的操作
+	Result        Result          // 录像结束时的游戏结果
+}
+
+// Recorder 订阅游戏的操作事件并录制录像
+type Recorder struct {
+	game      game.Game
+	mode      string
+	replay    *Replay
+	recording bool
+}
+
+// NewRecorder 为游戏创建录像机，mode 为游戏模式的标识
+func NewRecorder(g game.Game, mode string) *Recorder {
+	recorder := &Recorder{game: g, mode: mode}
+	g.AddEventHandler(recorder.handleEvent)
+	return recorder
+}
+
+// Start 从游戏当前的状态开始录制，应在游戏创建或重置后、推进任何一帧之前调用
+func (r *Recorder) Start() {
+	r.replay = &Replay{
+		Version:       FormatVersion,
+		EngineVersion: game.EngineVersion,
+		Mode:          r.mode,
+		Config:        r.game.GetConfig(),
+		Date:          time.Now(),
+	}
+	// 标准方块集不需要保存完整定义
+	if r.replay.Config.PieceSet == game.StandardPieceSet() {
+		r.replay.Config.PieceSet = nil
+	}
+	r.recording = true
+}
+
+// Stop 结束录制并返回录像，没有在录制时返回 nil
+func (r *Recorder) Stop() *Replay {
+	if !r.recording {
+		return nil
+	}
+	r.recording = false
+	r.replay.Result = resultOf(r.game)
+	return r.replay
+}
+
+// Snapshot 返回到目前为止的录像副本，结果为游戏当前的状态，没有在录制时返回 nil
+func (r *Recorder) Snapshot() *Replay {
+	if !r.recording {
+		return nil
+	}
+	snapshot := *r.replay
+	snapshot.Inputs = append([]Input(nil), r.replay.Inputs...)
+	snapshot.Result = resultOf(r.game)
+	return &snapshot
+}
+
+// Resume 接着录制保存的游戏，replay 为保存时 Snapshot 返回的录像，游戏应已恢复到保存时的状态
+func (r *Recorder) Resume(replay *Replay) {
+	r.replay = replay
+	r.replay.Result = Result{}
+	r.recording = true
+}
+
+// IsRecording 返回是否正在录制
+func (r *Recorder) IsRecording() bool {
+	return r.recording
+}
+
+// handleEvent 记录操作事件
+func (r *Recorder) handleEvent(event game.GameEvent) {
+	if r.recording && event.Type == game.GameEventInput {
+		r.replay.Inputs = append(r.replay.Inputs, Input{Frame: event.Frame, Input: event.Input})
+	}
+}
+
+// resultOf 返回游戏当前的结果
+func resultOf(g game.Game) Result {
+	return Result{
+		Frames:    g.GetFrame(),
+		State:     g.GetState(),
+		Score:     g.GetScore(),
+		Lines:     g.GetLinesCleared(),
+		BoardHash: BoardHash(g.GetBoard()),
+	}
+}
+
+// BoardHash 返回棋盘尺寸和所有格子颜色的 FNV-1a 哈希
+func BoardHash(board game.Board) string {
+	hash := fnv.New64a()
+	write := func(value int) {
+		hash.Write([]byte{byte(value), byte(value >> 8)})
+	}
+	write(board.GetWidth())
+	write(board.GetHeight())
+	for y := 0; y < board.GetHeight(); y++ {
+		for x := 0; x < board.GetWidth(); x++ {
+			write(int(board.GetCell(x, y)))
+		}
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// KeyframeInterval 重现时每隔多少帧保存一次完整的游戏状态，用于快速跳转
+const KeyframeInterval = 600
+
+// keyframe 某一帧执行操作之前的完整游戏状态
+type keyframe struct {
+	state *game.State
+	next  int // 此时下一个要执行的操作
+}
+
+// Player 按录像逐帧重现游戏。重现过程中每隔 KeyframeInterval 帧保存一次状态，
+// 向回跳转时从不晚于目标的最近一个状态继续重现
+type Player struct {
+	replay    *Replay
+	game      game.Game
+	next      int // 下一个要执行的操作
+	keyframes []keyframe
+	interval  int // 保存状态的间隔（帧）
+}
+
+// NewPlayer 按录像的配置和模式创建游戏，准备从第 0 帧开始重现
+func NewPlayer(replay *Replay) (*Player, error) {
+	if replay.Version > FormatVersion {
+		return nil, fmt.Errorf("录像文件版本 %d 高于支持的版本 %d", replay.Version, FormatVersion)
+	}
+	if replay.EngineVersion != game.EngineVersion {
+		return nil, fmt.Errorf("录像由第 %d 版游戏逻辑录制，当前为第 %d 版，无法重现", replay.EngineVersion, game.EngineVersion)
+	}
+	info, ok := game.FindMode(replay.Mode)
+	if !ok {
+		return nil, fmt.Errorf("录像使用了未知的游戏模式: %s", replay.Mode)
+	}
+	if replay.Config.Seed == 0 {
+		return nil, fmt.Errorf("录像缺少随机种子")
+	}
+
+	g := game.NewGameWithMode(replay.Config, info.Create())
+	g.SetState(types.GameStatePlaying)
+	return &Player{replay: replay, game: g, interval: KeyframeInterval}, nil
+}
+
+// Game 返回重现中的游戏
+func (p *Player) Game() game.Game {
+	return p.game
+}
+
+// Frame 返回当前的逻辑帧
+func (p *Player) Frame() int {
+	return p.game.GetFrame()
+}
+
+// Length 返回录像的总帧数
+func (p *Player) Length() int {
+	return p.replay.Result.Frames
+}
+
+// Finished 判断是否已经重现到录像的结尾
+func (p *Player) Finished() bool {
+	return p.game.GetFrame() >= p.replay.Result.Frames || p.game.GetState() != types.GameStatePlaying
+}
+
+// Step 执行当前帧的所有操作后推进一帧；到达录像结尾时只执行操作，返回 false
+func (p *Player) Step() bool {
+	p.saveKeyframe()
+	p.applyInputs()
+	if p.Finished() {
+		return false
+	}
+	p.game.Update(types.FrameDuration)
+	return true
+}
+
+// Seek 跳转到指定的帧，停在执行该帧的操作之前（结尾除外）。超出录像范围时跳转到开头或结尾
+func (p *Player) Seek(frame int) error {
+	if frame < 0 {
+		frame = 0
+	}
+	if frame > p.Length() {
+		frame = p.Length()
+	}
+
+	// 目标在当前帧之前，或者中间有已经保存的状态时，从最近的状态继续
+	index := frame / p.interval
+	if index >= len(p.keyframes) {
+		index = len(p.keyframes) - 1
+	}
+	if index >= 0 && (frame < p.Frame() || index*p.interval > p.Frame()) {
+		if err := p.game.LoadState(p.keyframes[index].state); err != nil {
+			return fmt.Errorf("恢复第 %d 帧的状态失败: %w", index*p.interval, err)
+		}
+		p.next = p.keyframes[index].next
+	}
+
+	for p.Frame() < frame && p.Step() {
+	}
+	// 跳转到结尾时与 Verify 一样执行最后一帧的操作
+	if p.Finished() {
+		p.Step()
+	}
+	return nil
+}
+
+// saveKeyframe 当前帧是保存间隔的整数倍且还没有保存过时保存游戏状态
+func (p *Player) saveKeyframe() {
+	frame := p.Frame()
+	if frame%p.interval != 0 || frame/p.interval != len(p.keyframes) {
+		return
+	}
+	state, err := p.game.SaveState()
+	if err != nil {
+		// 无法保存时只是不能快速跳转，重现本身不受影响
+		return
+	}
+	p.keyframes = append(p.keyframes, keyframe{state: state, next: p.next})
+}
+
+// applyInputs 执行发生在当前帧的所有操作
+func (p *Player) applyInputs() {
+	frame := p.game.GetFrame()
+	for p.next < len(p.replay.Inputs) && p.replay.Inputs[p.next].Frame <= frame {
+		p.game.ApplyInput(p.replay.Inputs[p.next].Input)
+		p.next++
+	}
+}
+
+// Verify 从头重现录像，检查结束时的帧数、分数、行数和棋盘是否与录制时一致
+func Verify(replay *Replay) error {
+	player, err := NewPlayer(replay)
+	if err != nil {
+		return err
+	}
+	for player.Step() {
+	}
+
+	expected := replay.Result
+	actual := resultOf(player.game)
+	if actual != expected {
+		return fmt.Errorf("重现结果与录像不一致: 录制时为第 %d 帧 %d 分 %d 行（棋盘 %s），重现为第 %d 帧 %d 分 %d 行（棋盘 %s）",
+			expected.Frames, expected.Score, expected.Lines, expected.BoardHash,
+			actual.Frames, actual.Score, actual.Lines, actual.BoardHash)
+	}
+	return nil
+}
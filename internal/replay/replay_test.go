@@ -0,0 +1,150 @@
+package replay
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"goeluosifangkuai/internal/ai"
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// recordGame 由电脑玩家和随机的按住移动进行一局游戏，不定长地推进时间，返回录像
+func recordGame(t *testing.T, mode string, config game.GameConfig) *Replay {
+	t.Helper()
+	info, ok := game.FindMode(mode)
+	if !ok {
+		t.Fatalf("未知的游戏模式: %s", mode)
+	}
+
+	g := game.NewGameWithMode(config, info.Create())
+	recorder := NewRecorder(g, mode)
+	g.SetState(types.GameStatePlaying)
+	recorder.Start()
+
+	player := ai.NewPlayer(ai.Config{Weights: ai.DefaultWeights()})
+	random := rand.New(rand.NewSource(7))
+	for pieces := 0; pieces < 40 && g.GetState() == types.GameStatePlaying; {
+		g.Update(random.Intn(100))
+		if g.GetCurrentTetromino() == nil {
+			continue
+		}
+
+		// 偶尔按住移动一段时间，让自动重复移动也进入录像
+		if random.Intn(4) == 0 {
+			g.ApplyInput(types.InputShiftLeft)
+			g.Update(random.Intn(300))
+			g.ApplyInput(types.InputReleaseLeft)
+			continue
+		}
+		if g.GetCurrentTetromino() != nil && player.Play(g) {
+			pieces++
+		}
+	}
+
+	return recorder.Stop()
+}
+
+func TestReplayVerifiesAfterSaveAndLoad(t *testing.T) {
+	config := game.DefaultGameConfig()
+	config.Seed = 42
+	recorded := recordGame(t, "marathon", config)
+	if len(recorded.Inputs) == 0 || recorded.Result.Lines == 0 {
+		t.Fatalf("录像应包含操作和消行，实际为 %d 个操作 %d 行", len(recorded.Inputs), recorded.Result.Lines)
+	}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, recorded); err != nil {
+		t.Fatalf("写入录像失败: %v", err)
+	}
+	loaded, err := Read(&buffer)
+	if err != nil {
+		t.Fatalf("读取录像失败: %v", err)
+	}
+	if len(loaded.Inputs) != len(recorded.Inputs) || loaded.Inputs[len(loaded.Inputs)-1] != recorded.Inputs[len(recorded.Inputs)-1] {
+		t.Errorf("读取的操作与录制的不一致")
+	}
+
+	if err := Verify(loaded); err != nil {
+		t.Errorf("录像应能一致地重现: %v", err)
+	}
+
+	loaded.Result.Score++
+	if err := Verify(loaded); err == nil {
+		t.Errorf("结果被修改后校验应失败")
+	}
+}
+
+func TestReplayKeepsCustomPieceSet(t *testing.T) {
+	set, err := game.LoadPieceSet("../../pieces/pentomino.json")
+	if err != nil {
+		t.Fatalf("加载方块集失败: %v", err)
+	}
+	config := game.DefaultGameConfig()
+	config.Seed = 3
+	config.PieceSet = set
+	recorded := recordGame(t, "classic", config)
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, recorded); err != nil {
+		t.Fatalf("写入录像失败: %v", err)
+	}
+	loaded, err := Read(&buffer)
+	if err != nil {
+		t.Fatalf("读取录像失败: %v", err)
+	}
+	if loaded.Config.PieceSet == nil || loaded.Config.PieceSet.Name != set.Name {
+		t.Fatalf("录像应保存自定义方块集")
+	}
+	if err := Verify(loaded); err != nil {
+		t.Errorf("使用自定义方块集的录像应能一致地重现: %v", err)
+	}
+}
+
+func TestPlayerSeekMatchesSequentialPlayback(t *testing.T) {
+	config := game.DefaultGameConfig()
+	config.Seed = 5
+	recorded := recordGame(t, "marathon", config)
+	// 录像只有几秒，缩短保存状态的间隔以跨过多个状态
+	const interval = 40
+	if recorded.Result.Frames < 3*interval {
+		t.Fatalf("录像太短，无法测试跳转: %d 帧", recorded.Result.Frames)
+	}
+
+	// 逐帧重现到目标帧的结果作为基准
+	target := recorded.Result.Frames * 2 / 3
+	sequential, err := NewPlayer(recorded)
+	if err != nil {
+		t.Fatalf("创建重现失败: %v", err)
+	}
+	for sequential.Frame() < target && sequential.Step() {
+	}
+	expected := resultOf(sequential.Game())
+
+	// 先跳到结尾，再向回跳转到目标帧
+	player, err := NewPlayer(recorded)
+	if err != nil {
+		t.Fatalf("创建重现失败: %v", err)
+	}
+	player.interval = interval
+	if err := player.Seek(player.Length()); err != nil {
+		t.Fatalf("跳转到结尾失败: %v", err)
+	}
+	if resultOf(player.Game()) != recorded.Result {
+		t.Errorf("跳转到结尾后的结果应与录像一致")
+	}
+	if err := player.Seek(target); err != nil {
+		t.Fatalf("向回跳转失败: %v", err)
+	}
+	if actual := resultOf(player.Game()); actual != expected {
+		t.Errorf("向回跳转后应与逐帧重现一致，期望 %+v，实际 %+v", expected, actual)
+	}
+
+	// 跳转后继续播放到结尾，结果仍与录像一致
+	for player.Step() {
+	}
+	if resultOf(player.Game()) != recorded.Result {
+		t.Errorf("跳转后继续播放的结果应与录像一致")
+	}
+}
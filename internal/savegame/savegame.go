@@ -0,0 +1,122 @@
+// Package savegame 实现未完成游戏的保存和继续
+package savegame
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/replay"
+	"goeluosifangkuai/internal/userdata"
+	"goeluosifangkuai/pkg/types"
+)
+
+// FormatVersion 存档文件格式的版本，格式改变时递增
+const FormatVersion = 1
+
+// SaveGame 一局未完成的游戏：模式、完整的游戏状态和到保存时为止的录像
+type SaveGame struct {
+	Version int         `json:"version"`
+	Mode    string      `json:"mode"` // 游戏模式标识，见 game.Modes
+	Date    time.Time   `json:"date"` // 保存的时间
+	State   *game.State `json:"state"`
+	Replay  []byte      `json:"replay,omitempty"` // 录像文件格式的录像，没有录制时为空
+}
+
+// New 保存游戏当前的状态，recorder 不为 nil 且正在录制时一起保存录像
+func New(g game.Game, mode string, recorder *replay.Recorder) (*SaveGame, error) {
+	if _, ok := game.FindMode(mode); !ok {
+		return nil, fmt.Errorf("该模式不支持保存: %q", mode)
+	}
+	state, err := g.SaveState()
+	if err != nil {
+		return nil, err
+	}
+
+	save := &SaveGame{
+		Version: FormatVersion,
+		Mode:    mode,
+		Date:    time.Now(),
+		State:   state,
+	}
+	if recorder != nil {
+		if recorded := recorder.Snapshot(); recorded != nil {
+			var buffer bytes.Buffer
+			if err := replay.Write(&buffer, recorded); err != nil {
+				return nil, fmt.Errorf("保存录像失败: %w", err)
+			}
+			save.Replay = buffer.Bytes()
+		}
+	}
+	return save, nil
+}
+
+// Restore 按存档的模式创建游戏并恢复状态，存档中有录像时一起返回
+func (s *SaveGame) Restore() (game.Game, *replay.Replay, error) {
+	info, ok := game.FindMode(s.Mode)
+	if !ok {
+		return nil, nil, fmt.Errorf("存档使用了未知的游戏模式: %s", s.Mode)
+	}
+	if s.State == nil {
+		return nil, nil, fmt.Errorf("存档缺少游戏状态")
+	}
+
+	g := game.NewGameWithMode(s.State.Config, info.Create())
+	if err := g.LoadState(s.State); err != nil {
+		return nil, nil, err
+	}
+
+	var recorded *replay.Replay
+	if len(s.Replay) > 0 {
+		var err error
+		if recorded, err = replay.Read(bytes.NewReader(s.Replay)); err != nil {
+			return nil, nil, err
+		}
+	}
+	return g, recorded, nil
+}
+
+// DefaultPath 返回用户数据目录中的存档文件（不使用玩家档案时）
+func DefaultPath() (string, error) {
+	return userdata.Path(types.SaveGameFile)
+}
+
+// Save 将存档写入文件。先写入临时文件并同步到磁盘再替换，写入中断时不会损坏原有的存档
+func Save(path string, save *SaveGame) error {
+	data, err := json.Marshal(save)
+	if err != nil {
+		return err
+	}
+	return userdata.WriteFile(path, data)
+}
+
+// Load 从文件加载存档
+func Load(path string) (*SaveGame, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取存档失败: %w", err)
+	}
+
+	var save SaveGame
+	if err := json.Unmarshal(data, &save); err != nil {
+		return nil, fmt.Errorf("解析存档失败: %w", err)
+	}
+	if save.Version < 1 || save.Version > FormatVersion {
+		return nil, fmt.Errorf("不支持的存档版本: %d", save.Version)
+	}
+	return &save, nil
+}
+
+// Exists 判断存档文件是否存在
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Remove 删除存档文件及其备份，文件不存在时不报错
+func Remove(path string) error {
+	return userdata.Remove(path)
+}
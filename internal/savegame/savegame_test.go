@@ -0,0 +1,78 @@
+package savegame
+
+import (
+	"path/filepath"
+	"testing"
+
+	"goeluosifangkuai/internal/ai"
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/internal/replay"
+	"goeluosifangkuai/pkg/types"
+)
+
+// playPieces 由电脑玩家放置若干个方块，每个方块之后推进一段时间
+func playPieces(g game.Game, pieces int) {
+	player := ai.NewPlayer(ai.Config{Weights: ai.DefaultWeights()})
+	for placed := 0; placed < pieces && g.GetState() == types.GameStatePlaying; {
+		g.Update(types.FrameDuration * 5)
+		if g.GetCurrentTetromino() != nil && player.Play(g) {
+			placed++
+		}
+	}
+}
+
+func TestSaveAndResumeContinuesGameAndReplay(t *testing.T) {
+	config := game.DefaultGameConfig()
+	config.Seed = 21
+	info, _ := game.FindMode("master")
+	original := game.NewGameWithMode(config, info.Create())
+	recorder := replay.NewRecorder(original, info.ID)
+	original.SetState(types.GameStatePlaying)
+	recorder.Start()
+	playPieces(original, 20)
+
+	save, err := New(original, info.ID, recorder)
+	if err != nil {
+		t.Fatalf("创建存档失败: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "save", types.SaveGameFile)
+	if err := Save(path, save); err != nil {
+		t.Fatalf("写入存档失败: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("读取存档失败: %v", err)
+	}
+	resumed, recorded, err := loaded.Restore()
+	if err != nil {
+		t.Fatalf("恢复存档失败: %v", err)
+	}
+	if recorded == nil {
+		t.Fatalf("存档应包含录像")
+	}
+	if resumed.GetMode().GetStatus(resumed) != original.GetMode().GetStatus(original) {
+		t.Errorf("模式状态应一起恢复，期望 %q，实际 %q",
+			original.GetMode().GetStatus(original), resumed.GetMode().GetStatus(resumed))
+	}
+
+	// 继续录制恢复的游戏，两局游戏之后的进行和录像都应一致
+	resumedRecorder := replay.NewRecorder(resumed, info.ID)
+	resumedRecorder.Resume(recorded)
+	resumed.SetState(types.GameStatePlaying)
+	playPieces(original, 20)
+	playPieces(resumed, 20)
+
+	if resumed.GetScore() != original.GetScore() || replay.BoardHash(resumed.GetBoard()) != replay.BoardHash(original.GetBoard()) {
+		t.Errorf("恢复的游戏应与原游戏相同，期望 %d 分，实际 %d 分", original.GetScore(), resumed.GetScore())
+	}
+	if err := replay.Verify(resumedRecorder.Stop()); err != nil {
+		t.Errorf("继续录制的录像应能一致地重现: %v", err)
+	}
+}
+
+func TestSaveRejectsUnknownMode(t *testing.T) {
+	g := game.NewGame(game.DefaultGameConfig())
+	if _, err := New(g, "", nil); err == nil {
+		t.Errorf("没有模式标识的游戏（如谜题）不应能保存")
+	}
+}
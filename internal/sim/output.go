@@ -0,0 +1,102 @@
+// Package sim 提供模拟结果的 JSON 和 CSV 输出
+package sim
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Report 一次批量模拟的完整结果
+type Report struct {
+	Mode      string   `json:"mode"`
+	Bot       string   `json:"bot"`
+	Seed      int64    `json:"seed"`
+	MaxPieces int      `json:"max_pieces"`
+	PieceTime int      `json:"piece_time_ms"`
+	Summary   Summary  `json:"summary"`
+	Results   []Result `json:"results"`
+}
+
+// NewReport 汇总配置和每局的结果
+func NewReport(config Config, results []Result) Report {
+	return Report{
+		Mode:      config.Mode,
+		Bot:       config.Bot,
+		Seed:      config.Seed,
+		MaxPieces: config.MaxPieces,
+		PieceTime: config.PieceTime,
+		Summary:   Summarize(results),
+		Results:   results,
+	}
+}
+
+// WriteJSON 以 JSON 格式输出完整结果
+func WriteJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// csvHeader CSV 输出的表头，与 Result 的 JSON 字段名一致
+var csvHeader = []string{"seed", "lines", "score", "level", "pieces", "time_ms", "pps", "wall_pps", "outcome"}
+
+// WriteCSV 以 CSV 格式输出每局的结果，每局一行
+func WriteCSV(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, result := range results {
+		record := []string{
+			strconv.FormatInt(result.Seed, 10),
+			strconv.Itoa(result.Lines),
+			strconv.Itoa(result.Score),
+			strconv.Itoa(result.Level),
+			strconv.Itoa(result.Pieces),
+			strconv.Itoa(result.Time),
+			strconv.FormatFloat(result.PPS, 'f', 3, 64),
+			strconv.FormatFloat(result.WallPPS, 'f', 3, 64),
+			result.Outcome,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// summaryCSVHeader 统计摘要 CSV 的表头，与 Summary 的 JSON 字段名一致
+var summaryCSVHeader = []string{
+	"games", "mean_lines", "median_lines", "min_lines", "max_lines", "mean_score", "median_score",
+	"mean_pieces", "mean_pps", "mean_wall_pps", "game_overs", "clears", "piece_limited",
+}
+
+// WriteSummaryCSV 以 CSV 格式输出所有游戏的统计摘要：表头和一行数据
+func WriteSummaryCSV(w io.Writer, summary Summary) error {
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 3, 64)
+	}
+	writer := csv.NewWriter(w)
+	records := [][]string{summaryCSVHeader, {
+		strconv.Itoa(summary.Games),
+		formatFloat(summary.MeanLines),
+		formatFloat(summary.MedianLines),
+		strconv.Itoa(summary.MinLines),
+		strconv.Itoa(summary.MaxLines),
+		formatFloat(summary.MeanScore),
+		formatFloat(summary.MedianScore),
+		formatFloat(summary.MeanPieces),
+		formatFloat(summary.MeanPPS),
+		formatFloat(summary.MeanWallPPS),
+		strconv.Itoa(summary.GameOvers),
+		strconv.Itoa(summary.Clears),
+		strconv.Itoa(summary.PieceLimited),
+	}}
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -0,0 +1,270 @@
+// Package sim 在没有界面的情况下由电脑玩家批量进行游戏并统计结果
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"goeluosifangkuai/internal/ai"
+	"goeluosifangkuai/internal/game"
+	"goeluosifangkuai/pkg/types"
+)
+
+// 可选的电脑玩家
+const (
+	BotAI          = "ai"           // 只考虑当前方块的特征评估
+	BotAILookahead = "ai-lookahead" // 同时考虑下一个方块的特征评估
+	BotRandom      = "random"       // 在所有可以到达的放置中随机选择
+)
+
+// Bots 返回所有可选的电脑玩家
+func Bots() []string {
+	return []string{BotAI, BotAILookahead, BotRandom}
+}
+
+// Config 批量模拟配置
+type Config struct {
+	Games     int             // 游戏局数，种子依次为 Seed, Seed+1, …
+	Seed      int64           // 第一局的种子
+	Bot       string          // 电脑玩家
+	Weights   ai.Weights      // 特征评估的权重
+	Mode      string          // 游戏模式标识，见 game.Modes
+	Game      game.GameConfig // 游戏配置（方块集、大方块变体等规则），种子由 Seed 决定
+	MaxPieces int             // 每局最多放置的方块数，0 表示不限（不会自行结束的模式必须限制）
+	PieceTime int             // 每放置一个方块经过的游戏时间（毫秒），决定每秒方块数的上限
+	Workers   int             // 并行进行游戏的 goroutine 数
+}
+
+// DefaultConfig 返回默认模拟配置
+func DefaultConfig() Config {
+	return Config{
+		Games:     10,
+		Seed:      1,
+		Bot:       BotAI,
+		Weights:   ai.DefaultWeights(),
+		Mode:      "classic",
+		Game:      game.DefaultGameConfig(),
+		MaxPieces: 1000,
+		PieceTime: 250,
+		Workers:   4,
+	}
+}
+
+// Result 一局游戏的结果
+type Result struct {
+	Seed    int64   `json:"seed"`
+	Lines   int     `json:"lines"`
+	Score   int     `json:"score"`
+	Level   int     `json:"level"`
+	Pieces  int     `json:"pieces"`
+	Time    int     `json:"time_ms"`  // 游戏时间（毫秒）
+	PPS     float64 `json:"pps"`      // 按游戏时间计算的每秒方块数，由 PieceTime 决定，反映的是规定的节奏
+	WallPPS float64 `json:"wall_pps"` // 按实际用时计算的每秒方块数，反映电脑玩家的计算速度，受机器和并行数影响
+	Outcome string  `json:"outcome"`  // 结束原因：gameover、clear 或 limit
+}
+
+// 一局游戏的结束原因
+const (
+	OutcomeGameOver = "gameover" // 堆到顶部
+	OutcomeClear    = "clear"    // 达成模式目标
+	OutcomeLimit    = "limit"    // 达到方块数上限
+)
+
+// Summary 所有游戏的统计
+type Summary struct {
+	Games        int     `json:"games"`
+	MeanLines    float64 `json:"mean_lines"`
+	MedianLines  float64 `json:"median_lines"`
+	MinLines     int     `json:"min_lines"`
+	MaxLines     int     `json:"max_lines"`
+	MeanScore    float64 `json:"mean_score"`
+	MedianScore  float64 `json:"median_score"`
+	MeanPieces   float64 `json:"mean_pieces"`
+	MeanPPS      float64 `json:"mean_pps"`
+	MeanWallPPS  float64 `json:"mean_wall_pps"`
+	GameOvers    int     `json:"game_overs"`
+	Clears       int     `json:"clears"`
+	PieceLimited int     `json:"piece_limited"`
+}
+
+// Run 并行进行所有游戏，结果按种子顺序排列，与并行数无关
+func Run(config Config) ([]Result, error) {
+	info, ok := game.FindMode(config.Mode)
+	if !ok {
+		return nil, fmt.Errorf("未知的游戏模式: %s", config.Mode)
+	}
+	if info.NeverEnds && config.MaxPieces <= 0 {
+		return nil, fmt.Errorf("%s 模式不会自行结束，需要限制每局的方块数", config.Mode)
+	}
+	if _, err := newBot(config, 0); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, config.Games)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				seed := config.Seed + int64(index)
+				bot, _ := newBot(config, seed)
+				gameConfig := config.Game
+				gameConfig.Seed = seed
+				// 每个任务只写入自己的位置，不需要加锁
+				results[index] = playGame(game.NewGameWithMode(gameConfig, info.Create()), bot, config)
+				results[index].Seed = seed
+			}
+		}()
+	}
+
+	for i := range results {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
+
+// bot 在游戏中放置当前方块的电脑玩家
+type bot interface {
+	Play(g game.Game) bool
+}
+
+// newBot 按配置创建电脑玩家，随机玩家使用每局的种子
+func newBot(config Config, seed int64) (bot, error) {
+	switch config.Bot {
+	case BotAI:
+		return ai.NewPlayer(ai.Config{Weights: config.Weights}), nil
+	case BotAILookahead:
+		return ai.NewPlayer(ai.Config{Weights: config.Weights, Lookahead: true}), nil
+	case BotRandom:
+		return &randomBot{random: rand.New(rand.NewSource(seed))}, nil
+	}
+	return nil, fmt.Errorf("未知的电脑玩家: %s", config.Bot)
+}
+
+// randomBot 在所有可以到达的放置中随机选择
+type randomBot struct {
+	random *rand.Rand
+}
+
+// Play 随机选择一个放置并执行
+func (b *randomBot) Play(g game.Game) bool {
+	paths := game.FindPaths(g.GetBoard(), g.GetCurrentTetromino())
+	if len(paths) == 0 {
+		return false
+	}
+	for _, input := range paths[b.random.Intn(len(paths))].Inputs {
+		g.ApplyInput(input)
+	}
+	return true
+}
+
+// playGame 由电脑玩家进行一局游戏。每放置一个方块推进 PieceTime 的游戏时间，
+// 使重力、出现延迟和限时模式的计时按真实的节奏进行
+func playGame(g game.Game, player bot, config Config) Result {
+	start := time.Now()
+	g.SetState(types.GameStatePlaying)
+	result := Result{Outcome: OutcomeLimit}
+
+	for g.GetState() == types.GameStatePlaying {
+		if config.MaxPieces > 0 && result.Pieces >= config.MaxPieces {
+			break
+		}
+
+		// 出现延迟期间没有当前方块，逐帧等待
+		if g.GetCurrentTetromino() == nil {
+			g.Update(types.FrameDuration)
+			continue
+		}
+
+		if !player.Play(g) {
+			break
+		}
+		result.Pieces++
+		if g.GetState() == types.GameStatePlaying {
+			g.Update(config.PieceTime)
+		}
+	}
+
+	switch g.GetState() {
+	case types.GameStateGameOver:
+		result.Outcome = OutcomeGameOver
+	case types.GameStateGameClear:
+		result.Outcome = OutcomeClear
+	}
+
+	result.Lines = g.GetLinesCleared()
+	result.Score = g.GetScore()
+	result.Level = g.GetLevel()
+	result.Time = g.GetElapsedTime()
+	if result.Time > 0 {
+		result.PPS = float64(result.Pieces) * 1000 / float64(result.Time)
+	}
+	if elapsed := time.Since(start).Seconds(); elapsed > 0 {
+		result.WallPPS = float64(result.Pieces) / elapsed
+	}
+	return result
+}
+
+// Summarize 统计所有游戏的结果
+func Summarize(results []Result) Summary {
+	summary := Summary{Games: len(results)}
+	if len(results) == 0 {
+		return summary
+	}
+
+	lines := make([]int, len(results))
+	scores := make([]int, len(results))
+	for i, result := range results {
+		lines[i] = result.Lines
+		scores[i] = result.Score
+		summary.MeanLines += float64(result.Lines)
+		summary.MeanScore += float64(result.Score)
+		summary.MeanPieces += float64(result.Pieces)
+		summary.MeanPPS += result.PPS
+		summary.MeanWallPPS += result.WallPPS
+
+		switch result.Outcome {
+		case OutcomeGameOver:
+			summary.GameOvers++
+		case OutcomeClear:
+			summary.Clears++
+		case OutcomeLimit:
+			summary.PieceLimited++
+		}
+	}
+
+	count := float64(len(results))
+	summary.MeanLines /= count
+	summary.MeanScore /= count
+	summary.MeanPieces /= count
+	summary.MeanPPS /= count
+	summary.MeanWallPPS /= count
+
+	sort.Ints(lines)
+	sort.Ints(scores)
+	summary.MinLines = lines[0]
+	summary.MaxLines = lines[len(lines)-1]
+	summary.MedianLines = median(lines)
+	summary.MedianScore = median(scores)
+	return summary
+}
+
+// median 返回已排序数列的中位数
+func median(sorted []int) float64 {
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[middle-1]+sorted[middle]) / 2
+	}
+	return float64(sorted[middle])
+}
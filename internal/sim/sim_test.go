@@ -0,0 +1,102 @@
+package sim
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunIsDeterministic(t *testing.T) {
+	config := DefaultConfig()
+	config.Games = 3
+	config.MaxPieces = 30
+
+	config.Workers = 1
+	serial, err := Run(config)
+	if err != nil {
+		t.Fatalf("模拟失败: %v", err)
+	}
+	config.Workers = 3
+	parallel, err := Run(config)
+	if err != nil {
+		t.Fatalf("模拟失败: %v", err)
+	}
+
+	// 按实际用时计算的速度每次运行都不同，不参与比较
+	for i := range serial {
+		if serial[i].WallPPS <= 0 || parallel[i].WallPPS <= 0 {
+			t.Errorf("第 %d 局应记录按实际用时计算的每秒方块数", i+1)
+		}
+		serial[i].WallPPS, parallel[i].WallPPS = 0, 0
+	}
+	if !reflect.DeepEqual(serial, parallel) {
+		t.Errorf("相同种子的结果不应受并行数影响:\n%+v\n%+v", serial, parallel)
+	}
+	for i, result := range serial {
+		if result.Seed != config.Seed+int64(i) {
+			t.Errorf("第 %d 局的种子应为 %d，实际为 %d", i+1, config.Seed+int64(i), result.Seed)
+		}
+		if result.Pieces != config.MaxPieces || result.Outcome != OutcomeLimit {
+			t.Errorf("第 %d 局应放置 %d 个方块后停止，实际为 %+v", i+1, config.MaxPieces, result)
+		}
+	}
+}
+
+func TestRunRejectsUnknownModeAndBot(t *testing.T) {
+	config := DefaultConfig()
+	config.Mode = "unknown"
+	if _, err := Run(config); err == nil {
+		t.Errorf("未知的游戏模式应返回错误")
+	}
+
+	config = DefaultConfig()
+	config.Bot = "unknown"
+	if _, err := Run(config); err == nil {
+		t.Errorf("未知的电脑玩家应返回错误")
+	}
+
+	for _, mode := range []string{"zen", "zen-no-gravity", "finesse", "perfect-clear"} {
+		config = DefaultConfig()
+		config.Mode = mode
+		config.MaxPieces = 0
+		if _, err := Run(config); err == nil {
+			t.Errorf("%s 模式不限方块数应返回错误", mode)
+		}
+	}
+}
+
+func TestSummarizeAndCSV(t *testing.T) {
+	results := []Result{
+		{Seed: 1, Lines: 10, Score: 100, Outcome: OutcomeGameOver},
+		{Seed: 2, Lines: 30, Score: 300, Outcome: OutcomeLimit},
+		{Seed: 3, Lines: 20, Score: 200, Outcome: OutcomeLimit},
+		{Seed: 4, Lines: 40, Score: 400, Outcome: OutcomeClear},
+	}
+
+	summary := Summarize(results)
+	if summary.MeanLines != 25 || summary.MedianLines != 25 || summary.MinLines != 10 || summary.MaxLines != 40 {
+		t.Errorf("行数统计错误: %+v", summary)
+	}
+	if summary.GameOvers != 1 || summary.PieceLimited != 2 || summary.Clears != 1 {
+		t.Errorf("结束原因统计错误: %+v", summary)
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteCSV(&buffer, results); err != nil {
+		t.Fatalf("输出 CSV 失败: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != len(results)+1 || !strings.HasPrefix(lines[1], "1,10,100,") {
+		t.Errorf("CSV 应包含表头和每局一行，实际为:\n%s", buffer.String())
+	}
+
+	buffer.Reset()
+	if err := WriteSummaryCSV(&buffer, summary); err != nil {
+		t.Fatalf("输出统计摘要 CSV 失败: %v", err)
+	}
+	lines = strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "games,mean_lines,") || !strings.HasPrefix(lines[1], "4,25.000,25.000,10,40,") {
+		t.Errorf("统计摘要 CSV 应包含表头和一行数据，实际为:\n%s", buffer.String())
+	}
+}
@@ -0,0 +1,133 @@
+// Package userdata 管理保存在用户数据目录中的文件：原子写入、备份和损坏恢复
+package userdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"goeluosifangkuai/pkg/types"
+)
+
+// Dir 返回用户数据目录，不存在时创建
+func Dir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("无法确定用户数据目录: %w", err)
+	}
+	dir := filepath.Join(base, types.UserDataDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Path 返回用户数据目录中的文件路径
+func Path(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// backupPath 返回文件的备份路径
+func backupPath(path string) string {
+	return path + ".bak"
+}
+
+// WriteFile 原子地写入文件：先写入临时文件并同步到磁盘，再替换原文件，原文件保留为备份。
+// 写入在任何一步中断时，原文件或备份中至少有一个是完整的
+func WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+
+	if err := os.Rename(path, backupPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// Remove 删除 WriteFile 写入的文件及其备份，文件不存在时不报错
+func Remove(path string) error {
+	for _, name := range []string{path, backupPath(path)} {
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON 将 v 编码为 JSON 后原子地写入文件
+func WriteJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data)
+}
+
+// ReadJSON 读取 WriteJSON 写入的文件。文件不存在时 v 保持不变；文件损坏时将其重命名为 .corrupt 保留，
+// 改为读取备份，此时 recovered 为 true。文件和备份都不可用时同样返回 recovered 为 true，v 保持不变
+func ReadJSON(path string, v interface{}) (recovered bool, err error) {
+	err = readJSON(path, v)
+	if err == nil {
+		return false, nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// 替换文件的过程中中断时只有备份
+		if backupErr := readJSON(backupPath(path), v); backupErr == nil {
+			return true, nil
+		}
+		return false, nil
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.Is(err, errEmpty):
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return false, err
+		}
+		readJSON(backupPath(path), v)
+		return true, nil
+	}
+	return false, err
+}
+
+// errEmpty 文件为空，写入时被截断
+var errEmpty = errors.New("文件为空")
+
+// readJSON 读取并解析 JSON 文件
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return errEmpty
+	}
+	return json.Unmarshal(data, v)
+}
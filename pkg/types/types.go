@@ -10,14 +10,17 @@ type Position struct {
 type Color int
 
 const (
-	ColorEmpty Color = iota
-	ColorI           // 青色 - I形方块
-	ColorO           // 黄色 - O形方块
-	ColorT           // 紫色 - T形方块
-	ColorS           // 绿色 - S形方块
-	ColorZ           // 红色 - Z形方块
-	ColorJ           // 蓝色 - J形方块
-	ColorL           // 橙色 - L形方块
+	ColorEmpty   Color = iota
+	ColorI             // 青色 - I形方块
+	ColorO             // 黄色 - O形方块
+	ColorT             // 紫色 - T形方块
+	ColorS             // 绿色 - S形方块
+	ColorZ             // 红色 - Z形方块
+	ColorJ             // 蓝色 - J形方块
+	ColorL             // 橙色 - L形方块
+	ColorGarbage       // 灰色 - 垃圾行
+
+	ColorCustom Color = 100 // 自定义颜色的起始值，具体颜色由方块集定义
 )
 
 // TetrominoType 表示俄罗斯方块的七种基本形状
@@ -31,6 +34,16 @@ const (
 	TetrominoZ
 	TetrominoJ
 	TetrominoL
+
+	TetrominoCustom TetrominoType = 100 // 自定义方块集中方块类型的起始值
+)
+
+// Randomizer 表示方块的随机生成方式
+type Randomizer int
+
+const (
+	RandomizerRandom Randomizer = iota // 每个方块独立随机
+	RandomizerBag                      // 7-bag：每一袋中方块集的每种方块各出现一次
 )
 
 // Direction 表示旋转方向
@@ -42,6 +55,25 @@ const (
 	DirectionRight
 )
 
+// Input 表示玩家的一次操作输入
+type Input int
+
+const (
+	InputLeft         Input = iota // 左移一格
+	InputRight                     // 右移一格
+	InputSoftDrop                  // 下移一格
+	InputHardDrop                  // 快速下降并固定
+	InputRotateRight               // 顺时针旋转
+	InputRotateLeft                // 逆时针旋转
+	InputHold                      // 暂存
+	InputShiftLeft                 // 按下左移键，按住超过 DAS 后自动重复
+	InputShiftRight                // 按下右移键，按住超过 DAS 后自动重复
+	InputReleaseLeft               // 松开左移键
+	InputReleaseRight              // 松开右移键
+	InputUndo                      // 撤销上一次放置
+	InputRedo                      // 重做被撤销的放置
+)
+
 // GameState 表示游戏状态
 type GameState int
 
@@ -50,12 +82,23 @@ const (
 	GameStatePlaying
 	GameStatePaused
 	GameStateGameOver
+	GameStateGameClear // 达成模式目标，与堆到顶部的游戏结束相区分
 )
 
 // 游戏配置常量
 const (
 	BoardWidth  = 10 // 游戏棋盘宽度
 	BoardHeight = 20 // 游戏棋盘高度
+	BigScale    = 2  // 大方块变体中每个逻辑格子占用的显示格数（每边）
+
+	// 逻辑时钟配置（毫秒）
+	FrameDuration = 16 // 每个逻辑帧的时长
+
+	// 重力与操作配置
+	GravityUnit = 256              // 重力单位：每帧下落一格
+	Gravity20G  = 20 * GravityUnit // 20G：方块出现后立即落到底部
+	DefaultDAS  = 10               // 默认自动重复移动延迟（帧）
+	DefaultARR  = 2                // 默认自动重复移动间隔（帧）
 
 	// 游戏速度配置（毫秒）
 	InitialDropInterval = 1000 // 初始下落间隔
@@ -65,4 +108,44 @@ const (
 	// 得分配置
 	ScorePerLine         = 100 // 每消除一行的基础分数
 	ScoreLevelMultiplier = 10  // 等级分数倍数
+
+	// 全消奖励（乘以等级），按照指南规则在消行得分之外额外计算
+	PerfectClearSingle    = 800  // 单消全消
+	PerfectClearDouble    = 1200 // 双消全消
+	PerfectClearTriple    = 1800 // 三消全消
+	PerfectClearTetris    = 2000 // 四消全消
+	PerfectClearB2BTetris = 3200 // 连续四消（Back-to-Back）全消
+
+	// 模式配置
+	UltraTimeLimit     = 2 * 60 * 1000 // 限时模式默认时间限制（毫秒）
+	SprintGoalLines    = 40            // 竞速模式默认需要消除的行数
+	MarathonGoalLines  = 150           // 马拉松模式默认目标行数
+	MarathonMaxLevel   = 15            // 马拉松模式默认最高等级
+	VariableGoalFactor = 5             // 变动目标制下每级所需行数为 5×等级
+	DigGoalLines       = 18            // 挖掘模式默认需要清除的垃圾行数
+	DigGarbageHeight   = 10            // 挖掘模式场上保持的垃圾行高度
+	ZenUndoLimit       = 10            // 禅模式默认可撤销的步数
+	MasterMaxLevel     = 999           // 大师模式的最高等级
+	FadeDelay          = 300           // 渐隐模式中方块固定后保持可见的帧数
+	FadeDuration       = 60            // 渐隐模式中方块从可见到隐形的帧数
+	PerfectClearHeight = 4             // 全消练习中方块堆叠允许的最高行数
+)
+
+// 数据文件配置
+const (
+	PuzzleDir     = "puzzles"         // 谜题文件所在目录
+	PieceSetDir   = "pieces"          // 方块集文件所在目录
+	AIWeightsFile = "ai_weights.json" // 训练得到的电脑玩家权重文件
+	ReplayDir     = "replays"         // 用户数据目录中的录像目录
+	ReplayExt     = ".replay"         // 录像文件的扩展名
+	SaveGameFile  = "savegame.json"   // 保存的未完成游戏
+)
+
+// 用户数据配置，文件保存在用户配置目录（如 ~/.config）下的 UserDataDir 目录中
+const (
+	UserDataDir    = "goeluosifangkuai" // 用户数据目录名
+	HighScoreFile  = "highscores.json"  // 排行榜文件
+	HistoryFile    = "history.jsonl"    // 游戏历史，每局一行 JSON，只追加
+	ProfileDir     = "profiles"         // 玩家档案目录，每个档案一个子目录
+	HighScoreLimit = 10                 // 每个排行榜保留的成绩数
 )